@@ -3,27 +3,45 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	app "go-boilerplate/internal/apps"
 	"go-boilerplate/internal/configs"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run starts the application and returns the process exit code: 0 on a clean shutdown,
+// 78 for an invalid configuration, 69 when a dependency is unavailable and 70 for any other
+// failure (see app.ExitCode).
+func run() int {
 	// how to run:
 	// go run cmd/main.go --mode http --stage dev
 	// go run cmd/main.go --mode rabbit --stage dev
+	// go run cmd/main.go --mode outbox-relay --stage dev
+	// go run cmd/main.go --stage prod --config deploy/config.yaml --set DB_HOST=db-1
+	// go run cmd/main.go --stage prod --print-config
 
 	// how to build:
 	// go build -o bin/app cmd/main.go
 	// ./bin/app --mode http --stage dev
 
-	modeFlag := flag.String("mode", "", "application mode: http or rabbit")
+	modeFlag := flag.String("mode", "", "application mode: http, rabbit or outbox-relay")
 	stageFlag := flag.String("stage", "", "stage name: dev, staging, prod, etc.")
+	configFlag := flag.String("config", "", "base YAML or TOML config file (default: config.yaml if present)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with its sources and exit")
+	overrides := configs.Overrides{}
+	flag.Var(overrides, "set", "override a config key, e.g. --set DB_HOST=localhost (repeatable)")
 	flag.Parse()
 
 	mode := strings.ToLower(strings.TrimSpace(*modeFlag))
@@ -39,16 +57,80 @@ func main() {
 	if stage == "" {
 		stage = strings.TrimSpace(os.Getenv("STAGE"))
 	}
-	cfg := configs.MustLoad(mode, stage)
+	opts := configs.LoadOptions{
+		Mode:       mode,
+		Stage:      stage,
+		ConfigFile: *configFlag,
+		Overrides:  overrides,
+	}
+	if *printConfig {
+		cfg, prov, err := configs.LoadLayered(opts)
+		fmt.Printf("# effective configuration: mode=%s stage=%s\n", mode, stage)
+		_ = configs.PrintConfig(os.Stdout, cfg, prov)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+			return app.ExitConfig
+		}
+		return app.ExitOK
+	}
+
+	cfg, err := configs.Load(opts)
+	if err != nil {
+		return fatal(bootstrapLogger(), app.ConfigError("load config", err))
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
 	a, err := app.New(cfg)
 	if err != nil {
-		panic(err)
+		return fatal(bootstrapLogger(), err)
 	}
+	// Reload on SIGHUP or when a config/dotenv/htpasswd file changes
+	files := configs.SourceFiles(opts)
+	if cfg.BasicAuthUsersFile != "" {
+		files = append(files, cfg.BasicAuthUsersFile)
+	}
+	a.Watcher = configs.NewWatcher(cfg, func() (configs.Config, error) {
+		c, _, err := configs.LoadLayered(opts)
+		return c, err
+	}, a.Logger, configs.WatcherOptions{Files: files})
+
+	code := app.ExitOK
 	if err := a.Run(ctx, app.Mode(mode)); err != nil {
-		panic(err)
+		code = fatal(a.Logger, err)
+	}
+
+	// Release connections and flush buffered logs, bounded by the shutdown timeout
+	closeCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutMS)*time.Millisecond)
+	defer cancel()
+	if err := a.Close(closeCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "shutdown: %v\n", err)
+		if code == app.ExitOK {
+			code = app.ExitSoftware
+		}
+	}
+	return code
+}
+
+// fatal logs err as a single structured line and returns its exit code.
+func fatal(logger *zap.Logger, err error) int {
+	code := app.ExitCode(err)
+	// No stack trace: the kind and wrapped message already say what failed.
+	logger.WithOptions(zap.AddStacktrace(zapcore.FatalLevel)).Error("application failed",
+		zap.String("kind", app.KindOf(err).String()),
+		zap.Int("exit_code", code),
+		zap.Error(err),
+	)
+	_ = logger.Sync()
+	return code
+}
+
+// bootstrapLogger is used for failures that happen before the application logger exists.
+func bootstrapLogger() *zap.Logger {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return zap.NewNop()
 	}
+	return logger
 }
@@ -2,6 +2,8 @@ package app
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"go-boilerplate/internal/configs"
 	"go-boilerplate/internal/dbs"
@@ -9,11 +11,19 @@ import (
 	"go-boilerplate/internal/repositories"
 	"go-boilerplate/internal/services"
 	"go-boilerplate/internal/transports/http"
+	"go-boilerplate/internal/transports/rabbit"
+	"go-boilerplate/internal/utils/authz"
+	"go-boilerplate/internal/utils/cache"
+	"go-boilerplate/internal/utils/health"
+	"go-boilerplate/internal/utils/idempotency"
+	"go-boilerplate/internal/utils/jobs"
+	"go-boilerplate/internal/utils/ratelimit"
+	"go-boilerplate/internal/utils/secrets"
 	"go-boilerplate/internal/utils/validation"
-	"log"
-	"os"
+	"go-boilerplate/internal/workers"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
@@ -38,60 +48,370 @@ const (
 	ModeRabbit Mode = "rabbit"
 	// ModeGRPC represents the gRPC server mode.
 	ModeGRPC Mode = "grpc"
+	// ModeOutboxRelay represents the worker that publishes outbox events to RabbitMQ.
+	ModeOutboxRelay Mode = "outbox-relay"
 )
 
 // App creates a new App instance with the provided configuration.
 type App struct {
 	Cfg    configs.Config
 	Logger *zap.Logger
+	// Level is the logger's minimum level; it follows LOG_LEVEL on config reloads.
+	Level zap.AtomicLevel
+	// Watcher, when set, hot-reloads the configuration while the app runs.
+	Watcher *configs.Watcher
+	// Lifecycle starts the components Run needs and stops them on Close. Components may be
+	// appended before Run; they start together with the built-in ones.
+	Lifecycle *Lifecycle
+	// Health holds the readiness checks of the dependencies the mode uses.
+	Health *health.Registry
+
+	stopLogs func(context.Context) error
+}
+
+// OnClose registers fn to release a resource when the app is closed. Closers run in the
+// reverse order of registration, so a component is closed before the ones it depends on.
+func (a *App) OnClose(name string, fn func(context.Context) error) {
+	a.Lifecycle.OnStop(name, fn)
+}
+
+// Close stops the started components in reverse order and then flushes the logger,
+// including logs still buffered for Elasticsearch. It gives up on whatever is left when ctx
+// is done. Close must be called once, after Run has returned; the logger must not be used
+// afterwards.
+func (a *App) Close(ctx context.Context) error {
+	var errs []error
+	if err := a.Lifecycle.Stop(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	a.Logger.Info("application stopped")
+	_ = a.Logger.Sync()
+	if a.stopLogs != nil {
+		if err := a.stopLogs(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("flush logs: %w", err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // Run initializes the application based on the provided mode and context.
+// Only the infrastructure the mode declares in Requirements is initialised. Components are
+// registered on a.Lifecycle and started in dependency order; if one fails, those already
+// started are stopped again. Close stops the rest.
+// Failures are returned as *Error so the caller can tell a bad configuration from an
+// unavailable dependency or a runtime failure (see ExitCode).
 func (a *App) Run(ctx context.Context, mode Mode) error {
-	pool, _err := dbs.NewMySQLDB(a.Cfg)
-	if condition := _err != nil; condition {
-		a.Logger.Error("failed to connect to database", zap.Error(_err))
-		return fmt.Errorf("failed to connect to database: %w", _err)
+	switch mode {
+	case ModeHTTP, ModeOutboxRelay:
+	case ModeRabbit, ModeGRPC:
+		// r := handler.NewPatternRouter()
+		// consumer := rabbit.NewResilientConsumer(a.Cfg, r, a.Logger)
+		// return consumer.Run(ctx)
+		// return grpcx.RunGRPCServer(ctx, svc, a.Cfg.GRPCAddr)
+		return errorf(KindConfig, "run", "please setup the %s mode first", mode)
+	default:
+		return errorf(KindConfig, "run", "unknown mode: %s", mode)
 	}
-	a.Logger.Info("connected to database successfully")
 
-	v := validation.GetValidator()
+	var (
+		db          *dbs.DB
+		pool        *sql.DB // the primary, for components that must not read stale data
+		policy      *authz.Policy
+		limiter     ratelimit.Store = ratelimit.NewMemoryStore()
+		idemStore   idempotency.Store
+		apiKeyRepo  repositories.APIKeyRepository
+		lookupCache cache.Cache                            // nil: repository lookups are not cached
+		pub         = rabbit.NewPublisher(a.Cfg, a.Logger) // connects on first use
+	)
+	lc := a.Lifecycle
+	a.OnClose("rabbit publisher", func(context.Context) error { return pub.Close() })
+
+	// Infrastructure: only what the mode declares is initialised (see Requirements)
+	needs := Requirements(mode, a.Cfg)
+	if need, ok := needs[DepDatabase]; ok {
+		lc.Append(a.dependencyHook(DepDatabase, need, Hook{
+			// Connection retries have their own deadline (DB_CONNECT_TIMEOUT_MS)
+			StartTimeout: time.Duration(a.Cfg.DbConnectTimeoutMS+a.Cfg.DbPingTimeoutMS)*time.Millisecond + time.Second,
+			OnStart: func(ctx context.Context) error {
+				var err error
+				if pool, err = dbs.NewMySQLDB(ctx, a.Cfg, a.Logger); err != nil {
+					a.Logger.Error("failed to connect to database", zap.Error(err))
+					return DependencyError("connect to database", err)
+				}
+				replicas, err := dbs.NewMySQLReplicas(a.Cfg, a.Logger)
+				if err != nil {
+					pool.Close()
+					return ConfigError("open database replicas", err)
+				}
+				db = dbs.NewDB(pool, replicas, a.Logger, dbs.DBOptions{
+					StickyFor:        time.Duration(a.Cfg.DbReadYourWritesMS) * time.Millisecond,
+					HealthInterval:   time.Duration(a.Cfg.DbReplicaHealthIntervalMS) * time.Millisecond,
+					FailureThreshold: a.Cfg.DbReplicaFailureThreshold,
+				})
+				a.Logger.Info("connected to database successfully", zap.Int("replicas", len(replicas)))
+				return nil
+			},
+			OnStop: func(context.Context) error { return db.Close() },
+		}, func(ctx context.Context) error {
+			if pool == nil {
+				return errNotConnected
+			}
+			return pool.PingContext(ctx)
+		}))
+		if len(a.Cfg.DbReplicaHosts) > 0 {
+			// Eject replicas that stop answering and restore them when they recover
+			lc.Append(BackgroundHook("database replicas", func(ctx context.Context) {
+				if db != nil {
+					db.MonitorReplicas(ctx)
+				}
+			}, string(DepDatabase)))
+		}
+	}
+	if need, ok := needs[DepRedis]; ok {
+		// Rate limiter store and lookup cache shared through Redis across replicas. The client
+		// is kept when Redis is down at startup: it reconnects by itself, and meanwhile rate
+		// limits are counted per replica and lookups go to the database.
+		var rdb *redis.Client
+		lc.Append(a.dependencyHook(DepRedis, need, Hook{
+			OnStart: func(context.Context) error {
+				var err error
+				rdb, err = dbs.NewRedisClient(a.Cfg)
+				a.OnClose("redis", func(context.Context) error { return rdb.Close() })
+				if a.Cfg.RateLimitStore == "redis" {
+					limiter = ratelimit.NewFallbackStore(ratelimit.NewRedisStore(rdb, "ratelimit:"), ratelimit.NewMemoryStore(), func(err error) {
+						if err != nil {
+							a.Logger.Warn("redis unavailable, rate limits are counted per replica", zap.Error(err))
+							return
+						}
+						a.Logger.Info("redis available again, rate limits are shared")
+					})
+				}
+				if a.Cfg.CacheStore == "redis" {
+					lookupCache = cache.NewRedisCache(rdb, "cache:")
+				}
+				if err != nil {
+					return DependencyError("connect to redis", err)
+				}
+				return nil
+			},
+		}, func(ctx context.Context) error {
+			if rdb == nil {
+				return errNotConnected
+			}
+			return rdb.Ping(ctx).Err()
+		}))
+	}
+	if need, ok := needs[DepRabbit]; ok {
+		// The publisher reconnects on its own, so the check also retries the connection.
+		lc.Append(a.dependencyHook(DepRabbit, need, Hook{
+			OnStart: func(ctx context.Context) error {
+				if err := pub.Check(ctx); err != nil {
+					return DependencyError("connect to rabbit", err)
+				}
+				return nil
+			},
+		}, pub.Check))
+	}
+
+	// Apply hot-reloadable settings as the configuration changes
+	if a.Watcher != nil {
+		a.Watcher.Subscribe(func(cfg configs.Config) {
+			if a.Level == (zap.AtomicLevel{}) {
+				return
+			}
+			if err := a.Level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+				a.Logger.Warn("invalid log level", zap.String("level", cfg.LogLevel), zap.Error(err))
+			}
+		})
+		lc.Append(BackgroundHook("config watcher", a.Watcher.Run))
+	}
+
+	// Re-read secret:// references and apply rotations through the watcher
+	if a.Watcher != nil && len(a.Cfg.SecretRefs()) > 0 && a.Cfg.SecretsRefreshIntervalMS > 0 {
+		lc.Append(BackgroundHook("secrets refresh", func(ctx context.Context) {
+			a.refreshSecrets(ctx, configs.NewSecretProvider(a.Cfg), time.Duration(a.Cfg.SecretsRefreshIntervalMS)*time.Millisecond)
+		}))
+	}
+
+	// Components used by the HTTP transport
+	if mode == ModeHTTP {
+		// Authorization policy shared by every route group
+		if a.Cfg.AuthzPolicyFile != "" {
+			lc.Append(Hook{
+				Name: "authorization policy",
+				OnStart: func(context.Context) error {
+					var err error
+					if policy, err = authz.LoadPolicy(a.Cfg.AuthzPolicyFile); err != nil {
+						a.Logger.Error("failed to load authorization policy", zap.Error(err))
+						return ConfigError("load authorization policy", err)
+					}
+					return nil
+				},
+			})
+		}
+
+		// Idempotency-Key records for retry-safe POSTs
+		lc.Append(Hook{
+			Name:      "idempotency store",
+			DependsOn: []string{string(DepDatabase)},
+			OnStart: func(context.Context) error {
+				idemOpts := idempotency.Options{
+					TTL:         time.Duration(a.Cfg.IdempotencyTTLMS) * time.Millisecond,
+					LockTimeout: time.Duration(a.Cfg.IdempotencyLockTimeoutMS) * time.Millisecond,
+				}
+				idemStore = idempotency.NewMemoryStore(idemOpts)
+				if a.Cfg.IdempotencyStore == "db" {
+					idemStore = idempotency.NewSQLStore(pool, idemOpts)
+				}
+				return nil
+			},
+		})
+		lc.Append(BackgroundHook("idempotency purge", func(ctx context.Context) {
+			a.purgeIdempotencyKeys(ctx, idemStore)
+		}, "idempotency store"))
+
+		// API keys: from the api_keys table or a YAML file
+		lc.Append(Hook{
+			Name:      "api keys",
+			DependsOn: []string{string(DepDatabase)},
+			OnStart: func(context.Context) error {
+				if a.Cfg.APIKeyStore != "file" {
+					apiKeyRepo = repositories.NewAPIKeyRepository(pool)
+					return nil
+				}
+				var err error
+				if apiKeyRepo, err = repositories.NewFileAPIKeyRepository(a.Cfg.APIKeysFile); err != nil {
+					a.Logger.Error("failed to load api keys", zap.Error(err))
+					return ConfigError("load api keys", err)
+				}
+				return nil
+			},
+		})
+	}
+
+	if err := lc.Start(ctx); err != nil {
+		return err
+	}
 
 	// Initialize Example repositories and services
-	repo := repositories.NewExampleRepository(pool)
+	v := validation.GetValidator()
+	auditRepo := repositories.NewAuditRepository(db, repositories.MySQL)
+	repo := repositories.NewExampleRepository(db, repositories.MySQL, auditRepo) // reads from the replicas
+	if a.Cfg.CacheStore == "memory" {
+		lookupCache = cache.NewMemoryCache(a.Cfg.CacheMaxEntries)
+	}
+	if lookupCache != nil {
+		repo = repositories.NewCachedExampleRepository(repo, lookupCache, repositories.CacheOptions{
+			TTL:         time.Duration(a.Cfg.CacheTTLMS) * time.Millisecond,
+			NegativeTTL: time.Duration(a.Cfg.CacheNegativeTTLMS) * time.Millisecond,
+			Log:         a.Logger,
+		})
+	}
 	//add more repositories if needed
 
+	// Background jobs for large example imports; they are waited for, up to the stop
+	// timeout, before the database closes
+	var imports *jobs.Runner
+	if mode == ModeHTTP {
+		imports = jobs.NewRunner(jobs.Options{
+			Workers:   a.Cfg.BatchWorkers,
+			MaxQueued: a.Cfg.BatchMaxQueued,
+			Retention: time.Duration(a.Cfg.BatchJobRetentionMS) * time.Millisecond,
+		})
+		a.OnClose("example imports", imports.Shutdown)
+	}
+
+	// Authorization for every transport; the default covers those that attach no Authorizer
+	// to their contexts, so their denials are logged as well
+	authorizer := authz.New(policy, a.Logger)
+	authz.SetDefault(authorizer)
+
 	// Create a service register to hold all services
 	// This is where the application services are registered.
 	// The services are responsible for handling business logic and interacting with repositories.
 	serviceRegister := services.Register{
-		ExampleService: services.NewExampleService(repo, a.Logger, a.Cfg, v),
+		ExampleService: services.NewExampleService(repo, a.Logger, a.Cfg, v, pub, imports),
+		APIKeyService:  services.NewAPIKeyService(apiKeyRepo, a.Logger, a.Cfg, v),
+		AuditService:   services.NewAuditService(auditRepo, v),
+		Publisher:      pub,
 		// add more services to the service register if needed
 	}
 
 	switch mode {
 	case ModeHTTP:
-		h := http.NewHTTPServer(serviceRegister, a.Cfg)
+		h := http.NewHTTPServer(serviceRegister, a.Cfg, http.Deps{
+			Logger:      a.Logger,
+			Authorizer:  authorizer,
+			Limiter:     limiter,
+			Idempotency: idemStore,
+			Config:      a.Watcher,
+			Health:      a.Health,
+		})
 		// Start the HTTP server with the provided context and address from the configuration.
 		// The server will listen for incoming HTTP requests and handle them using the registered routes.
 		a.Logger.Info("starting HTTP server", zap.String("address", a.Cfg.HTTPAddr))
-		return h.Run(ctx, a.Cfg.HTTPAddr)
-	case ModeRabbit:
-		// r := handler.NewPatternRouter()
-		// consumer := rabbit.NewResilientConsumer(a.Cfg, r, a.Logger)
-		// return consumer.Run(ctx)
-		return fmt.Errorf("please setup the %s mode first", mode)
-	case ModeGRPC:
-		// return grpcx.RunGRPCServer(ctx, svc, a.Cfg.GRPCAddr)
-		return fmt.Errorf("please setup the %s mode first", mode)
+		if err := h.Run(ctx, a.Cfg.HTTPAddr); err != nil {
+			return RuntimeError("http server", err)
+		}
+		return nil
+	case ModeOutboxRelay:
+		// Publish events written to the outbox by the repositories
+		relay := workers.NewOutboxRelay(repositories.NewOutboxRepository(pool, repositories.MySQL), pub, a.Logger, workers.OutboxRelayOptions{
+			PollInterval: time.Duration(a.Cfg.OutboxPollIntervalMS) * time.Millisecond,
+			BatchSize:    a.Cfg.OutboxBatchSize,
+			MaxAttempts:  a.Cfg.OutboxMaxAttempts,
+			Retention:    time.Duration(a.Cfg.OutboxRetentionHours) * time.Hour,
+		})
+		if err := relay.Run(ctx); err != nil {
+			return RuntimeError("outbox relay", err)
+		}
+		return nil
 	default:
-		return fmt.Errorf("unknown mode: %s", mode)
+		return errorf(KindConfig, "run", "unknown mode: %s", mode)
+	}
+}
+
+// purgeIdempotencyKeys deletes expired idempotency records every hour until ctx is done.
+func (a *App) purgeIdempotencyKeys(ctx context.Context, store idempotency.Store) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := store.Purge(ctx, now)
+			if err != nil {
+				a.Logger.Warn("failed to purge idempotency keys", zap.Error(err))
+				continue
+			}
+			a.Logger.Info("purged idempotency keys", zap.Int64("deleted", n))
+		}
+	}
+}
+
+// refreshSecrets re-resolves the secret references in the configuration every interval
+// until ctx is done and hands rotated values to the config watcher, which applies them
+// to its subscribers.
+func (a *App) refreshSecrets(ctx context.Context, p secrets.Provider, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Watcher.RefreshSecrets(ctx, p); err != nil {
+				a.Logger.Warn("failed to refresh secrets", zap.Error(err))
+			}
+		}
 	}
 }
 
 // New initializes the application with the provided configuration.
 // It sets up the logger and prepares the application for running in the specified mode.
-// It returns an App instance or an error if initialization fails.
+// It returns an App instance or an error if initialization fails. Call Close when done.
 func New(cfg configs.Config) (*App, error) {
 
 	// Prepare Elasticsearch options (could come from cfg)
@@ -107,17 +427,19 @@ func New(cfg configs.Config) (*App, error) {
 	}
 
 	// Initialize logger
-	logger, stopES, err := logs.NewWithElastic(cfg.AppName, os.Getenv("LOG_TZ"), esOpts)
-	if err != nil {
-		log.Fatalf("failed to init logger: %v", err)
+	level := zap.NewAtomicLevel()
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		level.SetLevel(zap.InfoLevel)
 	}
-	defer func() {
-		stopES()
-		_ = logger.Sync()
-	}()
-
+	esOpts.Level = level
+	// The Elasticsearch sink keeps flushing in the background until Close.
+	logger, stopLogs, err := logs.NewWithElastic(cfg.AppName, cfg.LogTZ, esOpts)
 	if err != nil {
-		return nil, err
+		return nil, ConfigError("init logger", err)
 	}
-	return &App{Cfg: cfg, Logger: logger}, nil
+	lc := NewLifecycle(logger, LifecycleOptions{
+		StartTimeout: time.Duration(cfg.StartTimeoutMS) * time.Millisecond,
+		StopTimeout:  time.Duration(cfg.ShutdownTimeoutMS) * time.Millisecond,
+	})
+	return &App{Cfg: cfg, Logger: logger, Level: level, Lifecycle: lc, Health: health.NewRegistry(), stopLogs: stopLogs}, nil
 }
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/configs"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeElastic records the messages of the documents sent to the Bulk API.
+func fakeElastic(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		body, _ := io.ReadAll(r.Body)
+		sc := bufio.NewScanner(strings.NewReader(string(body)))
+		mu.Lock()
+		for sc.Scan() {
+			var doc struct {
+				Message string `json:"message"`
+			}
+			if json.Unmarshal(sc.Bytes(), &doc) == nil && doc.Message != "" {
+				messages = append(messages, doc.Message)
+			}
+		}
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), messages...)
+	}
+}
+
+func newTestApp(t *testing.T, esURL string) *App {
+	t.Helper()
+	a, err := New(configs.Config{
+		AppName:                    "test",
+		LogLevel:                   "info",
+		ElasticEnabled:             true,
+		ElasticAddresses:           []string{esURL},
+		ElasticIndex:               "logs",
+		ElasticBulkFlushBytes:      1 << 20,
+		ElasticBulkFlushIntervalMS: int(time.Hour / time.Millisecond),
+	})
+	require.NoError(t, err)
+	return a
+}
+
+func TestApp_LogsFromRunReachElasticsearch(t *testing.T) {
+	es, messages := fakeElastic(t)
+	a := newTestApp(t, es.URL)
+
+	// No database is configured, so Run fails right after logging.
+	err := a.Run(context.Background(), ModeHTTP)
+	require.Equal(t, ExitUnavailable, ExitCode(err))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, a.Close(ctx))
+	require.Contains(t, messages(), "failed to connect to database")
+	require.Contains(t, messages(), "application stopped")
+}
+
+func TestApp_CloseRunsClosersInReverseOrder(t *testing.T) {
+	es, _ := fakeElastic(t)
+	a := newTestApp(t, es.URL)
+
+	var order []string
+	a.OnClose("database", func(context.Context) error { order = append(order, "database"); return nil })
+	a.OnClose("publisher", func(context.Context) error { order = append(order, "publisher"); return errors.New("boom") })
+	a.OnClose("server", func(context.Context) error { order = append(order, "server"); return nil })
+
+	err := a.Close(context.Background())
+	require.ErrorContains(t, err, "stop publisher: boom")
+	require.Equal(t, []string{"server", "publisher", "database"}, order)
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"go-boilerplate/internal/configs"
+	"go-boilerplate/internal/utils/health"
+
+	"go.uber.org/zap"
+)
+
+// Dependency is a piece of infrastructure a mode may need.
+type Dependency string
+
+// Known dependencies. Elasticsearch is not listed: log shipping is set up by New for every
+// mode and never blocks startup.
+const (
+	DepDatabase Dependency = "database"
+	DepRedis    Dependency = "redis"
+	DepRabbit   Dependency = "rabbit"
+)
+
+// Need says how a mode depends on a Dependency.
+type Need int
+
+const (
+	// Required dependencies must be available for the mode to start.
+	Required Need = iota + 1
+	// Optional dependencies are connected at start when available. When they are not, the
+	// mode starts degraded and readiness reports them as down.
+	Optional
+)
+
+// Requirements declares the infrastructure mode needs with cfg. Dependencies that are not
+// listed are never initialised, so a mode keeps working while they are down.
+func Requirements(mode Mode, cfg configs.Config) map[Dependency]Need {
+	switch mode {
+	case ModeHTTP:
+		deps := map[Dependency]Need{
+			DepDatabase: Required,
+			// Events published directly by the services are best-effort.
+			DepRabbit: Optional,
+		}
+		if cfg.RateLimitStore == "redis" || cfg.CacheStore == "redis" {
+			// Without Redis, rate limits fall back to per-replica counters and lookups
+			// are not cached.
+			deps[DepRedis] = Optional
+		}
+		return deps
+	case ModeOutboxRelay:
+		return map[Dependency]Need{
+			DepDatabase: Required,
+			DepRabbit:   Required,
+		}
+	default:
+		return nil
+	}
+}
+
+// errNotConnected is reported by readiness for a dependency that failed to start.
+var errNotConnected = errors.New("not connected")
+
+// dependencyHook names h after dep and registers check for readiness. A required dependency
+// that fails to start aborts the startup. An optional one is logged and skipped: the start
+// continues, h's OnStop is not called, and check is expected to report it as down.
+func (a *App) dependencyHook(dep Dependency, need Need, h Hook, check health.CheckFunc) Hook {
+	h.Name = string(dep)
+	a.Health.Register(h.Name, need == Required, check)
+	if need == Required || h.OnStart == nil {
+		return h
+	}
+
+	start, stop := h.OnStart, h.OnStop
+	var up bool
+	h.OnStart = func(ctx context.Context) error {
+		if err := start(ctx); err != nil {
+			a.Logger.Warn("optional dependency unavailable, starting degraded", zap.String("dependency", h.Name), zap.Error(err))
+			return nil
+		}
+		up = true
+		return nil
+	}
+	h.OnStop = func(ctx context.Context) error {
+		if !up || stop == nil {
+			return nil
+		}
+		return stop(ctx)
+	}
+	return h
+}
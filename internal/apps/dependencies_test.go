@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/configs"
+	"go-boilerplate/internal/utils/health"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRequirements(t *testing.T) {
+	require.Equal(t, map[Dependency]Need{DepDatabase: Required, DepRabbit: Optional},
+		Requirements(ModeHTTP, configs.Config{RateLimitStore: "memory"}))
+	require.Equal(t, map[Dependency]Need{DepDatabase: Required, DepRabbit: Optional, DepRedis: Optional},
+		Requirements(ModeHTTP, configs.Config{RateLimitStore: "redis"}))
+	require.Equal(t, map[Dependency]Need{DepDatabase: Required, DepRabbit: Optional, DepRedis: Optional},
+		Requirements(ModeHTTP, configs.Config{RateLimitStore: "memory", CacheStore: "redis"}))
+	require.Equal(t, map[Dependency]Need{DepDatabase: Required, DepRabbit: Required},
+		Requirements(ModeOutboxRelay, configs.Config{}))
+	require.Empty(t, Requirements(ModeGRPC, configs.Config{}))
+}
+
+func newDependencyTestApp() *App {
+	log := zap.NewNop()
+	return &App{Logger: log, Lifecycle: NewLifecycle(log, LifecycleOptions{}), Health: health.NewRegistry()}
+}
+
+func TestDependencyHook_OptionalStartsDegraded(t *testing.T) {
+	a := newDependencyTestApp()
+	down := errors.New("connection refused")
+	stopped := false
+	a.Lifecycle.Append(a.dependencyHook(DepRabbit, Optional, Hook{
+		OnStart: func(context.Context) error { return down },
+		OnStop:  func(context.Context) error { stopped = true; return nil },
+	}, func(context.Context) error { return down }))
+
+	require.NoError(t, a.Lifecycle.Start(context.Background()))
+	rep := a.Health.Check(context.Background(), time.Second)
+	require.Equal(t, health.StatusDegraded, rep.Status)
+	require.Equal(t, "rabbit", rep.Components[0].Name)
+
+	require.NoError(t, a.Lifecycle.Stop(context.Background()))
+	require.False(t, stopped, "a dependency that never started is not stopped")
+}
+
+func TestDependencyHook_RequiredAbortsStart(t *testing.T) {
+	a := newDependencyTestApp()
+	down := errors.New("connection refused")
+	a.Lifecycle.Append(a.dependencyHook(DepDatabase, Required, Hook{
+		OnStart: func(context.Context) error { return DependencyError("connect to database", down) },
+	}, func(context.Context) error { return errNotConnected }))
+
+	err := a.Lifecycle.Start(context.Background())
+	require.Equal(t, ExitUnavailable, ExitCode(err))
+	require.Equal(t, health.StatusNotReady, a.Health.Check(context.Background(), time.Second).Status)
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorKind classifies why the application failed so main can exit with a distinct code.
+type ErrorKind int
+
+// Error kinds, from the least to the most specific.
+const (
+	// KindRuntime is a failure while the application was running.
+	KindRuntime ErrorKind = iota
+	// KindConfig is an invalid or incomplete configuration.
+	KindConfig
+	// KindDependency is a dependency, such as the database or Redis, that is unavailable.
+	KindDependency
+)
+
+// Exit codes returned by ExitCode, following the BSD sysexits convention.
+const (
+	ExitOK          = 0
+	ExitUnavailable = 69 // EX_UNAVAILABLE
+	ExitSoftware    = 70 // EX_SOFTWARE
+	ExitConfig      = 78 // EX_CONFIG
+)
+
+// String returns the kind name used in logs.
+func (k ErrorKind) String() string {
+	switch k {
+	case KindConfig:
+		return "config"
+	case KindDependency:
+		return "dependency"
+	default:
+		return "runtime"
+	}
+}
+
+// Error is an application failure tagged with its kind and the operation that failed.
+type Error struct {
+	Kind ErrorKind
+	Op   string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError wraps err as a configuration failure of op.
+func ConfigError(op string, err error) error {
+	return &Error{Kind: KindConfig, Op: op, Err: err}
+}
+
+// DependencyError wraps err as an unavailable dependency failure of op.
+func DependencyError(op string, err error) error {
+	return &Error{Kind: KindDependency, Op: op, Err: err}
+}
+
+// RuntimeError wraps err as a runtime failure of op.
+func RuntimeError(op string, err error) error {
+	return &Error{Kind: KindRuntime, Op: op, Err: err}
+}
+
+// KindOf returns the kind of the outermost *Error in err's chain, or KindRuntime.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindRuntime
+}
+
+// ExitCode maps err to the process exit code: ExitOK for nil, ExitConfig, ExitUnavailable
+// or ExitSoftware depending on its kind.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	switch KindOf(err) {
+	case KindConfig:
+		return ExitConfig
+	case KindDependency:
+		return ExitUnavailable
+	default:
+		return ExitSoftware
+	}
+}
+
+// errorf is fmt.Errorf wrapped as an Error of kind.
+func errorf(kind ErrorKind, op, format string, args ...any) error {
+	return &Error{Kind: kind, Op: op, Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	cause := errors.New("boom")
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"config", ConfigError("load config", cause), ExitConfig},
+		{"dependency", DependencyError("connect to database", cause), ExitUnavailable},
+		{"runtime", RuntimeError("http server", cause), ExitSoftware},
+		{"untyped", cause, ExitSoftware},
+		{"wrapped", fmt.Errorf("start: %w", DependencyError("connect to redis", cause)), ExitUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, ExitCode(tc.err))
+		})
+	}
+}
+
+func TestError_UnwrapsCause(t *testing.T) {
+	cause := errors.New("dial tcp: connection refused")
+	err := DependencyError("connect to database", cause)
+	require.ErrorIs(t, err, cause)
+	require.EqualError(t, err, "connect to database: dial tcp: connection refused")
+	require.Equal(t, "dependency", KindOf(err).String())
+}
@@ -0,0 +1,241 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Hook is a component managed by a Lifecycle. OnStart acquires the component (opens a pool,
+// loads a file, starts a worker) and OnStop releases it; either may be nil.
+type Hook struct {
+	Name string
+	// DependsOn names hooks that must start before this one and stop after it.
+	DependsOn []string
+	OnStart   func(ctx context.Context) error
+	OnStop    func(ctx context.Context) error
+	// StartTimeout and StopTimeout override the Lifecycle defaults for this hook.
+	StartTimeout time.Duration
+	StopTimeout  time.Duration
+}
+
+// LifecycleOptions tunes a Lifecycle.
+type LifecycleOptions struct {
+	// StartTimeout bounds each OnStart; defaults to 15s.
+	StartTimeout time.Duration
+	// StopTimeout bounds each OnStop; defaults to 10s.
+	StopTimeout time.Duration
+}
+
+// Lifecycle starts registered components in dependency order and stops them in reverse.
+//
+// If a hook fails to start, the hooks already started by that Start call are stopped again
+// before the error is returned, so a failed startup does not leak connections.
+type Lifecycle struct {
+	log  *zap.Logger
+	opts LifecycleOptions
+
+	mu      sync.Mutex
+	pending []Hook
+	started []Hook
+}
+
+// NewLifecycle returns an empty Lifecycle.
+func NewLifecycle(log *zap.Logger, opts LifecycleOptions) *Lifecycle {
+	if opts.StartTimeout <= 0 {
+		opts.StartTimeout = 15 * time.Second
+	}
+	if opts.StopTimeout <= 0 {
+		opts.StopTimeout = 10 * time.Second
+	}
+	return &Lifecycle{log: log, opts: opts}
+}
+
+// Append registers h to be started by the next Start.
+func (l *Lifecycle) Append(h Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, h)
+}
+
+// OnStop registers fn to release a resource that is already acquired. It is stopped with,
+// and in reverse order of, the started hooks.
+func (l *Lifecycle) OnStop(name string, fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.started = append(l.started, Hook{Name: name, OnStop: fn})
+}
+
+// Start runs OnStart of every pending hook, dependencies first and otherwise in
+// registration order. On failure it stops the hooks it started and returns the error.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = nil
+	known := map[string]bool{}
+	for _, h := range l.started {
+		known[h.Name] = true
+	}
+	l.mu.Unlock()
+
+	order, err := startOrder(pending, known)
+	if err != nil {
+		return err
+	}
+
+	var started []Hook
+	for _, h := range order {
+		if err := l.start(ctx, h); err != nil {
+			l.log.Error("component failed to start, rolling back", zap.String("component", h.Name), zap.Error(err))
+			if rbErr := l.stop(context.WithoutCancel(ctx), started); rbErr != nil {
+				l.log.Warn("rollback incomplete", zap.Error(rbErr))
+			}
+			return fmt.Errorf("start %s: %w", h.Name, err)
+		}
+		started = append(started, h)
+	}
+
+	l.mu.Lock()
+	l.started = append(l.started, started...)
+	l.mu.Unlock()
+	return nil
+}
+
+// Stop runs OnStop of every started hook in reverse start order, bounded by ctx and each
+// hook's stop timeout. It keeps going after a failure and returns all errors.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	started := l.started
+	l.started = nil
+	l.mu.Unlock()
+	return l.stop(ctx, started)
+}
+
+func (l *Lifecycle) start(ctx context.Context, h Hook) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, orDefault(h.StartTimeout, l.opts.StartTimeout))
+	defer cancel()
+	begin := time.Now()
+	if err := h.OnStart(ctx); err != nil {
+		return err
+	}
+	l.log.Debug("component started", zap.String("component", h.Name), zap.Duration("took", time.Since(begin)))
+	return nil
+}
+
+func (l *Lifecycle) stop(ctx context.Context, hooks []Hook) error {
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.OnStop == nil {
+			continue
+		}
+		hctx, cancel := context.WithTimeout(ctx, orDefault(h.StopTimeout, l.opts.StopTimeout))
+		err := h.OnStop(hctx)
+		cancel()
+		if err != nil {
+			l.log.Warn("component failed to stop", zap.String("component", h.Name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+			continue
+		}
+		l.log.Debug("component stopped", zap.String("component", h.Name))
+	}
+	return errors.Join(errs...)
+}
+
+// startOrder sorts hooks so that every hook follows its dependencies, keeping registration
+// order otherwise. Dependencies in started are already satisfied.
+func startOrder(hooks []Hook, started map[string]bool) ([]Hook, error) {
+	byName := make(map[string]int, len(hooks))
+	for i, h := range hooks {
+		if _, dup := byName[h.Name]; dup || started[h.Name] {
+			return nil, fmt.Errorf("lifecycle: duplicate component %q", h.Name)
+		}
+		byName[h.Name] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(hooks))
+	order := make([]Hook, 0, len(hooks))
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("lifecycle: dependency cycle %v", append(path, hooks[i].Name))
+		}
+		state[i] = visiting
+		for _, dep := range hooks[i].DependsOn {
+			if started[dep] {
+				continue
+			}
+			j, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("lifecycle: %q depends on unknown component %q", hooks[i].Name, dep)
+			}
+			if err := visit(j, append(path, hooks[i].Name)); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		order = append(order, hooks[i])
+		return nil
+	}
+	for i := range hooks {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// BackgroundHook returns a hook that runs fn in its own goroutine from start until stop.
+// fn must return when its context is done; OnStop waits for it.
+func BackgroundHook(name string, fn func(ctx context.Context), dependsOn ...string) Hook {
+	var (
+		cancel context.CancelFunc
+		done   chan struct{}
+	)
+	return Hook{
+		Name:      name,
+		DependsOn: dependsOn,
+		OnStart: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			done = make(chan struct{})
+			go func() {
+				defer close(done)
+				fn(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// orDefault returns d, or def when d is not positive.
+func orDefault(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
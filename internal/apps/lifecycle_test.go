@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// recorder collects start and stop events in the order they happen.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(e string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) hook(name string, deps ...string) Hook {
+	return Hook{
+		Name:      name,
+		DependsOn: deps,
+		OnStart:   func(context.Context) error { r.add("start " + name); return nil },
+		OnStop:    func(context.Context) error { r.add("stop " + name); return nil },
+	}
+}
+
+func TestLifecycle_StartsDependenciesFirstAndStopsInReverse(t *testing.T) {
+	var rec recorder
+	lc := NewLifecycle(zap.NewNop(), LifecycleOptions{})
+	lc.Append(rec.hook("http", "services"))
+	lc.Append(rec.hook("services", "database", "cache"))
+	lc.Append(rec.hook("database"))
+	lc.Append(rec.hook("cache"))
+
+	require.NoError(t, lc.Start(context.Background()))
+	require.NoError(t, lc.Stop(context.Background()))
+	require.Equal(t, []string{
+		"start database", "start cache", "start services", "start http",
+		"stop http", "stop services", "stop cache", "stop database",
+	}, rec.events)
+}
+
+func TestLifecycle_RollsBackOnStartFailure(t *testing.T) {
+	var rec recorder
+	boom := errors.New("connection refused")
+	lc := NewLifecycle(zap.NewNop(), LifecycleOptions{})
+	lc.Append(rec.hook("database"))
+	lc.Append(rec.hook("cache"))
+	lc.Append(Hook{Name: "publisher", OnStart: func(context.Context) error { return DependencyError("dial rabbit", boom) }})
+	lc.Append(rec.hook("scheduler"))
+
+	err := lc.Start(context.Background())
+	require.ErrorIs(t, err, boom)
+	require.Equal(t, ExitUnavailable, ExitCode(err), "the hook's error kind is kept")
+	require.Equal(t, []string{"start database", "start cache", "stop cache", "stop database"}, rec.events)
+
+	// Nothing is left to stop.
+	require.NoError(t, lc.Stop(context.Background()))
+	require.Len(t, rec.events, 4)
+}
+
+func TestLifecycle_StartTimeout(t *testing.T) {
+	lc := NewLifecycle(zap.NewNop(), LifecycleOptions{StartTimeout: 20 * time.Millisecond})
+	lc.Append(Hook{Name: "slow", OnStart: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	require.ErrorIs(t, lc.Start(context.Background()), context.DeadlineExceeded)
+}
+
+func TestLifecycle_InvalidDependencies(t *testing.T) {
+	lc := NewLifecycle(zap.NewNop(), LifecycleOptions{})
+	lc.Append(Hook{Name: "a", DependsOn: []string{"b"}})
+	lc.Append(Hook{Name: "b", DependsOn: []string{"a"}})
+	require.ErrorContains(t, lc.Start(context.Background()), "dependency cycle")
+
+	lc.Append(Hook{Name: "c", DependsOn: []string{"missing"}})
+	require.ErrorContains(t, lc.Start(context.Background()), `unknown component "missing"`)
+}
+
+func TestLifecycle_StopContinuesAfterFailure(t *testing.T) {
+	var rec recorder
+	lc := NewLifecycle(zap.NewNop(), LifecycleOptions{})
+	lc.Append(rec.hook("database"))
+	lc.Append(Hook{Name: "publisher", OnStop: func(context.Context) error { return errors.New("boom") }})
+	lc.OnStop("file", func(context.Context) error { rec.add("stop file"); return nil })
+	require.NoError(t, lc.Start(context.Background()))
+
+	err := lc.Stop(context.Background())
+	require.ErrorContains(t, err, "stop publisher: boom")
+	require.Equal(t, []string{"start database", "stop database", "stop file"}, rec.events)
+}
+
+func TestBackgroundHook(t *testing.T) {
+	running := make(chan struct{})
+	lc := NewLifecycle(zap.NewNop(), LifecycleOptions{})
+	lc.Append(BackgroundHook("worker", func(ctx context.Context) {
+		close(running)
+		<-ctx.Done()
+	}))
+	require.NoError(t, lc.Start(context.Background()))
+	<-running
+	require.NoError(t, lc.Stop(context.Background()))
+}
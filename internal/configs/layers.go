@@ -0,0 +1,360 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-boilerplate/internal/utils/jwks"
+	"go-boilerplate/internal/utils/secrets"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Layer is one source of configuration values, keyed by env name.
+type Layer struct {
+	// Name identifies the layer in provenance output, e.g. "file:config.yaml" or "env".
+	Name   string
+	Values map[string]string
+}
+
+// Layers are consulted from last to first, so later layers override earlier ones.
+// Blank values do not override.
+type Layers []Layer
+
+// Lookup returns the value of key from the highest-priority layer that sets it.
+// It is a LookupFunc.
+func (ls Layers) Lookup(key string) (string, bool) {
+	if i := ls.find(key); i >= 0 {
+		return ls[i].Values[key], true
+	}
+	return "", false
+}
+
+// Source returns the name of the layer that supplies key, or "".
+func (ls Layers) Source(key string) string {
+	if i := ls.find(key); i >= 0 {
+		return ls[i].Name
+	}
+	return ""
+}
+
+func (ls Layers) find(key string) int {
+	for i := len(ls) - 1; i >= 0; i-- {
+		if v, ok := ls[i].Values[key]; ok && strings.TrimSpace(v) != "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// Provenance maps each config key to where its final value came from: a layer name,
+// "default", or "" when the key is unset.
+type Provenance map[string]string
+
+// Provenance sources that are not layers.
+const (
+	SourceDefault = "default"
+	SourceUnset   = ""
+)
+
+// LoadLayers is LoadStruct over layers. It also reports which layer supplied every key.
+func LoadLayers(dst any, layers Layers) (Provenance, error) {
+	l, err := load(dst, layers.Lookup)
+	if l == nil {
+		return nil, err
+	}
+	prov := make(Provenance, len(l.keys))
+	for _, key := range l.keys {
+		switch {
+		case l.sources[key] != "":
+			prov[key] = l.sources[key]
+		case layers.Source(key) != "":
+			prov[key] = layers.Source(key)
+		case l.defaulted[key]:
+			prov[key] = SourceDefault
+		default:
+			prov[key] = SourceUnset
+		}
+	}
+	return prov, err
+}
+
+// LoadOptions selects the layers read by LoadLayered.
+type LoadOptions struct {
+	Mode  string
+	Stage string
+	// ConfigFile is the base YAML or TOML file. When empty, config.yaml, config.yml and
+	// config.toml are looked for next to the working directory and the binary.
+	ConfigFile string
+	// Overrides are KEY=VALUE pairs from the command line; they win over everything else.
+	Overrides Overrides
+}
+
+// LoadLayered builds the configuration from, in increasing priority: field defaults, the
+// base config file, its stage overlay (config.<stage>.yaml next to it), the dotenv file,
+// the process environment and command-line overrides. secret:// references are then
+// resolved through the configured secret provider. It returns the provenance of every
+// key along with the config; on error the config holds whatever could be parsed.
+func LoadLayered(opts LoadOptions) (Config, Provenance, error) {
+	var (
+		layers Layers
+		errs   []error
+	)
+
+	files := findSourceFiles(opts)
+	if files.base != "" {
+		values, err := ReadConfigFile(files.base)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		layers = append(layers, Layer{Name: "file:" + files.base, Values: values})
+	}
+	if files.overlay != "" {
+		values, err := ReadConfigFile(files.overlay)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		layers = append(layers, Layer{Name: "file:" + files.overlay, Values: values})
+	}
+	if files.dotenv != "" {
+		values, err := godotenv.Read(files.dotenv)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read %s: %w", files.dotenv, err))
+		} else {
+			log.Printf("Loaded environment from %s", files.dotenv)
+		}
+		layers = append(layers, Layer{Name: "dotenv:" + files.dotenv, Values: values})
+	} else {
+		log.Printf("No %s file found (using defaults and env vars)", dotenvName(opts.Stage))
+	}
+
+	layers = append(layers,
+		Layer{Name: "env", Values: environ()},
+		Layer{Name: "flag", Values: opts.Overrides},
+	)
+
+	switch opts.Mode {
+	case "http", "rabbit", "grpc", "outbox-relay":
+	default:
+		log.Printf("Unknown MODE=%q, falling back to MODE=http validation", opts.Mode)
+	}
+
+	cfg := Config{Mode: opts.Mode, Stage: opts.Stage}
+	prov, err := LoadLayers(&cfg, layers)
+	errs = append(errs, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	errs = append(errs, resolveSecrets(ctx, &cfg, NewSecretProvider(cfg)))
+	for key, ref := range cfg.SecretRefs() {
+		prov[key] += " (" + secrets.RefScheme + ref + ")"
+	}
+
+	if cfg.JWTJWKSFile != "" {
+		if _, err := jwks.NewFromFile(cfg.JWTJWKSFile); err != nil {
+			errs = append(errs, fmt.Errorf("JWT_JWKS_FILE: %w", err))
+		}
+	}
+
+	if cfg.BasicAuthUsersFile != "" {
+		entries, err := readHtpasswdFile(cfg.BasicAuthUsersFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("BASIC_AUTH_USERS_FILE: %w", err))
+		}
+		for _, entry := range entries {
+			if err := checkHtpasswdEntry(entry); err != nil {
+				errs = append(errs, fmt.Errorf("BASIC_AUTH_USERS_FILE: %w", err))
+			}
+		}
+		cfg.BasicAuthUsers = append(cfg.BasicAuthUsers, entries...)
+	}
+
+	return cfg, prov, errors.Join(errs...)
+}
+
+type sourceFiles struct {
+	base, overlay, dotenv string
+}
+
+// SourceFiles returns the config and dotenv files LoadLayered reads for opts, e.g. for a
+// Watcher to poll.
+func SourceFiles(opts LoadOptions) []string {
+	f := findSourceFiles(opts)
+	var out []string
+	for _, p := range []string{f.base, f.overlay, f.dotenv} {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func findSourceFiles(opts LoadOptions) sourceFiles {
+	var f sourceFiles
+	f.base = opts.ConfigFile
+	if f.base == "" {
+		f.base, _ = findFile("config.yaml", "config.yml", "config.toml")
+	}
+	if f.base != "" && opts.Stage != "" {
+		overlay := filepath.Join(filepath.Dir(f.base), "config."+opts.Stage+filepath.Ext(f.base))
+		if _, err := os.Stat(overlay); err == nil {
+			f.overlay = overlay
+		}
+	}
+	f.dotenv, _ = findFile(dotenvName(opts.Stage))
+	return f
+}
+
+func dotenvName(stage string) string {
+	if stage == "" {
+		return ".env"
+	}
+	return fmt.Sprintf(".env.stage.%s", stage)
+}
+
+// ReadConfigFile reads a YAML (.yaml, .yml) or TOML (.toml) file into values keyed by env
+// name. Nested keys are joined with "_" and upper-cased, so `db: {port: 3306}` and
+// `DB_PORT: 3306` are equivalent. Lists become multi-line values, one element per line.
+func ReadConfigFile(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &doc)
+	case ".toml":
+		err = toml.Unmarshal(raw, &doc)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format (want .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	out := map[string]string{}
+	if err := flatten("", doc, out); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return out, nil
+}
+
+func flatten(prefix string, v any, out map[string]string) error {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			key := strings.ToUpper(k)
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+			if err := flatten(key, child, out); err != nil {
+				return err
+			}
+		}
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := scalarString(prefix, item)
+			if err != nil {
+				return err
+			}
+			items = append(items, s)
+		}
+		out[prefix] = strings.Join(items, "\n")
+	default:
+		s, err := scalarString(prefix, v)
+		if err != nil {
+			return err
+		}
+		out[prefix] = s
+	}
+	return nil
+}
+
+func scalarString(key string, v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case map[string]any, []any:
+		return "", fmt.Errorf("%s: lists may only contain scalar values", key)
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// Overrides collects KEY=VALUE pairs from a repeatable command-line flag.
+type Overrides map[string]string
+
+// String implements flag.Value.
+func (o Overrides) String() string {
+	pairs := make([]string, 0, len(o))
+	for k, v := range o {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value.
+func (o Overrides) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(k) == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", s)
+	}
+	o[strings.TrimSpace(k)] = v
+	return nil
+}
+
+func environ() map[string]string {
+	out := map[string]string{}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// findFile returns the first of names found next to the working directory or the binary.
+func findFile(names ...string) (string, bool) {
+	cwd, _ := os.Getwd()
+	exe, _ := os.Executable()
+	exeDir := filepath.Dir(exe)
+
+	dirs := []string{
+		cwd,                         // run from repo root
+		exeDir,                      // near the binary
+		filepath.Join(exeDir, ".."), // binary in ./bin
+		filepath.Join(cwd, ".."),    // ran from ./cmd
+	}
+	for _, dir := range dirs {
+		for _, name := range names {
+			p := filepath.Join(dir, name)
+			if _, err := os.Stat(p); err == nil {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,280 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-boilerplate/internal/utils/validation"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// LookupFunc returns the raw value of an environment variable and whether it is set.
+// os.LookupEnv is the default.
+type LookupFunc func(key string) (string, bool)
+
+// LoadStruct populates the struct pointed to by dst from environment variables and validates it
+// with validation.GetValidator().
+//
+// Fields are driven by struct tags:
+//
+//	env:"DB_PORT"            variable name; add ",lower" to lower-case the value, or ",file"
+//	                         to also accept DB_PORT_FILE naming a file that holds the value
+//	default:"3306"           used when the variable is unset or blank
+//	sep:";"                  separator for slices and maps (default ","; " " splits on whitespace;
+//	                         a multi-line value is always split on newlines)
+//	validate:"required,min=1" validator rules, checked after every field has been parsed
+//	envPrefix:"DB_"          on a nested struct field, prefixes the env names inside it
+//
+// Supported field types are strings, booleans, integers, floats, time.Duration (e.g. "5s"),
+// slices and string-keyed maps of those (map entries are "key=value"), and nested structs.
+// Fields without an env tag are left untouched, as are fields whose variable is unset and
+// that have no default. Every parse and validation problem is reported in the returned
+// error, not just the first.
+func LoadStruct(dst any) error {
+	return LoadStructWith(dst, os.LookupEnv)
+}
+
+// LoadStructWith is LoadStruct with a custom variable lookup.
+func LoadStructWith(dst any, lookup LookupFunc) error {
+	_, err := load(dst, lookup)
+	return err
+}
+
+func load(dst any, lookup LookupFunc) (*loader, error) {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("configs: LoadStruct needs a non-nil pointer to a struct, got %T", dst)
+	}
+
+	l := &loader{lookup: lookup, keys: map[string]string{}, failed: map[string]bool{}, defaulted: map[string]bool{}, sources: map[string]string{}}
+	l.loadStruct(rv.Elem(), rv.Elem().Type().Name(), "")
+
+	if err := validation.GetValidator().Struct(dst); err != nil {
+		var verrs validator.ValidationErrors
+		if !errors.As(err, &verrs) {
+			return l, errors.Join(append(l.errs, err)...)
+		}
+		for _, fe := range verrs {
+			ns := fe.StructNamespace()
+			key, ok := l.keys[ns]
+			if !ok {
+				// Slice and map elements ("Config.Routes[1]") are reported under their field.
+				base, index, _ := strings.Cut(ns, "[")
+				if key, ok = l.keys[base]; ok {
+					key += "[" + index
+				} else {
+					key = ns
+				}
+			}
+			if l.failed[key] {
+				continue // already reported as a parse error
+			}
+			rule := fe.Tag()
+			if fe.Param() != "" {
+				rule += "=" + fe.Param()
+			}
+			l.errs = append(l.errs, fmt.Errorf("%s: failed validation %q", key, rule))
+		}
+	}
+	return l, errors.Join(l.errs...)
+}
+
+type loader struct {
+	lookup LookupFunc
+	// keys maps validator struct namespaces ("Config.DbPort") to env names for error messages.
+	keys   map[string]string
+	failed map[string]bool
+	// defaulted holds the keys whose value came from a default tag.
+	defaulted map[string]bool
+	// sources records values read from somewhere other than the lookup, such as KEY_FILE.
+	sources map[string]string
+	errs    []error
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// envField is an env-tagged struct field found by walkEnvFields.
+type envField struct {
+	Key       string // env name including any envPrefix
+	Namespace string // validator struct namespace, e.g. "Config.DbPort"
+	Field     reflect.StructField
+	Value     reflect.Value
+}
+
+// has reports whether the env tag carries option opt, as in env:"DB_PASSWORD,file".
+func (f envField) has(opt string) bool {
+	_, opts, _ := strings.Cut(f.Field.Tag.Get("env"), ",")
+	for o := range strings.SplitSeq(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// sep returns the list separator declared by the sep tag, "," by default.
+func (f envField) sep() string {
+	if sep := f.Field.Tag.Get("sep"); sep != "" {
+		return sep
+	}
+	return ","
+}
+
+// walkEnvFields calls fn for every exported env-tagged field of v, descending into untagged
+// nested structs.
+func walkEnvFields(v reflect.Value, namespace, prefix string, fn func(envField)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		ns := namespace + "." + f.Name
+		tag, hasTag := f.Tag.Lookup("env")
+		if tag == "-" {
+			continue
+		}
+		if !hasTag {
+			if f.Type.Kind() == reflect.Struct {
+				walkEnvFields(v.Field(i), ns, prefix+f.Tag.Get("envPrefix"), fn)
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fn(envField{Key: prefix + name, Namespace: ns, Field: f, Value: v.Field(i)})
+	}
+}
+
+func (l *loader) loadStruct(v reflect.Value, namespace, prefix string) {
+	walkEnvFields(v, namespace, prefix, func(f envField) {
+		key := f.Key
+		l.keys[f.Namespace] = key
+
+		raw, _ := l.lookup(key)
+		raw = strings.TrimSpace(raw)
+		if f.has("file") {
+			path, _ := l.lookup(key + "_FILE")
+			if path = strings.TrimSpace(path); path != "" {
+				if raw != "" {
+					l.fail(key, fmt.Errorf("%s and %s_FILE are mutually exclusive", key, key))
+					return
+				}
+				b, err := os.ReadFile(path)
+				if err != nil {
+					l.fail(key, fmt.Errorf("%s_FILE: %w", key, err))
+					return
+				}
+				raw = strings.TrimSpace(string(b))
+				l.sources[key] = "file:" + path
+			}
+		}
+		if raw == "" {
+			var ok bool
+			if raw, ok = f.Field.Tag.Lookup("default"); !ok {
+				return
+			}
+			l.defaulted[key] = true
+		}
+		if f.has("lower") {
+			raw = strings.ToLower(raw)
+		}
+
+		if err := setValue(f.Value, raw, f.sep()); err != nil {
+			l.fail(key, fmt.Errorf("%s: %w", key, err))
+		}
+	})
+}
+
+func (l *loader) fail(key string, err error) {
+	l.failed[key] = true
+	l.errs = append(l.errs, err)
+}
+
+// setValue parses raw into v according to v's type.
+func setValue(v reflect.Value, raw, sep string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as a duration", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		// supports: 1, t, T, TRUE, true, True / 0, f, F, FALSE, false, False
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as a bool", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as an integer", raw)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.ReplaceAll(raw, "_", ""), 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as an unsigned integer", raw)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as a number", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		parts := splitValue(raw, sep)
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := setValue(s.Index(i), p, sep); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		m := reflect.MakeMap(v.Type())
+		for _, entry := range splitValue(raw, sep) {
+			k, val, ok := strings.Cut(entry, "=")
+			if !ok {
+				return fmt.Errorf("invalid map entry %q: expected key=value", entry)
+			}
+			ev := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(ev, strings.TrimSpace(val), sep); err != nil {
+				return fmt.Errorf("key %q: %w", k, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(k)).Convert(v.Type().Key()), ev)
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// splitValue splits a list value on sep, or on whitespace when sep is " ", dropping blanks.
+// Multi-line values, such as lists from config files, are split on newlines instead.
+func splitValue(raw, sep string) []string {
+	if strings.Contains(raw, "\n") {
+		return splitList(raw, "\n")
+	}
+	if sep == " " {
+		return strings.Fields(raw)
+	}
+	return splitList(raw, sep)
+}
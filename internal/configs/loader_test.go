@@ -0,0 +1,130 @@
+package configs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testDBConfig struct {
+	Host string `env:"HOST" default:"localhost"`
+	Port int    `env:"PORT" default:"3306" validate:"required,min=1"`
+}
+
+type testConfig struct {
+	Name     string            `env:"NAME" validate:"required"`
+	Store    string            `env:"STORE,lower" default:"memory" validate:"oneof=memory redis"`
+	Enabled  bool              `env:"ENABLED"`
+	Timeout  time.Duration     `env:"TIMEOUT" default:"5s"`
+	Ratio    float64           `env:"RATIO" default:"0.5"`
+	Hosts    []string          `env:"HOSTS" default:"a,b"`
+	Ports    []int             `env:"PORTS"`
+	Users    []string          `env:"USERS" sep:" "`
+	Labels   map[string]string `env:"LABELS" sep:";"`
+	Limits   map[string]int    `env:"LIMITS"`
+	DB       testDBConfig      `envPrefix:"DB_"`
+	Untagged string
+}
+
+func lookupFrom(env map[string]string) LookupFunc {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoadStruct_ParsesTypesAndDefaults(t *testing.T) {
+	cfg := testConfig{Untagged: "kept"}
+	err := LoadStructWith(&cfg, lookupFrom(map[string]string{
+		"NAME":    "svc",
+		"STORE":   " Redis ",
+		"ENABLED": "true",
+		"PORTS":   "80, 443",
+		"USERS":   "alice:x  bob:y",
+		"LABELS":  "team=core; tier = gold",
+		"LIMITS":  "read=100,write=10",
+		"DB_PORT": "5432",
+	}))
+	require.NoError(t, err)
+
+	require.Equal(t, "svc", cfg.Name)
+	require.Equal(t, "redis", cfg.Store)
+	require.True(t, cfg.Enabled)
+	require.Equal(t, 5*time.Second, cfg.Timeout)
+	require.Equal(t, 0.5, cfg.Ratio)
+	require.Equal(t, []string{"a", "b"}, cfg.Hosts)
+	require.Equal(t, []int{80, 443}, cfg.Ports)
+	require.Equal(t, []string{"alice:x", "bob:y"}, cfg.Users)
+	require.Equal(t, map[string]string{"team": "core", "tier": "gold"}, cfg.Labels)
+	require.Equal(t, map[string]int{"read": 100, "write": 10}, cfg.Limits)
+	require.Equal(t, testDBConfig{Host: "localhost", Port: 5432}, cfg.DB)
+	require.Equal(t, "kept", cfg.Untagged)
+}
+
+func TestLoadStruct_ReportsAllErrors(t *testing.T) {
+	var cfg testConfig
+	err := LoadStructWith(&cfg, lookupFrom(map[string]string{
+		"STORE":   "disk",
+		"ENABLED": "maybe",
+		"TIMEOUT": "soon",
+		"LIMITS":  "read",
+		"DB_PORT": "abc",
+	}))
+	require.Error(t, err)
+
+	msg := err.Error()
+	require.Contains(t, msg, `ENABLED: cannot parse "maybe" as a bool`)
+	require.Contains(t, msg, `TIMEOUT: cannot parse "soon" as a duration`)
+	require.Contains(t, msg, `LIMITS: invalid map entry "read"`)
+	require.Contains(t, msg, `DB_PORT: cannot parse "abc" as an integer`)
+	require.Contains(t, msg, `NAME: failed validation "required"`)
+	require.Contains(t, msg, `STORE: failed validation "oneof=memory redis"`)
+	// DB_PORT is not reported twice.
+	require.NotContains(t, msg, `DB_PORT: failed validation`)
+}
+
+func TestLoadStruct_RejectsNonPointer(t *testing.T) {
+	require.Error(t, LoadStructWith(testConfig{}, lookupFrom(nil)))
+}
+
+func TestLoadStruct_ConfigDefaults(t *testing.T) {
+	cfg := Config{Mode: "http"}
+	require.NoError(t, LoadStructWith(&cfg, lookupFrom(nil)))
+
+	require.Equal(t, 3306, cfg.DbPort)
+	require.Equal(t, ":8080", cfg.HTTPAddr)
+	require.Equal(t, []string{"HS256", "RS256", "ES256"}, cfg.JWTAlgorithms)
+	require.Equal(t, []string{"dwh.*", "#"}, cfg.RabbitRoutingKeys)
+	require.True(t, cfg.RabbitPublishMandatory)
+	require.Equal(t, "basic", cfg.HTTPExampleAuth)
+}
+
+func TestLoadStruct_ConfigValidation(t *testing.T) {
+	cfg := Config{Mode: "http"}
+	err := LoadStructWith(&cfg, lookupFrom(map[string]string{
+		"API_KEY_STORE":        "FILE",
+		"HTTP_ADMIN_AUTH":      "digest",
+		"BASIC_AUTH_USERS":     "alice:$2a$10$x bob carol:$argon2id$v=19$m=65536,t=1,p=0$c2FsdA$a2V5 dave:$argon2id$v=19$m=4194304,t=1,p=1$c2FsdA$a2V5",
+		"RATE_LIMIT_ROUTES":    "POST /example/=10/1m;GET=5/1s;GET /x=2000/1ms",
+		"RATE_LIMIT_REQUESTS":  "2000",
+		"RATE_LIMIT_WINDOW_MS": "1",
+		"RATE_LIMIT_IP":        "lots",
+		"DB_REPLICA_HOSTS":     "replica-1",
+	}))
+	require.Error(t, err)
+
+	msg := err.Error()
+	require.Contains(t, msg, `API_KEYS_FILE: failed validation "required_if=APIKeyStore file"`)
+	require.Contains(t, msg, `HTTP_ADMIN_AUTH: failed validation "oneof=basic jwt apikey none"`)
+	require.Contains(t, msg, `BASIC_AUTH_USERS[1]: failed validation "htpasswd"`)
+	require.Contains(t, msg, `BASIC_AUTH_USERS[2]: failed validation "htpasswd"`)
+	require.Contains(t, msg, `BASIC_AUTH_USERS[3]: failed validation "htpasswd"`)
+	require.NotContains(t, msg, `BASIC_AUTH_USERS[0]`)
+	require.Contains(t, msg, `RATE_LIMIT_ROUTES[1]: failed validation "ratelimit_route"`)
+	require.Contains(t, msg, `RATE_LIMIT_ROUTES[2]: failed validation "ratelimit_route"`)
+	require.NotContains(t, msg, `RATE_LIMIT_ROUTES[0]`)
+	require.Contains(t, msg, `RATE_LIMIT_REQUESTS: failed validation "ratelimit_window"`)
+	require.Contains(t, msg, `RATE_LIMIT_IP: failed validation "ratelimit"`)
+	require.Contains(t, msg, `DB_SESSION_KEY: failed validation "required_with=DbReplicaHosts"`)
+}
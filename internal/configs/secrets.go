@@ -0,0 +1,83 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go-boilerplate/internal/utils/secrets"
+)
+
+// NewSecretProvider returns the provider selected by SECRETS_PROVIDER, or nil when none
+// is configured.
+func NewSecretProvider(cfg Config) secrets.Provider {
+	switch cfg.SecretsProvider {
+	case "file":
+		return secrets.NewFileVault(cfg.SecretsDir)
+	case "vault":
+		return secrets.NewVaultProvider(cfg.VaultAddr, cfg.VaultToken, secrets.WithVaultMount(cfg.VaultMount))
+	default:
+		return nil
+	}
+}
+
+// SecretRefs returns the config keys that were resolved from secret:// references, mapped
+// to the reference (without the scheme).
+func (c Config) SecretRefs() map[string]string {
+	return c.secretRefs
+}
+
+// resolveSecrets replaces secret:// references in the string fields of cfg with their
+// values from p and remembers them for RefreshSecrets.
+func resolveSecrets(ctx context.Context, cfg *Config, p secrets.Provider) error {
+	refs := map[string]string{}
+	var errs []error
+	walkEnvFields(reflect.ValueOf(cfg).Elem(), "", "", func(f envField) {
+		if f.Value.Kind() != reflect.String || !secrets.IsRef(f.Value.String()) {
+			return
+		}
+		ref := strings.TrimPrefix(f.Value.String(), secrets.RefScheme)
+		if p == nil {
+			errs = append(errs, fmt.Errorf("%s: references %s%s but SECRETS_PROVIDER is not set", f.Key, secrets.RefScheme, ref))
+			return
+		}
+		v, err := p.Secret(ctx, ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Key, err))
+			return
+		}
+		f.Value.SetString(v)
+		refs[f.Key] = ref
+	})
+	if len(refs) > 0 {
+		cfg.secretRefs = refs
+	}
+	return errors.Join(errs...)
+}
+
+// RefreshSecrets re-reads every secret reference of cfg from p. It returns the updated
+// config and the keys whose value changed; cfg itself is not modified.
+func RefreshSecrets(ctx context.Context, cfg Config, p secrets.Provider) (Config, []string, error) {
+	var (
+		changed []string
+		errs    []error
+	)
+	walkEnvFields(reflect.ValueOf(&cfg).Elem(), "", "", func(f envField) {
+		ref, ok := cfg.secretRefs[f.Key]
+		if !ok {
+			return
+		}
+		v, err := p.Secret(ctx, ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Key, err))
+			return
+		}
+		if v != f.Value.String() {
+			f.Value.SetString(v)
+			changed = append(changed, f.Key)
+		}
+	})
+	return cfg, changed, errors.Join(errs...)
+}
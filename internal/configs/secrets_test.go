@@ -0,0 +1,60 @@
+package configs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mapProvider map[string]string
+
+func (m mapProvider) Secret(_ context.Context, ref string) (string, error) {
+	return m[ref], nil
+}
+
+func TestLoad_FileVariant(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "db_password")
+	require.NoError(t, os.WriteFile(p, []byte("from-file\n"), 0o600))
+
+	cfg := Config{Mode: "http"}
+	prov, err := LoadLayers(&cfg, Layers{{Name: "env", Values: map[string]string{"DB_PASSWORD_FILE": p}}})
+	require.NoError(t, err)
+	require.Equal(t, "from-file", cfg.DbPassword)
+	require.Equal(t, "file:"+p, prov["DB_PASSWORD"])
+
+	cfg = Config{Mode: "http"}
+	_, err = LoadLayers(&cfg, Layers{{Name: "env", Values: map[string]string{"DB_PASSWORD": "x", "DB_PASSWORD_FILE": p}}})
+	require.ErrorContains(t, err, "DB_PASSWORD and DB_PASSWORD_FILE are mutually exclusive")
+
+	cfg = Config{Mode: "http"}
+	_, err = LoadLayers(&cfg, Layers{{Name: "env", Values: map[string]string{"DB_PASSWORD_FILE": filepath.Join(dir, "missing")}}})
+	require.ErrorContains(t, err, "DB_PASSWORD_FILE:")
+}
+
+func TestResolveAndRefreshSecrets(t *testing.T) {
+	cfg := Config{DbPassword: "secret://db/password", ElasticAPIKey: "secret://es/key", DbUser: "plain"}
+	p := mapProvider{"db/password": "pw1", "es/key": "k1"}
+
+	require.NoError(t, resolveSecrets(context.Background(), &cfg, p))
+	require.Equal(t, "pw1", cfg.DbPassword)
+	require.Equal(t, "k1", cfg.ElasticAPIKey)
+	require.Equal(t, "plain", cfg.DbUser)
+	require.Equal(t, map[string]string{"DB_PASSWORD": "db/password", "ELASTIC_API_KEY": "es/key"}, cfg.SecretRefs())
+
+	p["db/password"] = "pw2"
+	next, changed, err := RefreshSecrets(context.Background(), cfg, p)
+	require.NoError(t, err)
+	require.Equal(t, []string{"DB_PASSWORD"}, changed)
+	require.Equal(t, "pw2", next.DbPassword)
+	require.Equal(t, "pw1", cfg.DbPassword)
+}
+
+func TestResolveSecrets_RequiresProvider(t *testing.T) {
+	cfg := Config{DbPassword: "secret://db/password"}
+	err := resolveSecrets(context.Background(), &cfg, nil)
+	require.ErrorContains(t, err, "DB_PASSWORD: references secret://db/password but SECRETS_PROVIDER is not set")
+}
@@ -0,0 +1,212 @@
+package configs
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go-boilerplate/internal/utils/secrets"
+
+	"go.uber.org/zap"
+)
+
+// Watcher reloads the configuration when one of its source files changes or the process
+// receives SIGHUP, and publishes validated snapshots to subscribers.
+//
+// Only fields tagged reload:"hot" are applied at runtime. Changes to any other field (for
+// example HTTP_ADDR or the DB DSN) need a restart: they are logged as rejected and the
+// running value is kept. A reload that fails to load or validate is discarded as a whole.
+type Watcher struct {
+	load     func() (Config, error)
+	files    []string
+	interval time.Duration
+	log      *zap.Logger
+
+	current atomic.Pointer[Config]
+
+	mu     sync.Mutex // serialises reloads and guards subs and secrets
+	subs   []func(Config)
+	mtimes map[string]time.Time
+	// secrets holds the secret values last read by RefreshSecrets, including rotations
+	// that need a restart and were not applied, so each rotation is handled once.
+	secrets Config
+}
+
+// WatcherOptions tunes a Watcher.
+type WatcherOptions struct {
+	// Files are polled for modification; typically SourceFiles of the load options.
+	Files []string
+	// PollInterval defaults to 2s.
+	PollInterval time.Duration
+}
+
+// NewWatcher returns a Watcher starting from initial that calls load to re-read the
+// configuration.
+func NewWatcher(initial Config, load func() (Config, error), log *zap.Logger, opts WatcherOptions) *Watcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	w := &Watcher{
+		load:     load,
+		files:    opts.Files,
+		interval: opts.PollInterval,
+		log:      log,
+		mtimes:   map[string]time.Time{},
+	}
+	w.current.Store(&initial)
+	w.secrets = initial
+	for _, f := range w.files {
+		w.mtimes[f] = modTime(f)
+	}
+	return w
+}
+
+// Current returns the latest applied configuration.
+func (w *Watcher) Current() Config {
+	return *w.current.Load()
+}
+
+// Subscribe registers fn to be called with every newly applied configuration. fn runs on
+// the reloading goroutine and should not block.
+func (w *Watcher) Subscribe(fn func(Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subs = append(w.subs, fn)
+}
+
+// Run watches for SIGHUP and file changes until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			w.log.Info("config reload requested by SIGHUP")
+			_ = w.Reload()
+		case <-ticker.C:
+			if w.filesChanged() {
+				w.log.Info("config file changed, reloading")
+				_ = w.Reload()
+			}
+		}
+	}
+}
+
+// Reload loads the configuration, applies its hot-reloadable changes and notifies
+// subscribers when anything changed.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, err := w.load()
+	if err != nil {
+		w.log.Warn("config reload failed, keeping current configuration", zap.Error(err))
+		return err
+	}
+
+	w.apply(next)
+	return nil
+}
+
+// RefreshSecrets re-reads the secret references of the configuration from p and applies
+// the rotated values like a reload: hot-reloadable keys such as BASIC_AUTH_PASS reach the
+// subscribers, other keys such as DB_PASSWORD are logged once as needing a restart. Values
+// are never logged. Keys that failed to refresh keep their value and are reported in the
+// error.
+func (w *Watcher) RefreshSecrets(ctx context.Context, p secrets.Provider) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, changed, err := RefreshSecrets(ctx, w.secrets, p)
+	if len(changed) > 0 {
+		w.secrets = next
+		w.log.Info("secrets rotated", zap.Strings("keys", changed))
+		w.apply(withKeys(w.Current(), next, changed))
+	}
+	return err
+}
+
+// withKeys returns dst with the fields of keys copied from src.
+func withKeys(dst, src Config, keys []string) Config {
+	srcFields := map[string]reflect.Value{}
+	walkEnvFields(reflect.ValueOf(&src).Elem(), "", "", func(f envField) {
+		srcFields[f.Key] = f.Value
+	})
+	walkEnvFields(reflect.ValueOf(&dst).Elem(), "", "", func(f envField) {
+		if slices.Contains(keys, f.Key) {
+			f.Value.Set(srcFields[f.Key])
+		}
+	})
+	return dst
+}
+
+// apply stores the hot-reloadable changes of next and notifies subscribers when anything
+// changed. w.mu must be held.
+func (w *Watcher) apply(next Config) {
+	merged, applied, rejected := mergeHot(w.Current(), next)
+	if len(rejected) > 0 {
+		w.log.Warn("config changes need a restart and were not applied", zap.Strings("keys", rejected))
+	}
+	if len(applied) == 0 {
+		return
+	}
+	w.current.Store(&merged)
+	w.log.Info("config reloaded", zap.Strings("keys", applied))
+	for _, fn := range w.subs {
+		fn(merged)
+	}
+}
+
+// mergeHot returns cur with the reload:"hot" fields that differ in next copied over, the
+// keys applied and the keys of other fields that differ.
+func mergeHot(cur, next Config) (Config, []string, []string) {
+	var applied, rejected []string
+	nextFields := map[string]reflect.Value{}
+	walkEnvFields(reflect.ValueOf(&next).Elem(), "", "", func(f envField) {
+		nextFields[f.Key] = f.Value
+	})
+	walkEnvFields(reflect.ValueOf(&cur).Elem(), "", "", func(f envField) {
+		nf := nextFields[f.Key]
+		if reflect.DeepEqual(f.Value.Interface(), nf.Interface()) {
+			return
+		}
+		if f.Field.Tag.Get("reload") != "hot" {
+			rejected = append(rejected, f.Key)
+			return
+		}
+		f.Value.Set(nf)
+		applied = append(applied, f.Key)
+	})
+	return cur, applied, rejected
+}
+
+func (w *Watcher) filesChanged() bool {
+	changed := false
+	for _, f := range w.files {
+		if m := modTime(f); !m.Equal(w.mtimes[f]) {
+			w.mtimes[f] = m
+			changed = true
+		}
+	}
+	return changed
+}
+
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
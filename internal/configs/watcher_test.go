@@ -0,0 +1,92 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWatcher_ReloadAppliesOnlyHotFields(t *testing.T) {
+	initial := Config{HTTPAddr: ":8080", LogLevel: "info", RateLimitRequests: 10}
+	next := initial
+	w := NewWatcher(initial, func() (Config, error) { return next, nil }, zap.NewNop(), WatcherOptions{})
+
+	var got []Config
+	w.Subscribe(func(c Config) { got = append(got, c) })
+
+	next.LogLevel = "debug"
+	next.RateLimitRequests = 20
+	next.HTTPAddr = ":9999"
+	require.NoError(t, w.Reload())
+
+	cur := w.Current()
+	require.Equal(t, "debug", cur.LogLevel)
+	require.Equal(t, 20, cur.RateLimitRequests)
+	require.Equal(t, ":8080", cur.HTTPAddr, "structural fields need a restart")
+	require.Len(t, got, 1)
+	require.Equal(t, cur, got[0])
+
+	// Nothing hot changed: subscribers are not called again.
+	require.NoError(t, w.Reload())
+	require.Len(t, got, 1)
+}
+
+func TestWatcher_FailedReloadKeepsCurrent(t *testing.T) {
+	w := NewWatcher(Config{LogLevel: "info"}, func() (Config, error) {
+		return Config{LogLevel: "debug"}, errors.New("LOG_LEVEL: failed validation")
+	}, zap.NewNop(), WatcherOptions{})
+	w.Subscribe(func(Config) { t.Fatal("subscriber must not be called") })
+
+	require.Error(t, w.Reload())
+	require.Equal(t, "info", w.Current().LogLevel)
+}
+
+func TestWatcher_RefreshSecretsAppliesRotations(t *testing.T) {
+	cfg := Config{BasicAuthPass: "secret://auth/pass", DbPassword: "secret://db/password"}
+	p := mapProvider{"auth/pass": "pw1", "db/password": "db1"}
+	require.NoError(t, resolveSecrets(context.Background(), &cfg, p))
+	core, logs := observer.New(zap.InfoLevel)
+	w := NewWatcher(cfg, func() (Config, error) { return cfg, nil }, zap.New(core), WatcherOptions{})
+	var got []Config
+	w.Subscribe(func(c Config) { got = append(got, c) })
+
+	p["auth/pass"], p["db/password"] = "pw2", "db2"
+	require.NoError(t, w.RefreshSecrets(context.Background(), p))
+	require.Len(t, got, 1)
+	require.Equal(t, "pw2", got[0].BasicAuthPass)
+	require.Equal(t, "pw2", w.Current().BasicAuthPass)
+	require.Equal(t, "db1", w.Current().DbPassword, "DB_PASSWORD needs a restart")
+
+	// A rotation is handled once, even when it could not be applied.
+	require.NoError(t, w.RefreshSecrets(context.Background(), p))
+	require.Len(t, got, 1)
+	require.Len(t, logs.FilterMessage("secrets rotated").All(), 1)
+	require.Len(t, logs.FilterMessage("config changes need a restart and were not applied").All(), 1)
+
+	p["auth/pass"] = "pw3"
+	require.NoError(t, w.RefreshSecrets(context.Background(), p))
+	require.Len(t, got, 2)
+	require.Equal(t, "pw3", w.Current().BasicAuthPass)
+	require.Equal(t, "db1", w.Current().DbPassword)
+	require.Len(t, logs.FilterMessage("config changes need a restart and were not applied").All(), 1)
+}
+
+func TestWatcher_DetectsFileChanges(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(p, []byte("log_level: info\n"), 0o600))
+
+	w := NewWatcher(Config{}, func() (Config, error) { return Config{}, nil }, zap.NewNop(), WatcherOptions{Files: []string{p}})
+	require.False(t, w.filesChanged())
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(p, later, later))
+	require.True(t, w.filesChanged())
+	require.False(t, w.filesChanged())
+}
@@ -0,0 +1,264 @@
+package dbs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go-boilerplate/internal/utils/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Querier is the subset of *sql.DB used by the repositories. Both *sql.DB and *DB
+// implement it, so a repository works with a single pool or with read/write splitting.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+var (
+	queriesRouted   = metrics.NewCounterVec("db_queries_routed_total")
+	replicaEjection = metrics.NewCounterVec("db_replica_ejections_total")
+)
+
+// Replica is a named read replica pool.
+type Replica struct {
+	Name string
+	DB   *sql.DB
+}
+
+type replica struct {
+	Replica
+	healthy  atomic.Bool
+	failures int // consecutive failed probes; only touched by the health loop
+}
+
+// DBOptions tunes a DB.
+type DBOptions struct {
+	// StickyFor is how long reads in a session go to the primary after it wrote; defaults
+	// to 2s. It should exceed the usual replication lag.
+	StickyFor time.Duration
+	// HealthInterval is the time between replica probes; defaults to 5s.
+	HealthInterval time.Duration
+	// ProbeTimeout bounds a single probe; defaults to 2s.
+	ProbeTimeout time.Duration
+	// FailureThreshold is the number of consecutive failed probes that ejects a replica;
+	// defaults to 2. One successful probe restores it.
+	FailureThreshold int
+}
+
+// DB routes writes and transactions to the primary and reads to healthy replicas in round
+// robin, falling back to the primary when none is available.
+//
+// Reads go to the primary as well when ctx was marked with WithPrimary, or when it carries
+// a session (see WithSession and ResumeSession) that wrote within StickyFor, so a client
+// reads its own writes despite replication lag. Reads made through a *sql.Tx always use the
+// primary.
+type DB struct {
+	primary  *sql.DB
+	replicas []*replica
+	opts     DBOptions
+	log      *zap.Logger
+	next     atomic.Uint64
+}
+
+// NewDB returns a DB over primary and replicas. Replicas start healthy; run
+// MonitorReplicas to eject and restore them.
+func NewDB(primary *sql.DB, replicas []Replica, log *zap.Logger, opts DBOptions) *DB {
+	if opts.StickyFor <= 0 {
+		opts.StickyFor = 2 * time.Second
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = 5 * time.Second
+	}
+	if opts.ProbeTimeout <= 0 {
+		opts.ProbeTimeout = 2 * time.Second
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 2
+	}
+	d := &DB{primary: primary, opts: opts, log: log}
+	for _, r := range replicas {
+		rep := &replica{Replica: r}
+		rep.healthy.Store(true)
+		d.replicas = append(d.replicas, rep)
+	}
+	return d
+}
+
+// Primary returns the primary pool, for components that must never read stale data.
+func (d *DB) Primary() *sql.DB {
+	return d.primary
+}
+
+// Reader returns the pool a read with ctx should use.
+func (d *DB) Reader(ctx context.Context) *sql.DB {
+	if len(d.replicas) == 0 || d.mustUsePrimary(ctx) {
+		queriesRouted.Inc("primary")
+		return d.primary
+	}
+	n := uint64(len(d.replicas))
+	start := d.next.Add(1)
+	for i := range n {
+		if r := d.replicas[(start+i)%n]; r.healthy.Load() {
+			queriesRouted.Inc("replica")
+			return r.DB
+		}
+	}
+	queriesRouted.Inc("primary")
+	return d.primary
+}
+
+func (d *DB) mustUsePrimary(ctx context.Context) bool {
+	if forced, _ := ctx.Value(primaryKey{}).(bool); forced {
+		return true
+	}
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		if last := s.lastWrite.Load(); last != 0 && time.Since(time.Unix(0, last)) < d.opts.StickyFor {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryContext runs a read on the pool chosen by Reader.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return d.Reader(ctx).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs a single-row read on the pool chosen by Reader.
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return d.Reader(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// ExecContext runs a write on the primary.
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	markWrite(ctx)
+	return d.primary.ExecContext(ctx, query, args...)
+}
+
+// BeginTx starts a transaction on the primary. It counts as a write for read-your-writes.
+func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	markWrite(ctx)
+	return d.primary.BeginTx(ctx, opts)
+}
+
+// Close closes the primary and every replica pool.
+func (d *DB) Close() error {
+	errs := []error{d.primary.Close()}
+	for _, r := range d.replicas {
+		errs = append(errs, r.DB.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// MonitorReplicas probes every replica each HealthInterval until ctx is done, ejecting a
+// replica after FailureThreshold consecutive failures and restoring it once it answers.
+func (d *DB) MonitorReplicas(ctx context.Context) {
+	if len(d.replicas) == 0 {
+		return
+	}
+	ticker := time.NewTicker(d.opts.HealthInterval)
+	defer ticker.Stop()
+	for {
+		d.probeReplicas(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *DB) probeReplicas(ctx context.Context) {
+	for _, r := range d.replicas {
+		pctx, cancel := context.WithTimeout(ctx, d.opts.ProbeTimeout)
+		err := r.DB.PingContext(pctx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			r.failures = 0
+			if !r.healthy.Swap(true) {
+				d.log.Info("database replica restored", zap.String("replica", r.Name))
+			}
+			continue
+		}
+		r.failures++
+		if r.failures >= d.opts.FailureThreshold && r.healthy.Swap(false) {
+			replicaEjection.Inc(r.Name)
+			d.log.Warn("database replica ejected", zap.String("replica", r.Name), zap.Int("failures", r.failures), zap.Error(err))
+		}
+	}
+}
+
+// HealthyReplicas returns the number of replicas currently receiving reads.
+func (d *DB) HealthyReplicas() int {
+	n := 0
+	for _, r := range d.replicas {
+		if r.healthy.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+type (
+	sessionKey struct{}
+	primaryKey struct{}
+)
+
+// session remembers when its context last wrote to the primary.
+type session struct {
+	lastWrite atomic.Int64 // unix nanoseconds
+}
+
+// WithSession returns ctx carrying a read-your-writes session. Install it once per unit of
+// work; writes made with ctx (or a context derived from it) then keep its reads on the
+// primary for DBOptions.StickyFor. To carry a session across units of work, such as the
+// requests of one HTTP client, save LastWrite and pass it to ResumeSession next time.
+func WithSession(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(sessionKey{}).(*session); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// ResumeSession returns ctx carrying a read-your-writes session that last wrote at
+// lastWrite, as reported by LastWrite for an earlier unit of work.
+func ResumeSession(ctx context.Context, lastWrite time.Time) context.Context {
+	s := &session{}
+	if !lastWrite.IsZero() {
+		s.lastWrite.Store(lastWrite.UnixNano())
+	}
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// LastWrite returns when the session of ctx last wrote to the primary; the zero time when
+// it has not written or ctx carries no session.
+func LastWrite(ctx context.Context) time.Time {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		if last := s.lastWrite.Load(); last != 0 {
+			return time.Unix(0, last)
+		}
+	}
+	return time.Time{}
+}
+
+// WithPrimary returns ctx whose reads always go to the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func markWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.lastWrite.Store(time.Now().UnixNano())
+	}
+}
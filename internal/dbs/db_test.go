@@ -0,0 +1,96 @@
+package dbs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+func TestDB_RoutesReadsToReplicasAndWritesToPrimary(t *testing.T) {
+	primary, pm := newMockDB(t)
+	r1, m1 := newMockDB(t)
+	r2, m2 := newMockDB(t)
+	d := NewDB(primary, []Replica{{Name: "r1", DB: r1}, {Name: "r2", DB: r2}}, zap.NewNop(), DBOptions{})
+
+	// Reads alternate between the replicas.
+	m1.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	m2.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	for range 2 {
+		var id int
+		require.NoError(t, d.QueryRowContext(context.Background(), "SELECT id FROM users WHERE id = ?", 1).Scan(&id))
+	}
+
+	// Writes and transactions use the primary.
+	pm.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+	pm.ExpectBegin()
+	pm.ExpectRollback()
+	_, err := d.ExecContext(context.Background(), "UPDATE users SET amount = ? WHERE id = ?", 10, 1)
+	require.NoError(t, err)
+	tx, err := d.BeginTx(context.Background(), nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	require.NoError(t, pm.ExpectationsWereMet())
+	require.NoError(t, m1.ExpectationsWereMet())
+	require.NoError(t, m2.ExpectationsWereMet())
+}
+
+func TestDB_ReadYourWrites(t *testing.T) {
+	primary, _ := newMockDB(t)
+	replica, _ := newMockDB(t)
+	d := NewDB(primary, []Replica{{Name: "r1", DB: replica}}, zap.NewNop(), DBOptions{StickyFor: 50 * time.Millisecond})
+
+	ctx := WithSession(context.Background())
+	require.Same(t, replica, d.Reader(ctx))
+
+	markWrite(ctx)
+	require.Same(t, primary, d.Reader(ctx), "reads right after a write stay on the primary")
+	require.Same(t, replica, d.Reader(context.Background()), "other sessions are not affected")
+
+	time.Sleep(60 * time.Millisecond)
+	require.Same(t, replica, d.Reader(ctx), "stickiness expires")
+
+	require.Same(t, primary, d.Reader(WithPrimary(context.Background())))
+}
+
+func TestDB_NoReplicasUsesPrimary(t *testing.T) {
+	primary, _ := newMockDB(t)
+	d := NewDB(primary, nil, zap.NewNop(), DBOptions{})
+	require.Same(t, primary, d.Reader(context.Background()))
+}
+
+func TestDB_EjectsAndRestoresReplicas(t *testing.T) {
+	primary, _ := newMockDB(t)
+	replica, rm := newMockDB(t)
+	d := NewDB(primary, []Replica{{Name: "r1", DB: replica}}, zap.NewNop(), DBOptions{FailureThreshold: 2})
+	down := errors.New("connection refused")
+
+	rm.ExpectPing().WillReturnError(down)
+	d.probeReplicas(context.Background())
+	require.Equal(t, 1, d.HealthyReplicas(), "one failure is tolerated")
+
+	rm.ExpectPing().WillReturnError(down)
+	d.probeReplicas(context.Background())
+	require.Equal(t, 0, d.HealthyReplicas())
+	require.Same(t, primary, d.Reader(context.Background()), "reads fall back to the primary")
+
+	rm.ExpectPing()
+	d.probeReplicas(context.Background())
+	require.Equal(t, 1, d.HealthyReplicas())
+	require.Same(t, replica, d.Reader(context.Background()))
+	require.NoError(t, rm.ExpectationsWereMet())
+}
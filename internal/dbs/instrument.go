@@ -0,0 +1,372 @@
+package dbs
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go-boilerplate/internal/utils/metrics"
+
+	"go.uber.org/zap"
+)
+
+var queryDuration = metrics.NewHistogramVec("db_query_duration_seconds", metrics.DurationBuckets)
+
+// InstrumentOptions tunes the query instrumentation.
+type InstrumentOptions struct {
+	// SlowThreshold logs queries that take at least this long; 0 disables slow query logs.
+	SlowThreshold time.Duration
+	// Explain runs EXPLAIN on slow SELECTs and logs the plan, at most once a minute per
+	// fingerprint. Meant for non-production stages: the statement runs again.
+	Explain bool
+	Log     *zap.Logger
+}
+
+// Instrument wraps c so that every query and exec on its connections is timed into the
+// db_query_duration_seconds histogram, labelled by the query fingerprint (see Fingerprint),
+// and logged when slower than opts.SlowThreshold. Argument values are never logged.
+func Instrument(c driver.Connector, opts InstrumentOptions) *InstrumentedConnector {
+	if opts.Log == nil {
+		opts.Log = zap.NewNop()
+	}
+	return &InstrumentedConnector{Connector: c, opts: opts, explained: map[string]time.Time{}}
+}
+
+// InstrumentedConnector is a driver.Connector returned by Instrument.
+type InstrumentedConnector struct {
+	driver.Connector
+	opts InstrumentOptions
+
+	mu        sync.Mutex
+	db        *sql.DB // runs EXPLAIN; set by ExplainWith
+	explained map[string]time.Time
+}
+
+// ExplainWith sets the pool used to run EXPLAIN, normally the one opened on c.
+func (c *InstrumentedConnector) ExplainWith(db *sql.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.db = db
+}
+
+// Connect implements driver.Connector.
+func (c *InstrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn, in: c}, nil
+}
+
+// observe records one statement. rows is the number of rows affected, or -1 for reads.
+func (c *InstrumentedConnector) observe(ctx context.Context, query string, args []driver.NamedValue, took time.Duration, rows int64, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+	fp := Fingerprint(query)
+	queryDuration.Observe(fp, took.Seconds())
+	if c.opts.SlowThreshold <= 0 || took < c.opts.SlowThreshold {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("sql", fp),
+		zap.Int("args", len(args)),
+		zap.Duration("duration", took),
+		zap.String("caller", caller()),
+	}
+	if rows >= 0 {
+		fields = append(fields, zap.Int64("rows_affected", rows))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	c.opts.Log.Warn("slow query", fields...)
+
+	if c.opts.Explain && err == nil && isSelect(query) && c.shouldExplain(fp) {
+		go c.explain(context.WithoutCancel(ctx), fp, query, args)
+	}
+}
+
+func (c *InstrumentedConnector) shouldExplain(fp string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.db == nil || time.Since(c.explained[fp]) < time.Minute {
+		return false
+	}
+	c.explained[fp] = time.Now()
+	return true
+}
+
+func (c *InstrumentedConnector) explain(ctx context.Context, fp, query string, args []driver.NamedValue) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	values := make([]any, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+query, values...)
+	if err != nil {
+		c.opts.Log.Warn("explain failed", zap.String("sql", fp), zap.Error(err))
+		return
+	}
+	defer rows.Close()
+	plan, err := scanMaps(rows)
+	if err != nil {
+		c.opts.Log.Warn("explain failed", zap.String("sql", fp), zap.Error(err))
+		return
+	}
+	c.opts.Log.Info("slow query plan", zap.String("sql", fp), zap.Any("plan", plan))
+}
+
+// scanMaps reads every row into a column → value map; byte slices become strings.
+func scanMaps(rows *sql.Rows) ([]map[string]any, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var out []map[string]any
+	for rows.Next() {
+		values := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(cols))
+		for i, col := range cols {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[col] = values[i]
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
+
+// instrumentedConn times the statements run directly on the connection and wraps the
+// prepared statements it returns. Optional driver interfaces are forwarded.
+type instrumentedConn struct {
+	driver.Conn
+	in *InstrumentedConnector
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{Stmt: stmt, query: query, in: c.in}, nil
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.ReadOnly || opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("dbs: driver does not support transaction options")
+	}
+	return c.Conn.Begin() //nolint:staticcheck // fallback for drivers without BeginTx
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	c.in.observe(ctx, query, args, time.Since(start), rowsAffected(res), err)
+	return res, err
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	c.in.observe(ctx, query, args, time.Since(start), -1, err)
+	return rows, err
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// instrumentedStmt times executions of a prepared statement.
+type instrumentedStmt struct {
+	driver.Stmt
+	query string
+	in    *InstrumentedConnector
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var (
+		res driver.Result
+		err error
+	)
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		res, err = s.Stmt.Exec(values(args)) //nolint:staticcheck // fallback for old drivers
+	}
+	s.in.observe(ctx, s.query, args, time.Since(start), rowsAffected(res), err)
+	return res, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(values(args)) //nolint:staticcheck // fallback for old drivers
+	}
+	s.in.observe(ctx, s.query, args, time.Since(start), -1, err)
+	return rows, err
+}
+
+func (s *instrumentedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	out := make([]driver.Value, len(args))
+	for i, a := range args {
+		out[i] = a.Value
+	}
+	return out
+}
+
+func rowsAffected(res driver.Result) int64 {
+	if res == nil {
+		return 0
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+var (
+	sqlStrings      = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.)*"`)
+	sqlDollarParams = regexp.MustCompile(`\$\d+`)
+	sqlNumbers      = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	sqlLists        = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)+\s*\)`)
+	sqlRepeatedRows = regexp.MustCompile(`(\(\?(?:, \.\.\.)?\))(?:\s*,\s*\(\?(?:, \.\.\.)?\))+`)
+	sqlSpaces       = regexp.MustCompile(`\s+`)
+)
+
+// Fingerprint normalises query so that statements differing only in literal values,
+// placeholder style, list lengths or whitespace share one label: literals and $n
+// placeholders become ?, lists of placeholders become (?, ...) and multi-row VALUES
+// collapse to a single row.
+func Fingerprint(query string) string {
+	q := sqlStrings.ReplaceAllString(query, "?")
+	q = sqlDollarParams.ReplaceAllString(q, "?")
+	q = sqlNumbers.ReplaceAllString(q, "?")
+	q = sqlSpaces.ReplaceAllString(q, " ")
+	q = sqlLists.ReplaceAllString(q, "(?, ...)")
+	q = sqlRepeatedRows.ReplaceAllString(q, "$1, ...")
+	return strings.TrimSpace(q)
+}
+
+func isSelect(query string) bool {
+	q := strings.TrimSpace(query)
+	return len(q) >= 6 && strings.EqualFold(q[:6], "select")
+}
+
+// dbsPackage is the import path of this package, skipped when looking for the caller.
+var dbsPackage = reflect.TypeOf(instrumentedConn{}).PkgPath()
+
+// caller returns "file:line" of the first frame outside database/sql and this package,
+// i.e. the repository method that ran the query.
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		internal := strings.HasPrefix(f.Function, "database/sql.") ||
+			(strings.HasPrefix(f.Function, dbsPackage+".") && !strings.HasSuffix(f.File, "_test.go"))
+		if !internal && f.Function != "" {
+			return fmt.Sprintf("%s:%d", trimPath(f.File), f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// trimPath shortens a file path to its last two elements, like zap's short caller.
+func trimPath(file string) string {
+	i := strings.LastIndexByte(file, '/')
+	if i < 0 {
+		return file
+	}
+	if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+		return file[j+1:]
+	}
+	return file
+}
@@ -0,0 +1,104 @@
+package dbs
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// dsnConnector opens connections to a sqlmock DSN.
+type dsnConnector struct {
+	drv driver.Driver
+	dsn string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.drv.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.drv }
+
+func newInstrumentedDB(t *testing.T, opts InstrumentOptions) (*sql.DB, sqlmock.Sqlmock, *observer.ObservedLogs) {
+	t.Helper()
+	dsn := "instrument_" + strings.ReplaceAll(t.Name(), "/", "_")
+	raw, mock, err := sqlmock.NewWithDSN(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { raw.Close() })
+
+	core, logs := observer.New(zap.InfoLevel)
+	opts.Log = zap.New(core)
+	ic := Instrument(dsnConnector{drv: raw.Driver(), dsn: dsn}, opts)
+	db := sql.OpenDB(ic)
+	ic.ExplainWith(db)
+	t.Cleanup(func() { db.Close() })
+	return db, mock, logs
+}
+
+func TestInstrument_LogsSlowQueries(t *testing.T) {
+	db, mock, logs := newInstrumentedDB(t, InstrumentOptions{SlowThreshold: 10 * time.Millisecond})
+
+	mock.ExpectExec("UPDATE users").WithArgs(500, "secret-value").
+		WillDelayFor(20 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 3))
+	_, err := db.ExecContext(context.Background(), "UPDATE users SET amount = ?  WHERE name = ?", 500, "secret-value")
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	rows, err := db.QueryContext(context.Background(), "SELECT id FROM users WHERE id = 7")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	slow := logs.FilterMessage("slow query").All()
+	require.Len(t, slow, 1, "only the slow statement is logged")
+	fields := slow[0].ContextMap()
+	require.Equal(t, "UPDATE users SET amount = ? WHERE name = ?", fields["sql"])
+	require.Equal(t, int64(2), fields["args"])
+	require.Equal(t, int64(3), fields["rows_affected"])
+	require.Contains(t, fields["caller"], "dbs/instrument_test.go:")
+	for _, v := range fields {
+		if s, ok := v.(string); ok {
+			require.NotContains(t, s, "secret-value", "argument values are never logged")
+		}
+	}
+
+	// Both statements are timed.
+	require.Equal(t, uint64(1), queryDuration.Snapshot("UPDATE users SET amount = ? WHERE name = ?").Count)
+	require.Equal(t, uint64(1), queryDuration.Snapshot("SELECT id FROM users WHERE id = ?").Count)
+}
+
+func TestInstrument_ExplainsSlowSelects(t *testing.T) {
+	db, mock, logs := newInstrumentedDB(t, InstrumentOptions{SlowThreshold: time.Millisecond, Explain: true})
+
+	mock.ExpectQuery("SELECT id FROM orders").WithArgs(42).
+		WillDelayFor(5 * time.Millisecond).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("EXPLAIN SELECT id FROM orders").WithArgs(42).
+		WillReturnRows(sqlmock.NewRows([]string{"table", "type", "rows"}).AddRow("orders", "ALL", 10000))
+
+	rows, err := db.QueryContext(context.Background(), "SELECT id FROM orders WHERE user_id = ?", 42)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	require.Eventually(t, func() bool { return logs.FilterMessage("slow query plan").Len() == 1 }, time.Second, 5*time.Millisecond)
+	plan := logs.FilterMessage("slow query plan").All()[0].ContextMap()["plan"]
+	require.Equal(t, []map[string]any{{"table": "orders", "type": "ALL", "rows": int64(10000)}}, plan)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFingerprint(t *testing.T) {
+	cases := map[string]string{
+		"SELECT id, user_id FROM users WHERE id = $1":                       "SELECT id, user_id FROM users WHERE id = ?",
+		"SELECT *\n\tFROM t1 WHERE name = 'O''Brien' AND n > 3.5":           "SELECT * FROM t1 WHERE name = ? AND n > ?",
+		"SELECT * FROM users WHERE id IN (?, ?, ?)":                         "SELECT * FROM users WHERE id IN (?, ...)",
+		"SELECT * FROM users WHERE id IN (1,2,3,4)":                         "SELECT * FROM users WHERE id IN (?, ...)",
+		"INSERT INTO users (user_id, amount) VALUES (?, ?), (?, ?), (?, ?)": "INSERT INTO users (user_id, amount) VALUES (?, ...), ...",
+		"UPDATE api_keys SET revoked_at = ? WHERE id = ?":                   "UPDATE api_keys SET revoked_at = ? WHERE id = ?",
+	}
+	for in, want := range cases {
+		require.Equal(t, want, Fingerprint(in), in)
+	}
+}
@@ -6,33 +6,64 @@ import (
 	"fmt"
 	"go-boilerplate/internal/configs"
 	"math/rand"
+	"net"
+	"strconv"
 	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
 )
 
 // NewMySQLDB initializes a new MySQL database connection with the provided configuration.
 // It sets up connection parameters such as user, password, host, port, and database name.
 // The function also configures connection timeouts and maximum connection settings.
 // It returns a pointer to the sql.DB instance or an error if the connection fails.
-// The connection string includes options for parsing time and setting read/write timeouts.
-// It also ensures that the database connection is healthy by pinging it before returning.
+// The DSN is built by MySQLDSN, so TLS, charset, collation, loc and interpolateParams
+// come from the configuration.
 // The connection pool is configured with maximum open connections, idle connections, and connection lifetime settings.
 // The jitter is added to the connection max lifetime to avoid thundering herd problems.
-// The function uses the context package to set a timeout for the ping operation to ensure that the connection is responsive.
-// If the ping fails, it closes the database connection and returns an error.
-// The function is designed to be used in applications that require a MySQL database connection with specific configurations.
-// It is a common pattern in Go applications to manage database connections efficiently.
-// The function is part of the dbs package, which contains database-related functionalities.
+// The database is pinged before returning, retrying with exponential backoff until
+// DB_CONNECT_TIMEOUT_MS so a database that starts slightly later than the app does not
+// fail the deployment. Each attempt is logged and counted in the db_connect_* metrics.
 // It is expected to be called during the application initialization phase to set up the database connection.
-// The function is designed to be flexible and can be adapted for different MySQL configurations as needed.
-func NewMySQLDB(cfg configs.Config) (*sql.DB, error) {
-	// Add timeouts & parseTime
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&timeout=5s&readTimeout=5s&writeTimeout=5s",
-		cfg.DbUser, cfg.DbPassword, cfg.DbHost, cfg.DbPort, cfg.DbName,
-	)
-	db, err := sql.Open("mysql", dsn)
+func NewMySQLDB(ctx context.Context, cfg configs.Config, log *zap.Logger) (*sql.DB, error) {
+	db, err := OpenMySQL(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	pingTimeout := time.Duration(cfg.DbPingTimeoutMS) * time.Millisecond
+	err = retry(ctx, "mysql", RetryPolicyFromConfig(cfg), log, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+		defer cancel()
+		return db.PingContext(ctx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenMySQL creates the connection pool for cfg without connecting. Queries are
+// instrumented (see Instrument): slower than DB_SLOW_QUERY_MS they are logged to log, and
+// with DB_EXPLAIN_SLOW_QUERIES their plan too, except in production stages.
+func OpenMySQL(cfg configs.Config, log *zap.Logger) (*sql.DB, error) {
+	mc, err := mysqlConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
+	connector, err := mysql.NewConnector(mc)
+	if err != nil {
+		return nil, err
+	}
+	ic := Instrument(connector, InstrumentOptions{
+		SlowThreshold: time.Duration(cfg.DbSlowQueryMS) * time.Millisecond,
+		Explain:       cfg.DbExplainSlowQueries && !configs.IsProduction(cfg.Stage),
+		Log:           log,
+	})
+	db := sql.OpenDB(ic)
+	ic.ExplainWith(db)
 
 	db.SetMaxOpenConns(cfg.DbMaxOpenConns)
 	db.SetMaxIdleConns(cfg.DbMaxIdleConns)
@@ -40,12 +71,79 @@ func NewMySQLDB(cfg configs.Config) (*sql.DB, error) {
 	jitter := time.Duration(rand.Intn(5)) * time.Minute
 	db.SetConnMaxLifetime(time.Duration(cfg.DbConnMaxLifetime)*time.Minute - jitter)
 	db.SetConnMaxIdleTime(10 * time.Minute)
+	return db, nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-	if err := db.PingContext(ctx); err != nil {
-		db.Close()
-		return nil, err
+// NewMySQLReplicas opens a pool for every host in DB_REPLICA_HOSTS, with the primary's
+// credentials and settings. It does not connect: an unreachable replica is ejected by
+// DB.MonitorReplicas instead of failing the startup.
+func NewMySQLReplicas(cfg configs.Config, log *zap.Logger) ([]Replica, error) {
+	replicas := make([]Replica, 0, len(cfg.DbReplicaHosts))
+	for _, hostport := range cfg.DbReplicaHosts {
+		rc := cfg
+		rc.DbHost = hostport
+		if host, port, err := net.SplitHostPort(hostport); err == nil {
+			rc.DbHost = host
+			if rc.DbPort, err = strconv.Atoi(port); err != nil {
+				closeReplicas(replicas)
+				return nil, fmt.Errorf("DB_REPLICA_HOSTS: invalid port in %q", hostport)
+			}
+		}
+		db, err := OpenMySQL(rc, log.With(zap.String("replica", hostport)))
+		if err != nil {
+			closeReplicas(replicas)
+			return nil, err
+		}
+		replicas = append(replicas, Replica{Name: hostport, DB: db})
 	}
-	return db, nil
+	return replicas, nil
+}
+
+func closeReplicas(replicas []Replica) {
+	for _, r := range replicas {
+		r.DB.Close()
+	}
+}
+
+// MySQLDSN builds the driver DSN from cfg. Times are parsed into time.Time and the dial,
+// read and write timeouts are 5s.
+func MySQLDSN(cfg configs.Config) (string, error) {
+	c, err := mysqlConfig(cfg)
+	if err != nil {
+		return "", err
+	}
+	return c.FormatDSN(), nil
+}
+
+func mysqlConfig(cfg configs.Config) (*mysql.Config, error) {
+	c := mysql.NewConfig()
+	c.User = cfg.DbUser
+	c.Passwd = cfg.DbPassword
+	c.Net = "tcp"
+	c.Addr = cfg.DbHost + ":" + strconv.Itoa(cfg.DbPort)
+	c.DBName = cfg.DbName
+	c.ParseTime = true
+	c.Timeout = 5 * time.Second
+	c.ReadTimeout = 5 * time.Second
+	c.WriteTimeout = 5 * time.Second
+	c.TLSConfig = cfg.DbTLS
+	c.Collation = cfg.DbCollation
+	c.InterpolateParams = cfg.DbInterpolateParams
+	if cfg.DbLoc != "" {
+		loc, err := time.LoadLocation(cfg.DbLoc)
+		if err != nil {
+			return nil, fmt.Errorf("DB_LOC: %w", err)
+		}
+		c.Loc = loc
+	}
+	if len(cfg.DbParams) > 0 || cfg.DbCharset != "" {
+		c.Params = make(map[string]string, len(cfg.DbParams)+1)
+		for k, v := range cfg.DbParams {
+			c.Params[k] = v
+		}
+		if cfg.DbCharset != "" {
+			c.Params["charset"] = cfg.DbCharset
+		}
+	}
+	return c, nil
 }
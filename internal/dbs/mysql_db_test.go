@@ -0,0 +1,111 @@
+package dbs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/configs"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMySQLDSN(t *testing.T) {
+	dsn, err := MySQLDSN(configs.Config{
+		DbUser:              "app",
+		DbPassword:          "p@ss",
+		DbHost:              "db",
+		DbPort:              3307,
+		DbName:              "orders",
+		DbTLS:               "skip-verify",
+		DbCharset:           "utf8mb4",
+		DbCollation:         "utf8mb4_unicode_ci",
+		DbLoc:               "Asia/Jakarta",
+		DbInterpolateParams: true,
+		DbParams:            map[string]string{"sql_mode": "'STRICT_ALL_TABLES'"},
+	})
+	require.NoError(t, err)
+
+	c, err := mysql.ParseDSN(dsn)
+	require.NoError(t, err)
+	require.Equal(t, "app", c.User)
+	require.Equal(t, "p@ss", c.Passwd)
+	require.Equal(t, "db:3307", c.Addr)
+	require.Equal(t, "orders", c.DBName)
+	require.Equal(t, "skip-verify", c.TLSConfig)
+	require.Equal(t, "utf8mb4_unicode_ci", c.Collation)
+	require.Equal(t, "Asia/Jakarta", c.Loc.String())
+	require.True(t, c.InterpolateParams)
+	require.True(t, c.ParseTime)
+	require.Equal(t, 5*time.Second, c.ReadTimeout)
+	require.Equal(t, "'STRICT_ALL_TABLES'", c.Params["sql_mode"])
+	require.Contains(t, dsn, "charset=utf8mb4")
+}
+
+func TestMySQLDSN_InvalidLoc(t *testing.T) {
+	_, err := MySQLDSN(configs.Config{DbLoc: "Mars/Olympus"})
+	require.ErrorContains(t, err, "DB_LOC")
+}
+
+func TestRetry_SucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	p := RetryPolicy{Initial: time.Millisecond, Max: 4 * time.Millisecond, Deadline: time.Second}
+	err := retry(context.Background(), "test-ok", p, zap.NewNop(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+	require.Equal(t, int64(2), connectAttempts.Value("test-ok:failure"))
+	require.Equal(t, int64(1), connectAttempts.Value("test-ok:success"))
+	require.Equal(t, uint64(1), connectDuration.Snapshot("test-ok").Count)
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	refused := errors.New("connection refused")
+
+	t.Run("max attempts", func(t *testing.T) {
+		calls := 0
+		p := RetryPolicy{Initial: time.Millisecond, Max: time.Millisecond, Deadline: time.Minute, MaxAttempts: 3}
+		err := retry(context.Background(), "test-attempts", p, zap.NewNop(), func(context.Context) error {
+			calls++
+			return refused
+		})
+		require.ErrorIs(t, err, refused)
+		require.ErrorContains(t, err, "giving up after 3 attempt(s)")
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("no deadline tries once", func(t *testing.T) {
+		calls := 0
+		err := retry(context.Background(), "test-once", RetryPolicy{Initial: time.Millisecond, Max: time.Millisecond}, zap.NewNop(), func(context.Context) error {
+			calls++
+			return refused
+		})
+		require.ErrorIs(t, err, refused)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		p := RetryPolicy{Initial: 10 * time.Millisecond, Max: 20 * time.Millisecond, Deadline: 100 * time.Millisecond}
+		start := time.Now()
+		err := retry(context.Background(), "test-deadline", p, zap.NewNop(), func(context.Context) error { return refused })
+		require.ErrorIs(t, err, refused)
+		require.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{Initial: 100 * time.Millisecond, Max: time.Second}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 10: time.Second} {
+		got := p.backoff(attempt)
+		require.GreaterOrEqual(t, got, want/2, "attempt %d", attempt)
+		require.LessOrEqual(t, got, want, "attempt %d", attempt)
+	}
+}
@@ -0,0 +1,25 @@
+package dbs
+
+import (
+	"context"
+	"go-boilerplate/internal/configs"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedisClient initializes a client for a Redis-protocol server using the provided configuration.
+// It pings the server with a short timeout so misconfiguration is caught at startup. The
+// client is returned even when the ping fails: it reconnects by itself once the server is
+// reachable, and must be closed either way.
+func NewRedisClient(cfg configs.Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return client, client.Ping(ctx).Err()
+}
@@ -0,0 +1,97 @@
+package dbs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"go-boilerplate/internal/configs"
+	"go-boilerplate/internal/utils/metrics"
+
+	"go.uber.org/zap"
+)
+
+var (
+	connectAttempts = metrics.NewCounterVec("db_connect_attempts_total")
+	connectDuration = metrics.NewHistogramVec("db_connect_duration_seconds", metrics.DurationBuckets)
+)
+
+// RetryPolicy controls how a connection is retried at startup. Each wait doubles from
+// Initial up to Max, with up to half of it replaced by random jitter so that replicas
+// restarting together do not retry in lockstep.
+type RetryPolicy struct {
+	Initial time.Duration
+	Max     time.Duration
+	// Deadline bounds all attempts together; 0 means a single attempt.
+	Deadline time.Duration
+	// MaxAttempts stops earlier than Deadline when positive.
+	MaxAttempts int
+}
+
+// RetryPolicyFromConfig returns the database connection retry policy in cfg.
+func RetryPolicyFromConfig(cfg configs.Config) RetryPolicy {
+	return RetryPolicy{
+		Initial:     time.Duration(cfg.DbConnectRetryInitialMS) * time.Millisecond,
+		Max:         time.Duration(cfg.DbConnectRetryMaxMS) * time.Millisecond,
+		Deadline:    time.Duration(cfg.DbConnectTimeoutMS) * time.Millisecond,
+		MaxAttempts: cfg.DbConnectMaxAttempts,
+	}
+}
+
+// backoff returns the wait after the given failed attempt (1-based).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Initial
+	for i := 1; i < attempt && d < p.Max; i++ {
+		d *= 2
+	}
+	d = min(d, p.Max)
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retry calls connect until it succeeds, the policy gives up or ctx is done. Every attempt
+// is logged and counted under name in the db_connect_* metrics.
+func retry(ctx context.Context, name string, p RetryPolicy, log *zap.Logger, connect func(ctx context.Context) error) error {
+	start := time.Now()
+	if p.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Deadline)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx)
+		if err == nil {
+			connectAttempts.Inc(name + ":success")
+			connectDuration.Observe(name, time.Since(start).Seconds())
+			log.Info("connected", zap.String("target", name), zap.Int("attempt", attempt), zap.Duration("took", time.Since(start)))
+			return nil
+		}
+		connectAttempts.Inc(name + ":failure")
+
+		wait := p.backoff(attempt)
+		last := p.Deadline <= 0 || (p.MaxAttempts > 0 && attempt >= p.MaxAttempts)
+		if dl, ok := ctx.Deadline(); ok && time.Until(dl) < wait {
+			last = true
+		}
+		if last || ctx.Err() != nil {
+			connectDuration.Observe(name, time.Since(start).Seconds())
+			log.Error("connection failed, giving up", zap.String("target", name), zap.Int("attempt", attempt), zap.Duration("took", time.Since(start)), zap.Error(err))
+			return fmt.Errorf("%s: giving up after %d attempt(s): %w", name, attempt, err)
+		}
+		log.Warn("connection attempt failed, retrying", zap.String("target", name), zap.Int("attempt", attempt), zap.Duration("retry_in", wait), zap.Error(err))
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%s: giving up after %d attempt(s): %w", name, attempt, errors.Join(err, ctx.Err()))
+		case <-t.C:
+		}
+	}
+}
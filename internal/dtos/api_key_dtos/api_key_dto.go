@@ -0,0 +1,22 @@
+package apikeydtos
+
+import "time"
+
+// MintAPIKeyDTO is the request to create a new API key.
+// Scopes are stored comma-separated, so they may not contain a comma; ExpiresAt, when set,
+// must be in the future.
+type MintAPIKeyDTO struct {
+	Name               string     `json:"name" validate:"required,max=100"`
+	Scopes             []string   `json:"scopes" validate:"dive,required,excludesall=0x2C"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute" validate:"min=0"`
+	ExpiresAt          *time.Time `json:"expires_at" validate:"omitempty,gt"`
+}
+
+// MintedAPIKeyDTO is returned once when a key is created. Key is the only time the
+// plaintext secret is ever exposed.
+type MintedAPIKeyDTO struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	Key    string `json:"key"`
+}
@@ -0,0 +1,19 @@
+package auditdtos
+
+import "go-boilerplate/internal/entities"
+
+// ListAuditDTO selects a page of the audit trail of one entity.
+type ListAuditDTO struct {
+	Entity string `form:"entity" validate:"required,max=64"`
+	ID     string `form:"id" validate:"required,max=64"`
+	Limit  int    `form:"limit" validate:"min=0,max=1000"`
+	Offset int    `form:"offset" validate:"min=0"`
+}
+
+// AuditPageDTO is one page of audit entries, newest first.
+type AuditPageDTO struct {
+	Entries []entities.AuditEntry `json:"entries"`
+	Total   int64                 `json:"total"`
+	Limit   int                   `json:"limit"`
+	Offset  int                   `json:"offset"`
+}
@@ -0,0 +1,260 @@
+package exampledtos
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-boilerplate/internal/entities"
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Batch import modes.
+const (
+	// BatchModeAtomic stores every row or none: a single invalid row rejects the batch.
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort stores every valid row it can and reports the others.
+	BatchModeBestEffort = "best_effort"
+)
+
+// Outcomes of a row in a batch import.
+const (
+	RowCreated = "created"
+	RowInvalid = "invalid" // could not be decoded or failed validation
+	RowFailed  = "failed"  // valid, but could not be stored
+	RowSkipped = "skipped" // valid, but not stored because another row was invalid
+)
+
+// Batch upload formats, selected by Content-Type.
+const (
+	ContentTypeJSON   = "application/json"     // a JSON array of ExampleDTO
+	ContentTypeNDJSON = "application/x-ndjson" // one ExampleDTO per line
+	ContentTypeCSV    = "text/csv"             // header row: user_id,amount,currency[,date]
+)
+
+// Errors returned by DecodeBatch.
+var (
+	ErrUnsupportedBatchFormat = errors.New("unsupported batch content type")
+	// ErrMalformedBatch wraps errors that make the rest of an upload unreadable, such as
+	// broken JSON syntax or CSV quoting. Problems confined to a row are reported per row.
+	ErrMalformedBatch = errors.New("malformed batch")
+	ErrTooManyRows    = errors.New("too many rows in batch")
+	ErrEmptyBatch     = errors.New("batch has no rows")
+)
+
+// BatchQueryDTO holds the query parameters of a batch import.
+type BatchQueryDTO struct {
+	Mode string `form:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	// Async forces the import to run, or not to run, as a background job. By default
+	// large uploads run in the background.
+	Async *bool `form:"async"`
+}
+
+// BatchRow is one decoded row of a batch upload.
+type BatchRow struct {
+	// Row is the 1-based position of the row in the upload, not counting the CSV header
+	// or blank NDJSON lines.
+	Row     int
+	Example ExampleDTO
+	// Err is set when the row could not be decoded; Example is then incomplete.
+	Err error
+}
+
+// BatchRowResultDTO is the outcome of one row.
+type BatchRowResultDTO struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	ID     int64  `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResultDTO reports the outcome of a batch import, row by row and in total.
+type BatchResultDTO struct {
+	Mode    string              `json:"mode"`
+	Total   int                 `json:"total"`
+	Created int                 `json:"created"`
+	Invalid int                 `json:"invalid"`
+	Failed  int                 `json:"failed"`
+	Skipped int                 `json:"skipped"`
+	Rows    []BatchRowResultDTO `json:"rows"`
+}
+
+// BatchJobDTO is the status of a batch import running in the background. Result is set
+// once it has succeeded; Error once it has failed.
+type BatchJobDTO struct {
+	ID         string          `json:"id"`
+	Status     string          `json:"status"`
+	Done       int             `json:"done"`
+	Total      int             `json:"total"`
+	Result     *BatchResultDTO `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// BatchImportDTO is the answer to a batch import: its result when it ran right away, or
+// the job running it in the background.
+type BatchImportDTO struct {
+	Result *BatchResultDTO `json:"result,omitempty"`
+	Job    *BatchJobDTO    `json:"job,omitempty"`
+}
+
+// DecodeBatch reads the rows of an upload in the format named by contentType. It fails
+// with ErrTooManyRows once the upload holds more than maxRows rows (0 for no limit).
+func DecodeBatch(r io.Reader, contentType string, maxRows int) ([]BatchRow, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedBatchFormat, contentType)
+	}
+	var rows []BatchRow
+	add := func(e ExampleDTO, err error) error {
+		if maxRows > 0 && len(rows) >= maxRows {
+			return fmt.Errorf("%w: more than %d", ErrTooManyRows, maxRows)
+		}
+		rows = append(rows, BatchRow{Row: len(rows) + 1, Example: e, Err: err})
+		return nil
+	}
+
+	switch mediaType {
+	case ContentTypeJSON:
+		err = decodeJSONArray(r, add)
+	case ContentTypeNDJSON, "application/ndjson":
+		err = decodeNDJSON(r, add)
+	case ContentTypeCSV:
+		err = decodeCSV(r, add)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedBatchFormat, mediaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	return rows, nil
+}
+
+func decodeJSONArray(r io.Reader, add func(ExampleDTO, error) error) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return fmt.Errorf("%w: expected a JSON array", ErrMalformedBatch)
+	}
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("%w: %w", ErrMalformedBatch, err)
+		}
+		var e ExampleDTO
+		err := json.Unmarshal(raw, &e)
+		if err := add(e, err); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedBatch, err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("%w: data after the JSON array", ErrMalformedBatch)
+	}
+	return nil
+}
+
+// maxNDJSONLine bounds one NDJSON line.
+const maxNDJSONLine = 1 << 20
+
+func decodeNDJSON(r io.Reader, add func(ExampleDTO, error) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), maxNDJSONLine)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e ExampleDTO
+		err := json.Unmarshal(line, &e)
+		if err := add(e, err); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedBatch, err)
+	}
+	return nil
+}
+
+// csvColumns are the columns a CSV upload may have; all but date are required.
+var csvColumns = map[string]bool{"user_id": true, "amount": true, "currency": true, "date": false}
+
+func decodeCSV(r io.Reader, add func(ExampleDTO, error) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // checked per row
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedBatch, err)
+	}
+
+	width, col := len(header), map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := csvColumns[name]; !ok {
+			return fmt.Errorf("%w: unknown column %q", ErrMalformedBatch, name)
+		}
+		if _, dup := col[name]; dup {
+			return fmt.Errorf("%w: duplicate column %q", ErrMalformedBatch, name)
+		}
+		col[name] = i
+	}
+	for name, required := range csvColumns {
+		if _, ok := col[name]; required && !ok {
+			return fmt.Errorf("%w: missing column %q", ErrMalformedBatch, name)
+		}
+	}
+
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrMalformedBatch, err)
+		}
+		if err := add(exampleFromCSV(rec, width, col)); err != nil {
+			return err
+		}
+	}
+}
+
+func exampleFromCSV(rec []string, width int, col map[string]int) (ExampleDTO, error) {
+	var e ExampleDTO
+	if len(rec) != width {
+		return e, fmt.Errorf("expected %d fields, got %d", width, len(rec))
+	}
+	field := func(name string) string {
+		if i, ok := col[name]; ok {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	e.UserID = field("user_id")
+	amount, err := entities.ParseMoney(field("amount"), strings.ToUpper(field("currency")))
+	if err != nil {
+		return e, err
+	}
+	e.Amount = amount
+	if d := field("date"); d != "" {
+		if e.Date, err = time.Parse(time.RFC3339, d); err != nil {
+			return e, fmt.Errorf("date: %w", err)
+		}
+	}
+	return e, nil
+}
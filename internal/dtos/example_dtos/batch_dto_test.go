@@ -0,0 +1,83 @@
+package exampledtos
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go-boilerplate/internal/entities"
+)
+
+func TestDecodeBatch_Formats(t *testing.T) {
+	want := []ExampleDTO{
+		{UserID: "u1", Amount: entities.Money{Minor: 1234, Currency: "USD"}},
+		{UserID: "u2", Amount: entities.Money{Minor: 5, Currency: "JPY"}},
+	}
+	for name, tc := range map[string]struct{ contentType, body string }{
+		"json": {"application/json; charset=utf-8", `[
+			{"user_id":"u1","amount":{"amount":"12.34","currency":"USD"}},
+			{"user_id":"u2","amount":{"amount":"5","currency":"JPY"}}
+		]`},
+		"ndjson": {"application/x-ndjson", "{\"user_id\":\"u1\",\"amount\":{\"amount\":\"12.34\",\"currency\":\"USD\"}}\n\n" +
+			"{\"user_id\":\"u2\",\"amount\":{\"amount\":\"5\",\"currency\":\"JPY\"}}\n"},
+		"csv": {"text/csv", "\ufeffUser_ID,amount,currency\nu1,12.34,usd\r\nu2,5,JPY\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rows, err := DecodeBatch(strings.NewReader(tc.body), tc.contentType, 0)
+			require.NoError(t, err)
+			require.Len(t, rows, 2)
+			for i, r := range rows {
+				require.NoError(t, r.Err)
+				require.Equal(t, i+1, r.Row)
+				require.Equal(t, want[i], r.Example)
+			}
+		})
+	}
+}
+
+func TestDecodeBatch_RowErrorsDoNotStopTheUpload(t *testing.T) {
+	for name, tc := range map[string]struct{ contentType, body string }{
+		"json":   {"application/json", `[{"user_id":"u1","amount":{"amount":"1.234","currency":"USD"}},{"user_id":"u2","amount":{"amount":"1","currency":"USD"}}]`},
+		"ndjson": {"application/x-ndjson", "{\"user_id\":\"u1\",\"amount\":12}\n{\"user_id\":\"u2\",\"amount\":{\"amount\":\"1\",\"currency\":\"USD\"}}"},
+		"csv":    {"text/csv", "user_id,amount,currency,date\nu1,abc,USD,\nu2,1,USD,2026-10-18T09:00:00Z\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rows, err := DecodeBatch(strings.NewReader(tc.body), tc.contentType, 0)
+			require.NoError(t, err)
+			require.Len(t, rows, 2)
+			require.Error(t, rows[0].Err)
+			require.NoError(t, rows[1].Err)
+			require.Equal(t, "u2", rows[1].Example.UserID)
+		})
+	}
+}
+
+func TestDecodeBatch_Errors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contentType, body string
+		want              error
+	}{
+		"content type":   {"application/xml", `<rows/>`, ErrUnsupportedBatchFormat},
+		"not an array":   {"application/json", `{"user_id":"u1"}`, ErrMalformedBatch},
+		"broken json":    {"application/json", `[{"user_id":"u1"},{"user_id":`, ErrMalformedBatch},
+		"trailing data":  {"application/json", `[] []`, ErrMalformedBatch},
+		"unknown column": {"text/csv", "user_id,amount,currency,note\n", ErrMalformedBatch},
+		"missing column": {"text/csv", "user_id,amount\n", ErrMalformedBatch},
+		"broken quoting": {"text/csv", "user_id,amount,currency\n\"u1,1,USD\n", ErrMalformedBatch},
+		"empty":          {"application/x-ndjson", "\n\n", ErrEmptyBatch},
+		"too many rows":  {"application/x-ndjson", "{}\n{}\n{}\n", ErrTooManyRows},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodeBatch(strings.NewReader(tc.body), tc.contentType, 2)
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+}
+
+func TestDecodeBatch_CSVFieldCountIsPerRow(t *testing.T) {
+	rows, err := DecodeBatch(strings.NewReader("user_id,amount,currency\nu1,1\nu2,2,USD\n"), "text/csv", 0)
+	require.NoError(t, err)
+	require.EqualError(t, rows[0].Err, "expected 3 fields, got 2")
+	require.NoError(t, rows[1].Err)
+}
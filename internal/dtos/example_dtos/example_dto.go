@@ -1,11 +1,15 @@
 package exampledtos
 
-import "time"
+import (
+	"go-boilerplate/internal/entities"
+	"time"
+)
 
 // ExampleDTO represents the data transfer object for an example entity.
+// Amount is sent as {"amount":"12.34","currency":"USD"}.
 type ExampleDTO struct {
-	ID     string    `json:"id"`
-	UserID string    `json:"user_id"`
-	Amount int64     `json:"amount"`
-	Date   time.Time `json:"date"`
+	ID     string         `json:"id"`
+	UserID string         `json:"user_id"`
+	Amount entities.Money `json:"amount" validate:"money_positive"`
+	Date   time.Time      `json:"date"`
 }
@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+// APIKeyEntity represents an API key issued to a machine client.
+// Only the SHA-256 hash of the secret is stored; Prefix is the public, non-secret part
+// of the key used to look it up.
+type APIKeyEntity struct {
+	ID                 int64      `json:"id"`
+	Name               string     `json:"name"`
+	Prefix             string     `json:"prefix"`
+	Hash               string     `json:"-"`
+	Scopes             []string   `json:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// Active reports whether the key is neither revoked nor expired at now.
+func (k *APIKeyEntity) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
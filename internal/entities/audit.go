@@ -0,0 +1,17 @@
+package entities
+
+import "time"
+
+// Audit holds the bookkeeping columns that repositories.Base maintains for an entity that
+// embeds it: timestamps and actors of the first and last write, the soft delete marker
+// and the version used for optimistic locking.
+type Audit struct {
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedBy string     `json:"created_by" db:"created_by"`
+	UpdatedBy string     `json:"updated_by" db:"updated_by"`
+	// Version starts at 1 and is incremented by every update. An update only applies if
+	// the row still has the version that was read.
+	Version int64 `json:"version" db:"version"`
+}
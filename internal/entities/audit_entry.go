@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit trail actions.
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	// AuditActionDelete covers soft and permanent deletes; After is the soft-deleted row,
+	// or null when the row was removed.
+	AuditActionDelete = "delete"
+)
+
+// AuditEntry records one change made to an entity through a repository: who made it, in
+// which request and over which transport, and the entity before and after the change as
+// JSON (null when it did not exist).
+type AuditEntry struct {
+	ID        int64           `json:"id" db:"id,pk"`
+	Entity    string          `json:"entity" db:"entity"`
+	EntityID  string          `json:"entity_id" db:"entity_id"`
+	Action    string          `json:"action" db:"action"`
+	Actor     string          `json:"actor" db:"actor"`
+	RequestID string          `json:"request_id,omitempty" db:"request_id"`
+	Transport string          `json:"transport,omitempty" db:"transport"`
+	Before    json.RawMessage `json:"before" db:"before_state"`
+	After     json.RawMessage `json:"after" db:"after_state"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// TableName returns the table audit entries are stored in.
+func (AuditEntry) TableName() string { return "audit_log" }
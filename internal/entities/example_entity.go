@@ -3,9 +3,14 @@ package entities
 import "time"
 
 // ExampleEntity represents an example entity with user, amount, and date fields.
+// It is stored in the users table; Date is not persisted.
 type ExampleEntity struct {
-	ID     string    `json:"id"`
-	UserID string    `json:"user_id"`
-	Amount int64     `json:"amount"`
-	Date   time.Time `json:"date"`
+	ID     string    `json:"id" db:"id,pk"`
+	UserID string    `json:"user_id" db:"user_id"`
+	Amount Money     `json:"amount" db:",inline"`
+	Date   time.Time `json:"date" db:"-"`
+	Audit
 }
+
+// TableName returns the table ExampleEntity rows are stored in.
+func (ExampleEntity) TableName() string { return "users" }
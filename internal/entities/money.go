@@ -0,0 +1,248 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money errors.
+var (
+	ErrUnknownCurrency  = errors.New("money: unknown currency")
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+	ErrMoneyOverflow    = errors.New("money: amount out of range")
+	// ErrMoneyPrecision is returned when an amount has more decimals than its currency.
+	ErrMoneyPrecision = errors.New("money: too many decimal places for currency")
+	ErrMoneySyntax    = errors.New("money: invalid amount")
+)
+
+// currencyExponents holds the ISO 4217 minor unit exponent of the supported currencies.
+var currencyExponents = map[string]int{
+	"AED": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2,
+	"INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "MYR": 2,
+	"NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2, "SAR": 2, "SEK": 2, "SGD": 2,
+	"THB": 2, "TND": 3, "TRY": 2, "TWD": 2, "UGX": 0, "USD": 2, "VND": 0, "XAF": 0,
+	"XOF": 0, "ZAR": 2,
+}
+
+// CurrencyExponent returns the number of decimal places of currency's minor unit,
+// e.g. 2 for USD and 0 for JPY, and whether the currency is supported.
+func CurrencyExponent(currency string) (int, bool) {
+	e, ok := currencyExponents[currency]
+	return e, ok
+}
+
+// Money is an amount in the minor unit of an ISO 4217 currency, e.g. {1234, "USD"} is
+// 12.34 USD. Arithmetic never rounds silently and reports overflow instead of wrapping.
+//
+// It is stored in the amount_minor and currency columns (map it with `db:",inline"`) and
+// encoded in JSON as {"amount":"12.34","currency":"USD"}, the amount being a decimal
+// string so that no precision is lost to floating point.
+type Money struct {
+	Minor    int64  `db:"amount_minor"`
+	Currency string `db:"currency"`
+}
+
+// NewMoney returns minor units of currency.
+func NewMoney(minor int64, currency string) (Money, error) {
+	if _, ok := CurrencyExponent(currency); !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// ParseMoney parses a decimal amount such as "12.34" or "-5" in currency. Amounts with
+// more decimals than the currency has are rejected rather than rounded.
+func ParseMoney(amount, currency string) (Money, error) {
+	exp, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	s, neg := amount, false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		s, neg = s[1:], s[0] == '-'
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || !digits(whole) || !digits(frac) || (strings.Contains(s, ".") && frac == "") {
+		return Money{}, fmt.Errorf("%w %q", ErrMoneySyntax, amount)
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("%w: %q in %s", ErrMoneyPrecision, amount, currency)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	n, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyOverflow, amount)
+	}
+	if neg {
+		n = -n
+	}
+	return Money{Minor: n, Currency: currency}, nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal formats the amount in major units with the currency's decimals, e.g. "12.34".
+func (m Money) Decimal() string {
+	exp := currencyExponents[m.Currency]
+	s := strconv.FormatUint(abs(m.Minor), 10)
+	if exp > 0 {
+		if len(s) <= exp {
+			s = strings.Repeat("0", exp-len(s)+1) + s
+		}
+		s = s[:len(s)-exp] + "." + s[len(s)-exp:]
+	}
+	if m.Minor < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// String formats m as "12.34 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool { return m.Minor == 0 }
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+// Add returns m + o. Both must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	if (o.Minor > 0 && m.Minor > math.MaxInt64-o.Minor) || (o.Minor < 0 && m.Minor < math.MinInt64-o.Minor) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrMoneyOverflow, m, o)
+	}
+	return Money{Minor: m.Minor + o.Minor, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o. Both must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Minor == math.MinInt64 {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrMoneyOverflow, m, o)
+	}
+	return m.Add(Money{Minor: -o.Minor, Currency: o.Currency})
+}
+
+// Mul returns m multiplied by n.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Minor == 0 || n == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+	p := m.Minor * n
+	if (p < 0) != ((m.Minor < 0) != (n < 0)) || p/n != m.Minor {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrMoneyOverflow, m, n)
+	}
+	return Money{Minor: p, Currency: m.Currency}, nil
+}
+
+// Allocate splits m into parts proportional to ratios without losing a minor unit: each
+// part gets its share rounded toward zero and the remainder is handed out one unit at a
+// time from the first part, so the parts always add up to m.
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("money: allocate needs at least one ratio")
+	}
+	sum := new(big.Int)
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, fmt.Errorf("money: negative ratio %d", r)
+		}
+		sum.Add(sum, big.NewInt(r))
+	}
+	if sum.Sign() == 0 {
+		return nil, errors.New("money: ratios add up to zero")
+	}
+
+	total := big.NewInt(m.Minor)
+	parts := make([]Money, len(ratios))
+	left := m.Minor
+	for i, r := range ratios {
+		share := new(big.Int).Mul(total, big.NewInt(r))
+		share.Quo(share, sum) // |share| <= |m.Minor|, so it fits
+		parts[i] = Money{Minor: share.Int64(), Currency: m.Currency}
+		left -= parts[i].Minor
+	}
+	unit := int64(1)
+	if left < 0 {
+		unit = -1
+	}
+	for i := 0; left != 0; i = (i + 1) % len(parts) {
+		if ratios[i] == 0 {
+			continue
+		}
+		parts[i].Minor += unit
+		left -= unit
+	}
+	return parts, nil
+}
+
+// Split divides m into n parts that differ by at most one minor unit.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("money: cannot split into %d parts", n)
+	}
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// MarshalJSON encodes m as {"amount":"12.34","currency":"USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, _ := json.Marshal(m.Decimal())
+	return json.Marshal(moneyJSON{Amount: amount, Currency: m.Currency})
+}
+
+// UnmarshalJSON decodes {"amount":"12.34","currency":"USD"}. The amount must be a string;
+// a JSON number is rejected so that it never passes through a float.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var in moneyJSON
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	var amount string
+	if err := json.Unmarshal(in.Amount, &amount); err != nil {
+		return fmt.Errorf("%w: amount must be a decimal string, got %s", ErrMoneySyntax, in.Amount)
+	}
+	v, err := ParseMoney(amount, in.Currency)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
@@ -0,0 +1,123 @@
+package entities
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		amount, currency string
+		want             int64
+	}{
+		{"12.34", "USD", 1234},
+		{"12.3", "USD", 1230},
+		{"12", "USD", 1200},
+		{"-0.05", "EUR", -5},
+		{"+1.50", "EUR", 150},
+		{"1500", "JPY", 1500},
+		{"1.000", "JPY", 1},
+		{"1.234", "KWD", 1234},
+	}
+	for _, tc := range cases {
+		m, err := ParseMoney(tc.amount, tc.currency)
+		require.NoError(t, err, tc.amount)
+		require.Equal(t, Money{Minor: tc.want, Currency: tc.currency}, m, tc.amount)
+	}
+
+	for _, bad := range []struct{ amount, currency string }{
+		{"1.234", "USD"}, {"1.5", "JPY"}, {"", "USD"}, {"1.", "USD"}, {".5", "USD"},
+		{"1,5", "USD"}, {"1e3", "USD"}, {"--1", "USD"}, {"1", "XXX"}, {"99999999999999999999", "USD"},
+	} {
+		_, err := ParseMoney(bad.amount, bad.currency)
+		require.Error(t, err, bad.amount)
+	}
+	_, err := ParseMoney("1.234", "USD")
+	require.ErrorIs(t, err, ErrMoneyPrecision)
+	_, err = ParseMoney("1", "XXX")
+	require.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestMoney_Decimal(t *testing.T) {
+	require.Equal(t, "12.34", Money{1234, "USD"}.Decimal())
+	require.Equal(t, "0.05", Money{5, "USD"}.Decimal())
+	require.Equal(t, "-0.05", Money{-5, "USD"}.Decimal())
+	require.Equal(t, "1500", Money{1500, "JPY"}.Decimal())
+	require.Equal(t, "0.001", Money{1, "BHD"}.Decimal())
+	require.Equal(t, "-92233720368547758.08", Money{math.MinInt64, "USD"}.Decimal())
+	require.Equal(t, "12.34 USD", Money{1234, "USD"}.String())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a, b := Money{150, "USD"}, Money{275, "USD"}
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	require.Equal(t, Money{425, "USD"}, sum)
+	diff, err := a.Sub(b)
+	require.NoError(t, err)
+	require.Equal(t, Money{-125, "USD"}, diff)
+	prod, err := a.Mul(-3)
+	require.NoError(t, err)
+	require.Equal(t, Money{-450, "USD"}, prod)
+
+	_, err = a.Add(Money{1, "EUR"})
+	require.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = Money{math.MaxInt64, "USD"}.Add(Money{1, "USD"})
+	require.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = Money{math.MinInt64, "USD"}.Sub(Money{1, "USD"})
+	require.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = Money{0, "USD"}.Sub(Money{math.MinInt64, "USD"})
+	require.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = Money{math.MaxInt64 / 2, "USD"}.Mul(3)
+	require.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = Money{math.MinInt64, "USD"}.Mul(-1)
+	require.ErrorIs(t, err, ErrMoneyOverflow)
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	parts, err := Money{100, "USD"}.Split(3)
+	require.NoError(t, err)
+	require.Equal(t, []Money{{34, "USD"}, {33, "USD"}, {33, "USD"}}, parts)
+
+	parts, err = Money{-100, "USD"}.Split(3)
+	require.NoError(t, err)
+	require.Equal(t, []Money{{-34, "USD"}, {-33, "USD"}, {-33, "USD"}}, parts)
+
+	parts, err = Money{5, "USD"}.Allocate(70, 0, 30)
+	require.NoError(t, err)
+	require.Equal(t, []Money{{4, "USD"}, {0, "USD"}, {1, "USD"}}, parts)
+
+	// Shares are computed without overflowing.
+	parts, err = Money{math.MaxInt64, "USD"}.Allocate(math.MaxInt64, 1)
+	require.NoError(t, err)
+	total, err := parts[0].Add(parts[1])
+	require.NoError(t, err)
+	require.Equal(t, Money{math.MaxInt64, "USD"}, total)
+
+	_, err = Money{1, "USD"}.Allocate()
+	require.Error(t, err)
+	_, err = Money{1, "USD"}.Allocate(0, 0)
+	require.Error(t, err)
+	_, err = Money{1, "USD"}.Allocate(1, -1)
+	require.Error(t, err)
+	_, err = Money{1, "USD"}.Split(0)
+	require.Error(t, err)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	b, err := json.Marshal(Money{1234, "USD"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"12.34","currency":"USD"}`, string(b))
+
+	var m Money
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"0.5","currency":"EUR"}`), &m))
+	require.Equal(t, Money{50, "EUR"}, m)
+
+	require.Error(t, json.Unmarshal([]byte(`{"amount":12.34,"currency":"USD"}`), &m), "numbers are rejected")
+	require.ErrorIs(t, json.Unmarshal([]byte(`{"amount":"1.234","currency":"USD"}`), &m), ErrMoneyPrecision)
+	require.ErrorIs(t, json.Unmarshal([]byte(`{"amount":"1","currency":"usd"}`), &m), ErrUnknownCurrency)
+}
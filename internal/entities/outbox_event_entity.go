@@ -0,0 +1,28 @@
+package entities
+
+import "time"
+
+// Outbox event statuses.
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	// OutboxStatusFailed marks events that exhausted their delivery attempts.
+	OutboxStatusFailed = "failed"
+)
+
+// OutboxEvent is a domain event stored in the outbox table in the same transaction as the
+// change that produced it, and published to the message broker by the outbox relay.
+type OutboxEvent struct {
+	ID            int64
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	RoutingKey    string
+	Payload       []byte
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
@@ -0,0 +1,56 @@
+package _mock
+
+import (
+	"context"
+	"go-boilerplate/internal/entities"
+	"time"
+)
+
+// MockAPIKeyRepository is a mock implementation of APIKeyRepository for testing purposes.
+type MockAPIKeyRepository struct {
+	GetByPrefixFunc   func(ctx context.Context, prefix string) (*entities.APIKeyEntity, error)
+	ListFunc          func(ctx context.Context) ([]entities.APIKeyEntity, error)
+	CreateFunc        func(ctx context.Context, k *entities.APIKeyEntity) (int64, error)
+	RevokeFunc        func(ctx context.Context, id int64, at time.Time) error
+	TouchLastUsedFunc func(ctx context.Context, id int64, at time.Time) error
+}
+
+// GetByPrefix calls the mocked GetByPrefixFunc.
+func (m *MockAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*entities.APIKeyEntity, error) {
+	if m.GetByPrefixFunc != nil {
+		return m.GetByPrefixFunc(ctx, prefix)
+	}
+	return nil, nil
+}
+
+// List calls the mocked ListFunc.
+func (m *MockAPIKeyRepository) List(ctx context.Context) ([]entities.APIKeyEntity, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx)
+	}
+	return nil, nil
+}
+
+// Create calls the mocked CreateFunc.
+func (m *MockAPIKeyRepository) Create(ctx context.Context, k *entities.APIKeyEntity) (int64, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, k)
+	}
+	return 0, nil
+}
+
+// Revoke calls the mocked RevokeFunc.
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	if m.RevokeFunc != nil {
+		return m.RevokeFunc(ctx, id, at)
+	}
+	return nil
+}
+
+// TouchLastUsed calls the mocked TouchLastUsedFunc.
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	if m.TouchLastUsedFunc != nil {
+		return m.TouchLastUsedFunc(ctx, id, at)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package _mock
+
+import (
+	"context"
+	"database/sql"
+	"go-boilerplate/internal/entities"
+)
+
+// MockAuditRepository is a mock implementation of AuditRepository for testing purposes.
+type MockAuditRepository struct {
+	AppendFunc     func(ctx context.Context, tx *sql.Tx, e *entities.AuditEntry) error
+	AppendManyFunc func(ctx context.Context, tx *sql.Tx, es []*entities.AuditEntry) error
+	ListFunc       func(ctx context.Context, entity, entityID string, limit, offset int) ([]entities.AuditEntry, int64, error)
+}
+
+// Append calls the mocked AppendFunc.
+func (m *MockAuditRepository) Append(ctx context.Context, tx *sql.Tx, e *entities.AuditEntry) error {
+	if m.AppendFunc != nil {
+		return m.AppendFunc(ctx, tx, e)
+	}
+	return nil
+}
+
+// AppendMany calls the mocked AppendManyFunc.
+func (m *MockAuditRepository) AppendMany(ctx context.Context, tx *sql.Tx, es []*entities.AuditEntry) error {
+	if m.AppendManyFunc != nil {
+		return m.AppendManyFunc(ctx, tx, es)
+	}
+	return nil
+}
+
+// List calls the mocked ListFunc.
+func (m *MockAuditRepository) List(ctx context.Context, entity, entityID string, limit, offset int) ([]entities.AuditEntry, int64, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, entity, entityID, limit, offset)
+	}
+	return nil, 0, nil
+}
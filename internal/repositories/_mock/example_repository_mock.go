@@ -7,8 +7,11 @@ import (
 
 // MockExampleRepository is a mock implementation of ExampleRepository for testing purposes.
 type MockExampleRepository struct {
-    GetByIDFunc func(ctx context.Context, id int64) (*entities.ExampleEntity, error)
-    CreateFunc  func(ctx context.Context, u *entities.ExampleEntity) (int64, error)
+    GetByIDFunc     func(ctx context.Context, id int64) (*entities.ExampleEntity, error)
+    CreateFunc      func(ctx context.Context, u *entities.ExampleEntity) (int64, error)
+    UpdateFunc      func(ctx context.Context, u *entities.ExampleEntity) error
+    DeleteFunc      func(ctx context.Context, id int64) error
+    CreateBatchFunc func(ctx context.Context, es []*entities.ExampleEntity, chunkSize int) ([]int64, error)
 }
 
 // GetByID calls the mocked GetByIDFunc.
@@ -26,3 +29,26 @@ func (m *MockExampleRepository) Create(ctx context.Context, u *entities.ExampleE
     }
     return 0, nil
 }
+
+// Update calls the mocked UpdateFunc.
+func (m *MockExampleRepository) Update(ctx context.Context, u *entities.ExampleEntity) error {
+    if m.UpdateFunc != nil {
+        return m.UpdateFunc(ctx, u)
+    }
+    return nil
+}
+
+// Delete calls the mocked DeleteFunc.
+func (m *MockExampleRepository) Delete(ctx context.Context, id int64) error {
+    if m.DeleteFunc != nil {
+        return m.DeleteFunc(ctx, id)
+    }
+    return nil
+}
+// CreateBatch calls the mocked CreateBatchFunc.
+func (m *MockExampleRepository) CreateBatch(ctx context.Context, es []*entities.ExampleEntity, chunkSize int) ([]int64, error) {
+    if m.CreateBatchFunc != nil {
+        return m.CreateBatchFunc(ctx, es, chunkSize)
+    }
+    return nil, nil
+}
@@ -0,0 +1,48 @@
+package _mock
+
+import (
+	"context"
+	"database/sql"
+	"go-boilerplate/internal/entities"
+	"time"
+)
+
+// MockOutboxRepository is a mock implementation of OutboxRepository for testing purposes.
+type MockOutboxRepository struct {
+	EnqueueFunc      func(ctx context.Context, tx *sql.Tx, e *entities.OutboxEvent) error
+	EnqueueManyFunc  func(ctx context.Context, tx *sql.Tx, es []*entities.OutboxEvent) error
+	ProcessBatchFunc func(ctx context.Context, now time.Time, limit int, fn func(events []entities.OutboxEvent)) (int, error)
+	PruneFunc        func(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Enqueue calls the mocked EnqueueFunc.
+func (m *MockOutboxRepository) Enqueue(ctx context.Context, tx *sql.Tx, e *entities.OutboxEvent) error {
+	if m.EnqueueFunc != nil {
+		return m.EnqueueFunc(ctx, tx, e)
+	}
+	return nil
+}
+
+// EnqueueMany calls the mocked EnqueueManyFunc.
+func (m *MockOutboxRepository) EnqueueMany(ctx context.Context, tx *sql.Tx, es []*entities.OutboxEvent) error {
+	if m.EnqueueManyFunc != nil {
+		return m.EnqueueManyFunc(ctx, tx, es)
+	}
+	return nil
+}
+
+// ProcessBatch calls the mocked ProcessBatchFunc.
+func (m *MockOutboxRepository) ProcessBatch(ctx context.Context, now time.Time, limit int, fn func(events []entities.OutboxEvent)) (int, error) {
+	if m.ProcessBatchFunc != nil {
+		return m.ProcessBatchFunc(ctx, now, limit, fn)
+	}
+	return 0, nil
+}
+
+// Prune calls the mocked PruneFunc.
+func (m *MockOutboxRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneFunc != nil {
+		return m.PruneFunc(ctx, before)
+	}
+	return 0, nil
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"go-boilerplate/internal/entities"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// apiKeyFileEntry is one key in an API key file:
+//
+//	keys:
+//	  - name: billing-sync
+//	    prefix: 3f9a1c2b
+//	    hash: <sha256 hex of the full key>
+//	    scopes: ["example:read"]
+//	    rate_limit_per_minute: 600
+//	    expires_at: 2027-01-01T00:00:00Z
+type apiKeyFileEntry struct {
+	Name               string     `yaml:"name"`
+	Prefix             string     `yaml:"prefix"`
+	Hash               string     `yaml:"hash"`
+	Scopes             []string   `yaml:"scopes"`
+	RateLimitPerMinute int        `yaml:"rate_limit_per_minute"`
+	ExpiresAt          *time.Time `yaml:"expires_at"`
+}
+
+type apiKeyFileRepository struct {
+	mu   sync.RWMutex
+	keys []entities.APIKeyEntity
+}
+
+// NewFileAPIKeyRepository loads hashed API keys from a YAML file.
+// The file is the source of truth, so Create and Revoke return ErrReadOnly;
+// last-used timestamps are tracked in memory only.
+func NewFileAPIKeyRepository(path string) (APIKeyRepository, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read api key file %s: %w", path, err)
+	}
+	var doc struct {
+		Keys []apiKeyFileEntry `yaml:"keys"`
+	}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse api key file %s: %w", path, err)
+	}
+
+	r := &apiKeyFileRepository{}
+	for i, e := range doc.Keys {
+		if e.Name == "" || e.Prefix == "" || e.Hash == "" {
+			return nil, fmt.Errorf("api key file %s: entry #%d needs name, prefix and hash", path, i)
+		}
+		r.keys = append(r.keys, entities.APIKeyEntity{
+			ID:                 int64(i + 1),
+			Name:               e.Name,
+			Prefix:             e.Prefix,
+			Hash:               e.Hash,
+			Scopes:             e.Scopes,
+			RateLimitPerMinute: e.RateLimitPerMinute,
+			ExpiresAt:          e.ExpiresAt,
+		})
+	}
+	return r, nil
+}
+
+func (r *apiKeyFileRepository) GetByPrefix(ctx context.Context, prefix string) (*entities.APIKeyEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			return &k, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *apiKeyFileRepository) List(ctx context.Context) ([]entities.APIKeyEntity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]entities.APIKeyEntity(nil), r.keys...), nil
+}
+
+func (r *apiKeyFileRepository) Create(ctx context.Context, k *entities.APIKeyEntity) (int64, error) {
+	return 0, ErrReadOnly
+}
+
+func (r *apiKeyFileRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	return ErrReadOnly
+}
+
+func (r *apiKeyFileRepository) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.keys {
+		if r.keys[i].ID == id {
+			t := at
+			r.keys[i].LastUsedAt = &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-boilerplate/internal/entities"
+	"strings"
+	"time"
+)
+
+// ErrReadOnly is returned by repositories that cannot be written to, such as the
+// file-backed API key repository.
+var ErrReadOnly = errors.New("repository is read-only")
+
+// APIKeyRepository defines the interface for storing hashed API keys.
+// Keys are looked up by their public prefix; the secret itself is never stored.
+type APIKeyRepository interface {
+	GetByPrefix(ctx context.Context, prefix string) (*entities.APIKeyEntity, error)
+	List(ctx context.Context) ([]entities.APIKeyEntity, error)
+	Create(ctx context.Context, k *entities.APIKeyEntity) (int64, error)
+	// Revoke returns ErrNotFound when there is no unrevoked key with id.
+	Revoke(ctx context.Context, id int64, at time.Time) error
+	TouchLastUsed(ctx context.Context, id int64, at time.Time) error
+}
+
+// apiKeyRepository stores keys in the api_keys table:
+//
+//	CREATE TABLE api_keys (
+//	  id                    BIGINT AUTO_INCREMENT PRIMARY KEY,
+//	  name                  VARCHAR(100) NOT NULL,
+//	  prefix                VARCHAR(32)  NOT NULL UNIQUE,
+//	  hash                  CHAR(64)     NOT NULL,
+//	  scopes                TEXT         NOT NULL,
+//	  rate_limit_per_minute INT          NOT NULL DEFAULT 0,
+//	  expires_at            DATETIME     NULL,
+//	  revoked_at            DATETIME     NULL,
+//	  last_used_at          DATETIME     NULL,
+//	  created_at            DATETIME     NOT NULL
+//	);
+type apiKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository backed by the api_keys table.
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, name, prefix, hash, scopes, rate_limit_per_minute, expires_at, revoked_at, last_used_at, created_at`
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*entities.APIKeyEntity, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = ?`, prefix)
+	k, err := scanAPIKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) ([]entities.APIKeyEntity, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []entities.APIKeyEntity
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *k)
+	}
+	return out, rows.Err()
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, k *entities.APIKeyEntity) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO api_keys (name, prefix, hash, scopes, rate_limit_per_minute, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		k.Name, k.Prefix, k.Hash, strings.Join(k.Scopes, ","), k.RateLimitPerMinute, k.ExpiresAt, k.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int64, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, at, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, at, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(s rowScanner) (*entities.APIKeyEntity, error) {
+	var (
+		k                              entities.APIKeyEntity
+		scopes                         string
+		expiresAt, revokedAt, lastUsed sql.NullTime
+	)
+	if err := s.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &scopes, &k.RateLimitPerMinute,
+		&expiresAt, &revokedAt, &lastUsed, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	if scopes != "" {
+		k.Scopes = strings.Split(scopes, ",")
+	}
+	k.ExpiresAt = nullTimePtr(expiresAt)
+	k.RevokedAt = nullTimePtr(revokedAt)
+	k.LastUsedAt = nullTimePtr(lastUsed)
+	return &k, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
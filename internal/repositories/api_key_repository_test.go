@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"go-boilerplate/internal/entities"
+)
+
+const apiKeySelect = `SELECT id, name, prefix, hash, scopes, rate_limit_per_minute, expires_at, revoked_at, last_used_at, created_at FROM api_keys`
+
+var apiKeyRowColumns = []string{"id", "name", "prefix", "hash", "scopes", "rate_limit_per_minute", "expires_at", "revoked_at", "last_used_at", "created_at"}
+
+func newAPIKeyMock(t *testing.T) (APIKeyRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewAPIKeyRepository(db), mock
+}
+
+func TestAPIKeyRepository_GetByPrefix(t *testing.T) {
+	repo, mock := newAPIKeyMock(t)
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(apiKeySelect + ` WHERE prefix = ?`).WithArgs("3f9a1c2b4d5e").
+		WillReturnRows(sqlmock.NewRows(apiKeyRowColumns).
+			AddRow(7, "billing-sync", "3f9a1c2b4d5e", "hash", "example:read,example:write", 60, at, nil, nil, at))
+	mock.ExpectQuery(apiKeySelect + ` WHERE prefix = ?`).WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows(apiKeyRowColumns))
+
+	k, err := repo.GetByPrefix(context.Background(), "3f9a1c2b4d5e")
+	require.NoError(t, err)
+	require.Equal(t, &entities.APIKeyEntity{
+		ID:                 7,
+		Name:               "billing-sync",
+		Prefix:             "3f9a1c2b4d5e",
+		Hash:               "hash",
+		Scopes:             []string{"example:read", "example:write"},
+		RateLimitPerMinute: 60,
+		ExpiresAt:          &at,
+		CreatedAt:          at,
+	}, k)
+
+	k, err = repo.GetByPrefix(context.Background(), "unknown")
+	require.NoError(t, err)
+	require.Nil(t, k)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepository_List(t *testing.T) {
+	repo, mock := newAPIKeyMock(t)
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(apiKeySelect + ` ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows(apiKeyRowColumns).
+			AddRow(1, "a", "p1", "h1", "", 0, nil, at, at, at).
+			AddRow(2, "b", "p2", "h2", "example:read", 0, nil, nil, nil, at))
+
+	keys, err := repo.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Nil(t, keys[0].Scopes)
+	require.Equal(t, &at, keys[0].RevokedAt)
+	require.Equal(t, &at, keys[0].LastUsedAt)
+	require.Equal(t, []string{"example:read"}, keys[1].Scopes)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepository_Create(t *testing.T) {
+	repo, mock := newAPIKeyMock(t)
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`INSERT INTO api_keys (name, prefix, hash, scopes, rate_limit_per_minute, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`).
+		WithArgs("billing-sync", "3f9a1c2b4d5e", "hash", "example:read,example:write", 60, nil, at).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	id, err := repo.Create(context.Background(), &entities.APIKeyEntity{
+		Name:               "billing-sync",
+		Prefix:             "3f9a1c2b4d5e",
+		Hash:               "hash",
+		Scopes:             []string{"example:read", "example:write"},
+		RateLimitPerMinute: 60,
+		CreatedAt:          at,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), id)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepository_Revoke(t *testing.T) {
+	repo, mock := newAPIKeyMock(t)
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	const revoke = `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+
+	mock.ExpectExec(revoke).WithArgs(at, int64(7)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(revoke).WithArgs(at, int64(7)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.Revoke(context.Background(), 7, at))
+	require.ErrorIs(t, repo.Revoke(context.Background(), 7, at), ErrNotFound, "already revoked")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/utils/correlation"
+)
+
+const auditInsert = `INSERT INTO audit_log (entity, entity_id, action, actor, request_id, transport, before_state, after_state, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+func TestAuditRepository_List(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewAuditRepository(db, MySQL)
+
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT COUNT(*) FROM audit_log WHERE entity = ? AND entity_id = ?`).WithArgs("example", "42").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT id, entity, entity_id, action, actor, request_id, transport, before_state, after_state, created_at FROM audit_log WHERE entity = ? AND entity_id = ? ORDER BY id DESC LIMIT 2 OFFSET 1`).
+		WithArgs("example", "42").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity", "entity_id", "action", "actor", "request_id", "transport", "before_state", "after_state", "created_at"}).
+			AddRow(2, "example", "42", "update", "alice", "req-1", "http", []byte(`{"amount":1}`), []byte(`{"amount":2}`), at).
+			AddRow(1, "example", "42", "create", "alice", "req-0", "http", []byte(`null`), []byte(`{"amount":1}`), at))
+
+	entries, total, err := repo.List(context.Background(), "example", "42", 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), total)
+	require.Len(t, entries, 2)
+	require.Equal(t, "update", entries[0].Action)
+	require.JSONEq(t, `{"amount":1}`, string(entries[0].Before))
+	require.JSONEq(t, `null`, string(entries[1].Before))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBase_WithAudit_RecordsChangesInTheSameTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	b := NewBase[ledger](db, MySQL).WithAudit(NewAuditRepository(db, MySQL), "ledger")
+	b.now = func() time.Time { return ledgerNow }
+
+	ctx := correlation.WithIDs(asAlice(), correlation.IDs{RequestID: "req-7", Transport: correlation.TransportHTTP})
+	row := func(total, version int64) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "total", "created_at", "updated_at", "deleted_at", "created_by", "updated_by", "version"}).
+			AddRow(1, total, ledgerNow, ledgerNow, nil, "alice", "alice", version)
+	}
+	var entry struct{ before, after []byte }
+	captureJSON := func(dst *[]byte) sqlmock.Argument { return jsonArg{dst} }
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT ` + ledgerColumns + ` FROM ledgers WHERE id = ?`).WithArgs(int64(1)).WillReturnRows(row(10, 1))
+	mock.ExpectExec(`UPDATE ledgers SET total = ?, updated_at = ?, updated_by = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`).
+		WithArgs(int64(15), ledgerNow, "alice", int64(1), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT ` + ledgerColumns + ` FROM ledgers WHERE id = ?`).WithArgs(int64(1)).WillReturnRows(row(15, 2))
+	mock.ExpectExec(auditInsert).
+		WithArgs("ledger", "1", entities.AuditActionUpdate, "alice", "req-7", "http", captureJSON(&entry.before), captureJSON(&entry.after), ledgerNow).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, b.Update(ctx, &ledger{ID: 1, Total: 15, Audit: entities.Audit{Version: 1}}))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	var before, after ledger
+	require.NoError(t, json.Unmarshal(entry.before, &before))
+	require.NoError(t, json.Unmarshal(entry.after, &after))
+	require.Equal(t, int64(10), before.Total)
+	require.Equal(t, int64(15), after.Total)
+	require.Equal(t, int64(2), after.Version)
+}
+
+func TestBase_WithAudit_FailedChangeIsNotRecorded(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	b := NewBase[ledger](db, MySQL).WithAudit(NewAuditRepository(db, MySQL), "ledger")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT ` + ledgerColumns + ` FROM ledgers WHERE id = ?`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(`UPDATE ledgers SET deleted_at = ?, updated_at = ?, updated_by = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	require.ErrorIs(t, b.Delete(context.Background(), 1), ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBase_WithAudit_UsesTheCallersTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	var appended []*entities.AuditEntry
+	audit := auditFunc(func(ctx context.Context, tx *sql.Tx, e *entities.AuditEntry) error {
+		require.NotNil(t, tx)
+		appended = append(appended, e)
+		return nil
+	})
+	b := NewBase[ledger](db, MySQL).WithAudit(audit, "ledger")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO ledgers (total, created_at, updated_at, deleted_at, created_by, updated_by, version) VALUES (?, ?, ?, ?, ?, ?, ?)`).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectQuery(`SELECT ` + ledgerColumns + ` FROM ledgers WHERE id = ?`).WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = b.WithTx(tx).Create(context.Background(), &ledger{Total: 1})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(), "the caller commits")
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.Len(t, appended, 1)
+	require.Equal(t, "4", appended[0].EntityID)
+	require.Equal(t, SystemActor, appended[0].Actor)
+	require.JSONEq(t, `null`, string(appended[0].Before))
+}
+
+func TestBase_WithAudit_CreateManyRecordsEveryRowInOneInsert(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	b := NewBase[ledger](db, MySQL).WithAudit(NewAuditRepository(db, MySQL), "ledger")
+	b.now = func() time.Time { return ledgerNow }
+	var after8, after9 []byte
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO ledgers (total, created_at, updated_at, deleted_at, created_by, updated_by, version) VALUES (?, ?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?, ?)`).
+		WillReturnResult(sqlmock.NewResult(8, 2))
+	mock.ExpectQuery(`SELECT `+ledgerColumns+` FROM ledgers WHERE id IN (?, ?)`).WithArgs(int64(8), int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "total", "created_at", "updated_at", "deleted_at", "created_by", "updated_by", "version"}).
+			AddRow(9, 2, ledgerNow, ledgerNow, nil, "alice", "alice", 1).
+			AddRow(8, 1, ledgerNow, ledgerNow, nil, "alice", "alice", 1))
+	mock.ExpectExec(auditInsert+`, (?, ?, ?, ?, ?, ?, ?, ?, ?)`).
+		WithArgs(
+			"ledger", "8", entities.AuditActionCreate, "alice", "", "", []byte("null"), jsonArg{&after8}, ledgerNow,
+			"ledger", "9", entities.AuditActionCreate, "alice", "", "", []byte("null"), jsonArg{&after9}, ledgerNow,
+		).
+		WillReturnResult(sqlmock.NewResult(1, 2))
+	mock.ExpectCommit()
+
+	ids, err := b.CreateMany(asAlice(), []*ledger{{Total: 1}, {Total: 2}})
+	require.NoError(t, err)
+	require.Equal(t, []int64{8, 9}, ids)
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Contains(t, string(after8), `"Total":1`, "snapshots are matched by key")
+	require.Contains(t, string(after9), `"Total":2`)
+}
+
+// auditFunc is an AuditRepository that only appends.
+type auditFunc func(ctx context.Context, tx *sql.Tx, e *entities.AuditEntry) error
+
+func (f auditFunc) Append(ctx context.Context, tx *sql.Tx, e *entities.AuditEntry) error {
+	return f(ctx, tx, e)
+}
+
+func (f auditFunc) AppendMany(ctx context.Context, tx *sql.Tx, es []*entities.AuditEntry) error {
+	for _, e := range es {
+		if err := f(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f auditFunc) List(context.Context, string, string, int, int) ([]entities.AuditEntry, int64, error) {
+	return nil, 0, nil
+}
+
+// jsonArg matches any JSON argument and keeps a copy of it.
+type jsonArg struct{ dst *[]byte }
+
+func (a jsonArg) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	if ok && json.Valid(b) {
+		*a.dst = append([]byte(nil), b...)
+	}
+	return ok
+}
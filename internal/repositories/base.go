@@ -0,0 +1,967 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-boilerplate/internal/dbs"
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/utils/auth"
+	"go-boilerplate/internal/utils/correlation"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by Base.
+var (
+	// ErrNotFound is returned by Update and Delete when no row has the given primary key.
+	// GetByID returns a nil entity instead, like the hand-written repositories.
+	ErrNotFound = errors.New("record not found")
+	// ErrUnknownColumn is returned when a filter or sort names a column that T does not map.
+	ErrUnknownColumn = errors.New("unknown column")
+	// ErrConflict is matched by a *ConflictError.
+	ErrConflict = errors.New("version conflict")
+)
+
+// ConflictError is returned by Update when the row was changed by someone else since e was
+// read, i.e. its version no longer matches.
+type ConflictError struct {
+	Table    string
+	ID       any
+	Expected int64 // version the caller read
+	Actual   int64 // version currently stored
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %v: version conflict: expected %d, found %d", e.Table, e.ID, e.Expected, e.Actual)
+}
+
+// Unwrap lets errors.Is(err, ErrConflict) match.
+func (e *ConflictError) Unwrap() error { return ErrConflict }
+
+// SystemActor is recorded in created_by and updated_by for writes made without an
+// authenticated principal, such as background jobs.
+const SystemActor = "system"
+
+// List limits applied by Base.List.
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 1000
+)
+
+// MaxBindParams is the most bind parameters one statement may have in MySQL and Postgres.
+const MaxBindParams = 65535
+
+// Entity is implemented by the types stored through Base. Columns are mapped from `db`
+// struct tags:
+//
+//	type ExampleEntity struct {
+//	    ID     string `db:"id,pk"`
+//	    UserID string `db:"user_id"`
+//	    Note   string `db:"-"` // not stored
+//	}
+//
+// Untagged fields are ignored, except embedded structs whose fields are mapped as if they
+// were declared on T. A struct field tagged `db:"prefix,inline"` is mapped the same way,
+// with prefix (which may be empty) prepended to its column names; entities.Money is
+// stored like that. Without a pk option the "id" column is the primary key.
+//
+// The audit columns of entities.Audit are maintained by Base when T maps them:
+// created_at/created_by and updated_at/updated_by are filled from the clock and the
+// authenticated principal, version implements optimistic locking, and deleted_at turns
+// Delete into a soft delete and hides deleted rows from every read.
+type Entity interface {
+	TableName() string
+}
+
+// Op is a comparison operator in a Filter.
+type Op string
+
+// Filter operators.
+const (
+	OpEq      Op = "="
+	OpNe      Op = "<>"
+	OpLt      Op = "<"
+	OpLte     Op = "<="
+	OpGt      Op = ">"
+	OpGte     Op = ">="
+	OpLike    Op = "LIKE"
+	OpIn      Op = "IN" // Value must be a non-empty slice
+	OpIsNull  Op = "IS NULL"
+	OpNotNull Op = "IS NOT NULL"
+)
+
+// Filter restricts List and Count to rows where Column Op Value holds.
+type Filter struct {
+	Column string
+	Op     Op
+	Value  any
+}
+
+// Where is shorthand for a Filter.
+func Where(column string, op Op, value any) Filter {
+	return Filter{Column: column, Op: op, Value: value}
+}
+
+// Sort orders List results by Column.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// ListSpec selects a page of rows. Filters are ANDed together. Without Sort, rows are
+// ordered by primary key so that pages are stable. Limit defaults to DefaultListLimit and
+// is capped at MaxListLimit.
+type ListSpec struct {
+	Filters []Filter
+	Sort    []Sort
+	Limit   int
+	Offset  int
+}
+
+// queryer is what Base needs to run statements; dbs.Querier and *sql.Tx both provide it.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type column struct {
+	name  string
+	index []int
+}
+
+type mapping struct {
+	table   string
+	columns []column
+	pk      int // index into columns
+	byName  map[string]int
+	list    string // comma separated column names
+
+	// Indexes of the audit columns in columns, or -1 when T does not map them.
+	createdAt, updatedAt, deletedAt, createdBy, updatedBy, version int
+}
+
+// Audit column names and the field kinds they must be mapped to.
+var auditColumns = []struct {
+	name string
+	ok   func(reflect.Type) bool
+}{
+	{"created_at", isTime},
+	{"updated_at", isTime},
+	{"deleted_at", func(t reflect.Type) bool { return t.Kind() == reflect.Pointer && isTime(t) }},
+	{"created_by", func(t reflect.Type) bool { return t.Kind() == reflect.String }},
+	{"updated_by", func(t reflect.Type) bool { return t.Kind() == reflect.String }},
+	{"version", func(t reflect.Type) bool { return t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64 }},
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func isTime(t reflect.Type) bool {
+	return t == timeType || (t.Kind() == reflect.Pointer && t.Elem() == timeType)
+}
+
+var mappings sync.Map // reflect.Type -> *mapping
+
+// Base implements CRUD for an entity type T whose columns are mapped from `db` tags.
+// Concrete repositories embed or wrap it and add their own queries with Table, Columns,
+// Scan and Query.
+type Base[T Entity] struct {
+	db          queryer
+	root        dbs.Querier // the pool, to begin transactions on
+	tx          *sql.Tx     // set by WithTx
+	dialect     Dialect
+	m           *mapping
+	withDeleted bool
+	now         func() time.Time
+
+	audit       AuditRepository
+	auditEntity string
+}
+
+// NewBase creates a Base for T on db. It panics if T's tags do not describe a valid
+// mapping, which is a programming error.
+func NewBase[T Entity](db dbs.Querier, d Dialect) *Base[T] {
+	m, err := mappingOf[T]()
+	if err != nil {
+		panic(err)
+	}
+	return &Base[T]{db: db, root: db, dialect: d, m: m, now: func() time.Time { return time.Now().UTC() }}
+}
+
+// WithTx returns a copy of b that runs its statements in tx.
+func (b *Base[T]) WithTx(tx *sql.Tx) *Base[T] {
+	c := *b
+	c.db = tx
+	c.tx = tx
+	return &c
+}
+
+// WithAudit returns a copy of b that records every Create, Update, Delete and Purge in r
+// under the entity name, together with the row before and after the change. The change
+// and its entry are written in one transaction: b's if it has one, or a new one.
+// A nil r disables auditing.
+func (b *Base[T]) WithAudit(r AuditRepository, entity string) *Base[T] {
+	c := *b
+	c.audit = r
+	c.auditEntity = entity
+	return &c
+}
+
+// WithDeleted returns a copy of b whose reads and updates include soft-deleted rows.
+func (b *Base[T]) WithDeleted() *Base[T] {
+	c := *b
+	c.withDeleted = true
+	return &c
+}
+
+// Dialect returns the dialect b generates SQL for.
+func (b *Base[T]) Dialect() Dialect { return b.dialect }
+
+// Table returns T's table name.
+func (b *Base[T]) Table() string { return b.m.table }
+
+// Columns returns T's mapped columns as a comma separated list, in the order Scan expects.
+func (b *Base[T]) Columns() string { return b.m.list }
+
+// Scan reads one row holding Columns into a new T.
+func (b *Base[T]) Scan(s rowScanner) (*T, error) {
+	var e T
+	v := reflect.ValueOf(&e).Elem()
+	dest := make([]any, len(b.m.columns))
+	for i, c := range b.m.columns {
+		dest[i] = v.FieldByIndex(c.index).Addr().Interface()
+	}
+	if err := s.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Query runs a custom SELECT returning Columns and scans every row.
+func (b *Base[T]) Query(ctx context.Context, query string, args ...any) ([]T, error) {
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []T
+	for rows.Next() {
+		e, err := b.Scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+// GetByID returns the row with primary key id, or nil if there is none or it was
+// soft-deleted.
+func (b *Base[T]) GetByID(ctx context.Context, id any) (*T, error) {
+	row := b.db.QueryRowContext(ctx,
+		`SELECT `+b.m.list+` FROM `+b.m.table+` WHERE `+b.pk()+` = `+b.dialect.Placeholder(1)+b.live(), id)
+	e, err := b.Scan(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+// List returns the rows selected by spec.
+func (b *Base[T]) List(ctx context.Context, spec ListSpec) ([]T, error) {
+	where, args, err := b.where(spec.Filters)
+	if err != nil {
+		return nil, err
+	}
+	order, err := b.orderBy(spec.Sort)
+	if err != nil {
+		return nil, err
+	}
+	limit := spec.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	q := `SELECT ` + b.m.list + ` FROM ` + b.m.table + where + order + ` LIMIT ` + strconv.Itoa(limit)
+	if spec.Offset > 0 {
+		q += ` OFFSET ` + strconv.Itoa(spec.Offset)
+	}
+	return b.Query(ctx, q, args...)
+}
+
+// Count returns the number of rows matching filters.
+func (b *Base[T]) Count(ctx context.Context, filters ...Filter) (int64, error) {
+	where, args, err := b.where(filters)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	err = b.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+b.m.table+where, args...).Scan(&n)
+	return n, err
+}
+
+// Exists reports whether a row with primary key id exists.
+func (b *Base[T]) Exists(ctx context.Context, id any) (bool, error) {
+	var ok bool
+	err := b.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+b.m.table+` WHERE `+b.pk()+` = `+b.dialect.Placeholder(1)+b.live()+`)`, id).Scan(&ok)
+	return ok, err
+}
+
+// Create inserts e and returns its primary key. A zero primary key is left to the
+// database to generate and is set on e afterwards. Unset audit columns are filled in:
+// both timestamps with the current time, both actors with the caller and version with 1.
+func (b *Base[T]) Create(ctx context.Context, e *T) (int64, error) {
+	var id int64
+	err := b.audited(ctx, entities.AuditActionCreate, func() any { return b.pkOf(e) }, func(tb *Base[T]) (err error) {
+		id, err = tb.create(ctx, e)
+		return err
+	})
+	return id, err
+}
+
+func (b *Base[T]) create(ctx context.Context, e *T) (int64, error) {
+	ids, err := b.createMany(ctx, []*T{e})
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// CreateMany inserts es with a single multi-row INSERT, filling in primary keys and audit
+// columns like Create, and returns the primary keys in the order of es. Either all rows
+// are inserted or none is. The primary keys must either all be zero or all be set, and the
+// statement may not need more than MaxBindParams parameters (rows times columns), so
+// callers split large batches.
+//
+// Without RETURNING, the keys are derived from LastInsertId, which is the key of the first
+// row: InnoDB allocates the keys of a multi-row INSERT consecutively, provided
+// auto_increment_increment is 1.
+func (b *Base[T]) CreateMany(ctx context.Context, es []*T) ([]int64, error) {
+	if len(es) == 0 {
+		return nil, nil
+	}
+	if b.audit == nil {
+		return b.createMany(ctx, es)
+	}
+	var ids []int64
+	err := b.inTx(ctx, func(tb *Base[T]) error {
+		var err error
+		if ids, err = tb.createMany(ctx, es); err != nil {
+			return err
+		}
+		// Read the new rows back in one query rather than one per row
+		keys := make([]any, len(es))
+		for i, e := range es {
+			keys[i] = tb.pkOf(e)
+		}
+		where, args, err := tb.WithDeleted().where([]Filter{Where(tb.pk(), OpIn, keys)})
+		if err != nil {
+			return err
+		}
+		rows, err := tb.Query(ctx, `SELECT `+tb.m.list+` FROM `+tb.m.table+where, args...)
+		if err != nil {
+			return err
+		}
+		after := make(map[string]json.RawMessage, len(rows))
+		for _, r := range rows {
+			if after[fmt.Sprint(tb.pkOf(&r))], err = json.Marshal(r); err != nil {
+				return err
+			}
+		}
+		entries := make([]*entities.AuditEntry, len(keys))
+		for i, key := range keys {
+			snap, ok := after[fmt.Sprint(key)]
+			if !ok {
+				snap = json.RawMessage("null")
+			}
+			entries[i] = tb.auditEntry(ctx, entities.AuditActionCreate, key, json.RawMessage("null"), snap)
+		}
+		return tb.audit.AppendMany(ctx, tb.tx, entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (b *Base[T]) createMany(ctx context.Context, es []*T) ([]int64, error) {
+	now, actor := b.now(), actorFrom(ctx)
+	var generated bool
+	for i, e := range es {
+		v := reflect.ValueOf(e).Elem()
+		b.setIfZero(v, b.m.createdAt, now)
+		b.setIfZero(v, b.m.updatedAt, now)
+		b.setIfZero(v, b.m.createdBy, actor)
+		b.setIfZero(v, b.m.updatedBy, actor)
+		if b.m.version >= 0 && v.FieldByIndex(b.m.columns[b.m.version].index).IsZero() {
+			v.FieldByIndex(b.m.columns[b.m.version].index).SetInt(1)
+		}
+		zero := v.FieldByIndex(b.m.columns[b.m.pk].index).IsZero()
+		if i == 0 {
+			generated = zero
+		} else if zero != generated {
+			return nil, fmt.Errorf("repositories: %s batch mixes generated and explicit primary keys", b.m.table)
+		}
+	}
+
+	var names []string
+	for i, c := range b.m.columns {
+		if i != b.m.pk || !generated {
+			names = append(names, c.name)
+		}
+	}
+	if len(names)*len(es) > MaxBindParams {
+		return nil, fmt.Errorf("repositories: inserting %d %s rows needs more than %d parameters", len(es), b.m.table, MaxBindParams)
+	}
+	var (
+		rows = make([]string, len(es))
+		args = make([]any, 0, len(names)*len(es))
+	)
+	for r, e := range es {
+		v := reflect.ValueOf(e).Elem()
+		marks := make([]string, 0, len(names))
+		for i, c := range b.m.columns {
+			if i == b.m.pk && generated {
+				continue
+			}
+			args = append(args, v.FieldByIndex(c.index).Interface())
+			marks = append(marks, b.dialect.Placeholder(len(args)))
+		}
+		rows[r] = `(` + strings.Join(marks, ", ") + `)`
+	}
+	q := `INSERT INTO ` + b.m.table + ` (` + strings.Join(names, ", ") + `) VALUES ` + strings.Join(rows, ", ")
+
+	ids := make([]int64, len(es))
+	switch {
+	case !generated:
+		if _, err := b.db.ExecContext(ctx, q, args...); err != nil {
+			return nil, err
+		}
+		for i, e := range es {
+			ids[i] = toInt64(reflect.ValueOf(e).Elem().FieldByIndex(b.m.columns[b.m.pk].index))
+		}
+		return ids, nil
+	case b.dialect.Returning():
+		// Rows come back in VALUES order
+		res, err := b.db.QueryContext(ctx, q+` RETURNING `+b.pk(), args...)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Close()
+		n := 0
+		for ; res.Next(); n++ {
+			if n == len(ids) {
+				return nil, fmt.Errorf("repositories: %s insert returned more keys than rows", b.m.table)
+			}
+			if err := res.Scan(&ids[n]); err != nil {
+				return nil, err
+			}
+		}
+		if err := res.Err(); err != nil {
+			return nil, err
+		}
+		if n != len(ids) {
+			return nil, fmt.Errorf("repositories: %s insert returned %d keys for %d rows", b.m.table, n, len(ids))
+		}
+	default:
+		res, err := b.db.ExecContext(ctx, q, args...)
+		if err != nil {
+			return nil, err
+		}
+		first, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		for i := range ids {
+			ids[i] = first + int64(i)
+		}
+	}
+	for i, e := range es {
+		if err := setInt64(reflect.ValueOf(e).Elem().FieldByIndex(b.m.columns[b.m.pk].index), ids[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// Update writes the mapped columns of e to the row with e's primary key and records the
+// caller in updated_at and updated_by. Creation columns and deleted_at are never changed.
+//
+// If T has a version column, the row is only updated while it still has e's version,
+// which is then incremented; otherwise Update returns a *ConflictError. It returns
+// ErrNotFound if there is no such row, or it was soft-deleted.
+func (b *Base[T]) Update(ctx context.Context, e *T) error {
+	return b.audited(ctx, entities.AuditActionUpdate, func() any { return b.pkOf(e) }, func(tb *Base[T]) error {
+		return tb.update(ctx, e)
+	})
+}
+
+func (b *Base[T]) update(ctx context.Context, e *T) error {
+	v := reflect.ValueOf(e).Elem()
+	now, actor := b.now(), actorFrom(ctx)
+	var (
+		sets []string
+		args []any
+	)
+	for i, c := range b.m.columns {
+		var val any
+		switch i {
+		case b.m.pk, b.m.createdAt, b.m.createdBy, b.m.deletedAt, b.m.version:
+			continue
+		case b.m.updatedAt:
+			val = now
+		case b.m.updatedBy:
+			val = actor
+		default:
+			val = v.FieldByIndex(c.index).Interface()
+		}
+		args = append(args, val)
+		sets = append(sets, c.name+` = `+b.dialect.Placeholder(len(args)))
+	}
+	if b.m.version >= 0 {
+		name := b.m.columns[b.m.version].name
+		sets = append(sets, name+` = `+name+` + 1`)
+	}
+
+	id := v.FieldByIndex(b.m.columns[b.m.pk].index).Interface()
+	args = append(args, id)
+	where := b.pk() + ` = ` + b.dialect.Placeholder(len(args))
+	var expected int64
+	if b.m.version >= 0 {
+		expected = v.FieldByIndex(b.m.columns[b.m.version].index).Int()
+		args = append(args, expected)
+		where += ` AND ` + b.m.columns[b.m.version].name + ` = ` + b.dialect.Placeholder(len(args))
+	}
+
+	res, err := b.db.ExecContext(ctx,
+		`UPDATE `+b.m.table+` SET `+strings.Join(sets, ", ")+` WHERE `+where+b.live(), args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if err := b.explainMiss(ctx, id, expected); err != nil {
+			return err
+		}
+	}
+
+	b.set(v, b.m.updatedAt, now)
+	b.set(v, b.m.updatedBy, actor)
+	if b.m.version >= 0 {
+		f := v.FieldByIndex(b.m.columns[b.m.version].index)
+		f.SetInt(f.Int() + 1)
+	}
+	return nil
+}
+
+// explainMiss finds out why an UPDATE matched no row, asking the primary so that
+// replication lag cannot hide the row.
+func (b *Base[T]) explainMiss(ctx context.Context, id any, expected int64) error {
+	ctx = dbs.WithPrimary(ctx)
+	if b.m.version < 0 {
+		// MySQL reports rows changed rather than rows matched, so an update that changes
+		// nothing looks the same as a missing row.
+		ok, err := b.Exists(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNotFound
+		}
+		return nil
+	}
+
+	var actual int64
+	err := b.db.QueryRowContext(ctx,
+		`SELECT `+b.m.columns[b.m.version].name+` FROM `+b.m.table+` WHERE `+b.pk()+` = `+b.dialect.Placeholder(1)+b.live(), id).Scan(&actual)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return &ConflictError{Table: b.m.table, ID: id, Expected: expected, Actual: actual}
+}
+
+// Delete removes the row with primary key id. If T has a deleted_at column the row is only
+// marked as deleted, along with the audit columns of an update. It returns ErrNotFound if
+// there is no such row.
+func (b *Base[T]) Delete(ctx context.Context, id any) error {
+	return b.audited(ctx, entities.AuditActionDelete, func() any { return id }, func(tb *Base[T]) error {
+		if tb.m.deletedAt < 0 {
+			return tb.purge(ctx, id)
+		}
+		return tb.softDelete(ctx, id)
+	})
+}
+
+func (b *Base[T]) softDelete(ctx context.Context, id any) error {
+	now := b.now()
+	sets := []string{b.m.columns[b.m.deletedAt].name + ` = ` + b.dialect.Placeholder(1)}
+	args := []any{now}
+	if b.m.updatedAt >= 0 {
+		args = append(args, now)
+		sets = append(sets, b.m.columns[b.m.updatedAt].name+` = `+b.dialect.Placeholder(len(args)))
+	}
+	if b.m.updatedBy >= 0 {
+		args = append(args, actorFrom(ctx))
+		sets = append(sets, b.m.columns[b.m.updatedBy].name+` = `+b.dialect.Placeholder(len(args)))
+	}
+	if b.m.version >= 0 {
+		name := b.m.columns[b.m.version].name
+		sets = append(sets, name+` = `+name+` + 1`)
+	}
+	args = append(args, id)
+
+	res, err := b.db.ExecContext(ctx,
+		`UPDATE `+b.m.table+` SET `+strings.Join(sets, ", ")+` WHERE `+b.pk()+` = `+b.dialect.Placeholder(len(args))+
+			` AND `+b.m.columns[b.m.deletedAt].name+` IS NULL`, args...)
+	return affectedOne(res, err)
+}
+
+// Purge permanently removes the row with primary key id, even if T supports soft delete.
+// It returns ErrNotFound if there is no such row.
+func (b *Base[T]) Purge(ctx context.Context, id any) error {
+	return b.audited(ctx, entities.AuditActionDelete, func() any { return id }, func(tb *Base[T]) error {
+		return tb.purge(ctx, id)
+	})
+}
+
+func (b *Base[T]) purge(ctx context.Context, id any) error {
+	res, err := b.db.ExecContext(ctx,
+		`DELETE FROM `+b.m.table+` WHERE `+b.pk()+` = `+b.dialect.Placeholder(1), id)
+	return affectedOne(res, err)
+}
+
+func affectedOne(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// audited runs fn, which changes the row with primary key id() through tb. With auditing
+// enabled, tb is bound to a transaction in which the row is read before and after fn and
+// the audit entry is appended.
+func (b *Base[T]) audited(ctx context.Context, action string, id func() any, fn func(tb *Base[T]) error) error {
+	if b.audit == nil {
+		return fn(b)
+	}
+
+	return b.inTx(ctx, func(tb *Base[T]) error {
+		snapshot := func() (json.RawMessage, error) {
+			e, err := tb.WithDeleted().GetByID(ctx, id())
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(e) // null if there is no row
+		}
+
+		before := json.RawMessage("null")
+		if action != entities.AuditActionCreate {
+			var err error
+			if before, err = snapshot(); err != nil {
+				return err
+			}
+		}
+		if err := fn(tb); err != nil {
+			return err
+		}
+		after, err := snapshot()
+		if err != nil {
+			return err
+		}
+		return tb.appendAudit(ctx, action, id(), before, after)
+	})
+}
+
+// inTx runs fn with b bound to a transaction: b's own if it has one, otherwise a new one
+// that is committed when fn succeeds.
+func (b *Base[T]) inTx(ctx context.Context, fn func(tb *Base[T]) error) error {
+	if b.tx != nil {
+		return fn(b)
+	}
+	tx, err := b.root.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(b.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// appendAudit records a change of the row with primary key id in b's transaction.
+func (b *Base[T]) appendAudit(ctx context.Context, action string, id any, before, after json.RawMessage) error {
+	return b.audit.Append(ctx, b.tx, b.auditEntry(ctx, action, id, before, after))
+}
+
+// auditEntry describes a change of the row with primary key id made with ctx.
+func (b *Base[T]) auditEntry(ctx context.Context, action string, id any, before, after json.RawMessage) *entities.AuditEntry {
+	ids := correlation.FromContext(ctx)
+	return &entities.AuditEntry{
+		Entity:    b.auditEntity,
+		EntityID:  fmt.Sprint(id),
+		Action:    action,
+		Actor:     actorFrom(ctx),
+		RequestID: ids.RequestID,
+		Transport: ids.Transport,
+		Before:    before,
+		After:     after,
+		CreatedAt: b.now(),
+	}
+}
+
+func (b *Base[T]) pkOf(e *T) any {
+	return reflect.ValueOf(e).Elem().FieldByIndex(b.m.columns[b.m.pk].index).Interface()
+}
+
+// live returns the condition that hides soft-deleted rows, if b applies one.
+func (b *Base[T]) live() string {
+	if b.m.deletedAt < 0 || b.withDeleted {
+		return ""
+	}
+	return ` AND ` + b.m.columns[b.m.deletedAt].name + ` IS NULL`
+}
+
+// set stores val in the audit column at index i, if T maps it.
+func (b *Base[T]) set(v reflect.Value, i int, val any) {
+	if i < 0 {
+		return
+	}
+	f := v.FieldByIndex(b.m.columns[i].index)
+	rv := reflect.ValueOf(val)
+	if f.Kind() == reflect.Pointer {
+		p := reflect.New(f.Type().Elem())
+		p.Elem().Set(rv)
+		rv = p
+	}
+	f.Set(rv)
+}
+
+func (b *Base[T]) setIfZero(v reflect.Value, i int, val any) {
+	if i >= 0 && v.FieldByIndex(b.m.columns[i].index).IsZero() {
+		b.set(v, i, val)
+	}
+}
+
+// actorFrom returns the authenticated subject in ctx, or SystemActor.
+func actorFrom(ctx context.Context) string {
+	if p, ok := auth.PrincipalFromContext(ctx); ok && p.Subject != "" {
+		return p.Subject
+	}
+	return SystemActor
+}
+
+func (b *Base[T]) pk() string { return b.m.columns[b.m.pk].name }
+
+// where renders filters as a WHERE clause. Column names are checked against the mapping,
+// so only values are ever bound from caller input.
+func (b *Base[T]) where(filters []Filter) (string, []any, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	if live := b.live(); live != "" {
+		conds = append(conds, strings.TrimPrefix(live, ` AND `))
+	}
+	for _, f := range filters {
+		if _, ok := b.m.byName[f.Column]; !ok {
+			return "", nil, fmt.Errorf("%w %q in %s filter", ErrUnknownColumn, f.Column, b.m.table)
+		}
+		switch f.Op {
+		case OpEq, OpNe, OpLt, OpLte, OpGt, OpGte, OpLike:
+			args = append(args, f.Value)
+			conds = append(conds, f.Column+` `+string(f.Op)+` `+b.dialect.Placeholder(len(args)))
+		case OpIn:
+			rv := reflect.ValueOf(f.Value)
+			if rv.Kind() != reflect.Slice || rv.Len() == 0 {
+				return "", nil, fmt.Errorf("filter %s IN: value must be a non-empty slice", f.Column)
+			}
+			marks := make([]string, rv.Len())
+			for i := range marks {
+				args = append(args, rv.Index(i).Interface())
+				marks[i] = b.dialect.Placeholder(len(args))
+			}
+			conds = append(conds, f.Column+` IN (`+strings.Join(marks, ", ")+`)`)
+		case OpIsNull, OpNotNull:
+			conds = append(conds, f.Column+` `+string(f.Op))
+		default:
+			return "", nil, fmt.Errorf("filter %s: unsupported operator %q", f.Column, f.Op)
+		}
+	}
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return ` WHERE ` + strings.Join(conds, " AND "), args, nil
+}
+
+func (b *Base[T]) orderBy(sorts []Sort) (string, error) {
+	if len(sorts) == 0 {
+		return ` ORDER BY ` + b.pk(), nil
+	}
+	parts := make([]string, len(sorts))
+	for i, s := range sorts {
+		if _, ok := b.m.byName[s.Column]; !ok {
+			return "", fmt.Errorf("%w %q in %s sort", ErrUnknownColumn, s.Column, b.m.table)
+		}
+		parts[i] = s.Column
+		if s.Desc {
+			parts[i] += ` DESC`
+		}
+	}
+	return ` ORDER BY ` + strings.Join(parts, ", "), nil
+}
+
+func mappingOf[T Entity]() (*mapping, error) {
+	var zero T
+	t := reflect.TypeOf(zero)
+	if m, ok := mappings.Load(t); ok {
+		return m.(*mapping), nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("repositories: %s is not a struct", t)
+	}
+
+	m := &mapping{table: zero.TableName(), pk: -1, byName: map[string]int{},
+		createdAt: -1, updatedAt: -1, deletedAt: -1, createdBy: -1, updatedBy: -1, version: -1}
+	var walk func(t reflect.Type, index []int, prefix string) error
+	walk = func(t reflect.Type, index []int, prefix string) error {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			idx := append(append([]int(nil), index...), i)
+			tag, ok := f.Tag.Lookup("db")
+			if !ok {
+				if f.Anonymous && f.Type.Kind() == reflect.Struct {
+					if err := walk(f.Type, idx, prefix); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "-" || !f.IsExported() {
+				continue
+			}
+			if opts == "inline" {
+				if f.Type.Kind() != reflect.Struct {
+					return fmt.Errorf("repositories: %s.%s is inline but not a struct", t, f.Name)
+				}
+				if err := walk(f.Type, idx, prefix+name); err != nil {
+					return err
+				}
+				continue
+			}
+			name = prefix + name
+			if _, dup := m.byName[name]; dup {
+				return fmt.Errorf("repositories: %s maps column %q twice", t, name)
+			}
+			m.byName[name] = len(m.columns)
+			if opts == "pk" {
+				if m.pk >= 0 {
+					return fmt.Errorf("repositories: %s has more than one pk column", t)
+				}
+				m.pk = len(m.columns)
+			}
+			m.columns = append(m.columns, column{name: name, index: idx})
+		}
+		return nil
+	}
+	if err := walk(t, nil, ""); err != nil {
+		return nil, err
+	}
+	if m.pk < 0 {
+		i, ok := m.byName["id"]
+		if !ok {
+			return nil, fmt.Errorf("repositories: %s has no pk column", t)
+		}
+		m.pk = i
+	}
+	for _, a := range auditColumns {
+		i, ok := m.byName[a.name]
+		if !ok {
+			continue
+		}
+		if i == m.pk {
+			return nil, fmt.Errorf("repositories: %s uses audit column %q as pk", t, a.name)
+		}
+		if f := t.FieldByIndex(m.columns[i].index); !a.ok(f.Type) {
+			return nil, fmt.Errorf("repositories: %s maps audit column %q to unsupported type %s", t, a.name, f.Type)
+		}
+		switch a.name {
+		case "created_at":
+			m.createdAt = i
+		case "updated_at":
+			m.updatedAt = i
+		case "deleted_at":
+			m.deletedAt = i
+		case "created_by":
+			m.createdBy = i
+		case "updated_by":
+			m.updatedBy = i
+		case "version":
+			m.version = i
+		}
+	}
+
+	names := make([]string, len(m.columns))
+	for i, c := range m.columns {
+		names[i] = c.name
+	}
+	m.list = strings.Join(names, ", ")
+
+	actual, _ := mappings.LoadOrStore(t, m)
+	return actual.(*mapping), nil
+}
+
+// setInt64 stores a generated key in an integer or string primary key field.
+func setInt64(v reflect.Value, id int64) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(id))
+	case reflect.String:
+		v.SetString(strconv.FormatInt(id, 10))
+	default:
+		return fmt.Errorf("repositories: cannot store generated key in %s", v.Type())
+	}
+	return nil
+}
+
+// toInt64 returns a caller-supplied primary key as an int64, or 0 if it is not numeric.
+func toInt64(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.String:
+		n, _ := strconv.ParseInt(v.String(), 10, 64)
+		return n
+	}
+	return 0
+}
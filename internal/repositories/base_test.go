@@ -0,0 +1,471 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/utils/auth"
+)
+
+type stamps struct {
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type widget struct {
+	Key   int64  `db:"widget_key,pk"`
+	Name  string `db:"name"`
+	Price int64  `db:"price"`
+	Note  string `db:"-"`
+	stamps
+}
+
+func (widget) TableName() string { return "widgets" }
+
+func newWidgetBase(t *testing.T, d Dialect) (*Base[widget], sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewBase[widget](db, d), mock
+}
+
+func TestBase_Mapping(t *testing.T) {
+	b, _ := newWidgetBase(t, MySQL)
+	require.Equal(t, "widgets", b.Table())
+	require.Equal(t, "widget_key, name, price, created_at", b.Columns())
+}
+
+func TestBase_GetByID(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		dialect Dialect
+		query   string
+	}{
+		{MySQL, `SELECT widget_key, name, price, created_at FROM widgets WHERE widget_key = ?`},
+		{Postgres, `SELECT widget_key, name, price, created_at FROM widgets WHERE widget_key = $1`},
+	} {
+		t.Run(tc.dialect.Name(), func(t *testing.T) {
+			b, mock := newWidgetBase(t, tc.dialect)
+			mock.ExpectQuery(tc.query).WithArgs(7).
+				WillReturnRows(sqlmock.NewRows([]string{"widget_key", "name", "price", "created_at"}).AddRow(7, "bolt", 25, at))
+			mock.ExpectQuery(tc.query).WithArgs(8).
+				WillReturnRows(sqlmock.NewRows([]string{"widget_key", "name", "price", "created_at"}))
+
+			w, err := b.GetByID(context.Background(), 7)
+			require.NoError(t, err)
+			require.Equal(t, &widget{Key: 7, Name: "bolt", Price: 25, stamps: stamps{CreatedAt: at}}, w)
+
+			w, err = b.GetByID(context.Background(), 8)
+			require.NoError(t, err)
+			require.Nil(t, w)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBase_List(t *testing.T) {
+	spec := ListSpec{
+		Filters: []Filter{Where("price", OpGte, 10), Where("name", OpIn, []string{"bolt", "nut"}), Where("created_at", OpNotNull, nil)},
+		Sort:    []Sort{{Column: "price", Desc: true}, {Column: "name"}},
+		Limit:   20,
+		Offset:  40,
+	}
+	for _, tc := range []struct {
+		dialect Dialect
+		query   string
+	}{
+		{MySQL, `SELECT widget_key, name, price, created_at FROM widgets WHERE price >= ? AND name IN (?, ?) AND created_at IS NOT NULL ORDER BY price DESC, name LIMIT 20 OFFSET 40`},
+		{Postgres, `SELECT widget_key, name, price, created_at FROM widgets WHERE price >= $1 AND name IN ($2, $3) AND created_at IS NOT NULL ORDER BY price DESC, name LIMIT 20 OFFSET 40`},
+	} {
+		t.Run(tc.dialect.Name(), func(t *testing.T) {
+			b, mock := newWidgetBase(t, tc.dialect)
+			mock.ExpectQuery(tc.query).WithArgs(10, "bolt", "nut").
+				WillReturnRows(sqlmock.NewRows([]string{"widget_key", "name", "price", "created_at"}).
+					AddRow(2, "nut", 30, time.Time{}).AddRow(1, "bolt", 30, time.Time{}))
+
+			ws, err := b.List(context.Background(), spec)
+			require.NoError(t, err)
+			require.Len(t, ws, 2)
+			require.Equal(t, "nut", ws[0].Name)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBase_List_Defaults(t *testing.T) {
+	b, mock := newWidgetBase(t, MySQL)
+	mock.ExpectQuery(`SELECT widget_key, name, price, created_at FROM widgets ORDER BY widget_key LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows([]string{"widget_key", "name", "price", "created_at"}))
+	mock.ExpectQuery(`SELECT widget_key, name, price, created_at FROM widgets ORDER BY widget_key LIMIT 1000`).
+		WillReturnRows(sqlmock.NewRows([]string{"widget_key", "name", "price", "created_at"}))
+
+	_, err := b.List(context.Background(), ListSpec{})
+	require.NoError(t, err)
+	_, err = b.List(context.Background(), ListSpec{Limit: 5000})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBase_RejectsUnknownColumns(t *testing.T) {
+	b, _ := newWidgetBase(t, MySQL)
+
+	_, err := b.List(context.Background(), ListSpec{Filters: []Filter{Where("price; DROP TABLE widgets", OpEq, 1)}})
+	require.ErrorIs(t, err, ErrUnknownColumn)
+	_, err = b.List(context.Background(), ListSpec{Sort: []Sort{{Column: "note"}}})
+	require.ErrorIs(t, err, ErrUnknownColumn)
+	_, err = b.Count(context.Background(), Where("name", OpIn, []string{}))
+	require.Error(t, err)
+}
+
+func TestBase_CountAndExists(t *testing.T) {
+	b, mock := newWidgetBase(t, Postgres)
+	mock.ExpectQuery(`SELECT COUNT(*) FROM widgets WHERE name LIKE $1`).WithArgs("bo%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT EXISTS (SELECT 1 FROM widgets WHERE widget_key = $1)`).WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	n, err := b.Count(context.Background(), Where("name", OpLike, "bo%"))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+
+	ok, err := b.Exists(context.Background(), 7)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBase_Create(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("mysql", func(t *testing.T) {
+		b, mock := newWidgetBase(t, MySQL)
+		mock.ExpectExec(`INSERT INTO widgets (name, price, created_at) VALUES (?, ?, ?)`).
+			WithArgs("bolt", int64(25), at).WillReturnResult(sqlmock.NewResult(11, 1))
+
+		w := &widget{Name: "bolt", Price: 25, stamps: stamps{CreatedAt: at}}
+		id, err := b.Create(context.Background(), w)
+		require.NoError(t, err)
+		require.Equal(t, int64(11), id)
+		require.Equal(t, int64(11), w.Key)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		b, mock := newWidgetBase(t, Postgres)
+		mock.ExpectQuery(`INSERT INTO widgets (name, price, created_at) VALUES ($1, $2, $3) RETURNING widget_key`).
+			WithArgs("bolt", int64(25), at).WillReturnRows(sqlmock.NewRows([]string{"widget_key"}).AddRow(12))
+
+		w := &widget{Name: "bolt", Price: 25, stamps: stamps{CreatedAt: at}}
+		id, err := b.Create(context.Background(), w)
+		require.NoError(t, err)
+		require.Equal(t, int64(12), id)
+		require.Equal(t, int64(12), w.Key)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("explicit key", func(t *testing.T) {
+		b, mock := newWidgetBase(t, Postgres)
+		mock.ExpectExec(`INSERT INTO widgets (widget_key, name, price, created_at) VALUES ($1, $2, $3, $4)`).
+			WithArgs(int64(99), "bolt", int64(25), at).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		id, err := b.Create(context.Background(), &widget{Key: 99, Name: "bolt", Price: 25, stamps: stamps{CreatedAt: at}})
+		require.NoError(t, err)
+		require.Equal(t, int64(99), id)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBase_CreateMany(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	batch := func() []*widget {
+		return []*widget{
+			{Name: "bolt", Price: 25, stamps: stamps{CreatedAt: at}},
+			{Name: "nut", Price: 5, stamps: stamps{CreatedAt: at}},
+			{Name: "washer", Price: 1, stamps: stamps{CreatedAt: at}},
+		}
+	}
+
+	t.Run("mysql", func(t *testing.T) {
+		b, mock := newWidgetBase(t, MySQL)
+		mock.ExpectExec(`INSERT INTO widgets (name, price, created_at) VALUES (?, ?, ?), (?, ?, ?), (?, ?, ?)`).
+			WithArgs("bolt", int64(25), at, "nut", int64(5), at, "washer", int64(1), at).
+			WillReturnResult(sqlmock.NewResult(40, 3))
+
+		ws := batch()
+		ids, err := b.CreateMany(context.Background(), ws)
+		require.NoError(t, err)
+		require.Equal(t, []int64{40, 41, 42}, ids, "keys follow the first one")
+		require.Equal(t, int64(42), ws[2].Key)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		b, mock := newWidgetBase(t, Postgres)
+		mock.ExpectQuery(`INSERT INTO widgets (name, price, created_at) VALUES ($1, $2, $3), ($4, $5, $6), ($7, $8, $9) RETURNING widget_key`).
+			WithArgs("bolt", int64(25), at, "nut", int64(5), at, "washer", int64(1), at).
+			WillReturnRows(sqlmock.NewRows([]string{"widget_key"}).AddRow(7).AddRow(9).AddRow(12))
+
+		ws := batch()
+		ids, err := b.CreateMany(context.Background(), ws)
+		require.NoError(t, err)
+		require.Equal(t, []int64{7, 9, 12}, ids)
+		require.Equal(t, int64(9), ws[1].Key)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("postgres missing keys", func(t *testing.T) {
+		b, mock := newWidgetBase(t, Postgres)
+		mock.ExpectQuery(`INSERT INTO widgets (name, price, created_at) VALUES ($1, $2, $3), ($4, $5, $6), ($7, $8, $9) RETURNING widget_key`).
+			WillReturnRows(sqlmock.NewRows([]string{"widget_key"}).AddRow(7))
+
+		_, err := b.CreateMany(context.Background(), batch())
+		require.ErrorContains(t, err, "returned 1 keys for 3 rows")
+	})
+
+	t.Run("mixed keys", func(t *testing.T) {
+		b, _ := newWidgetBase(t, MySQL)
+		ws := batch()
+		ws[1].Key = 5
+		_, err := b.CreateMany(context.Background(), ws)
+		require.ErrorContains(t, err, "mixes generated and explicit primary keys")
+	})
+
+	t.Run("too many parameters", func(t *testing.T) {
+		b, _ := newWidgetBase(t, MySQL)
+		ws := make([]*widget, MaxBindParams/3+1)
+		for i := range ws {
+			ws[i] = &widget{Name: "bolt"}
+		}
+		_, err := b.CreateMany(context.Background(), ws)
+		require.ErrorContains(t, err, "parameters")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		b, _ := newWidgetBase(t, MySQL)
+		ids, err := b.CreateMany(context.Background(), nil)
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+}
+
+func TestBase_Update(t *testing.T) {
+	b, mock := newWidgetBase(t, MySQL)
+	// created_at is an audit column and is never rewritten.
+	const update = `UPDATE widgets SET name = ?, price = ? WHERE widget_key = ?`
+	mock.ExpectExec(update).WithArgs("bolt", int64(30), int64(7)).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Unchanged row: MySQL reports zero rows affected, the existence check tells it apart.
+	mock.ExpectExec(update).WithArgs("bolt", int64(30), int64(7)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS (SELECT 1 FROM widgets WHERE widget_key = ?)`).WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec(update).WithArgs("bolt", int64(30), int64(8)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS (SELECT 1 FROM widgets WHERE widget_key = ?)`).WithArgs(int64(8)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	ctx := context.Background()
+	require.NoError(t, b.Update(ctx, &widget{Key: 7, Name: "bolt", Price: 30}))
+	require.NoError(t, b.Update(ctx, &widget{Key: 7, Name: "bolt", Price: 30}))
+	require.ErrorIs(t, b.Update(ctx, &widget{Key: 8, Name: "bolt", Price: 30}), ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBase_Delete(t *testing.T) {
+	b, mock := newWidgetBase(t, Postgres)
+	mock.ExpectExec(`DELETE FROM widgets WHERE widget_key = $1`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM widgets WHERE widget_key = $1`).WithArgs(8).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, b.Delete(context.Background(), 7))
+	require.ErrorIs(t, b.Delete(context.Background(), 8), ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBase_WithTx(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	b := NewBase[widget](db, MySQL)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM widgets WHERE widget_key = ?`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, b.WithTx(tx).Delete(context.Background(), 7))
+	require.NoError(t, tx.Commit())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewBase_PanicsWithoutPrimaryKey(t *testing.T) {
+	require.Panics(t, func() { NewBase[noKey](nil, MySQL) })
+}
+
+type noKey struct {
+	Name string `db:"name"`
+}
+
+func (noKey) TableName() string { return "no_keys" }
+
+type ledger struct {
+	ID    int64 `db:"id"`
+	Total int64 `db:"total"`
+	entities.Audit
+}
+
+func (ledger) TableName() string { return "ledgers" }
+
+const ledgerColumns = `id, total, created_at, updated_at, deleted_at, created_by, updated_by, version`
+
+var ledgerNow = time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+func newLedgerBase(t *testing.T) (*Base[ledger], sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	b := NewBase[ledger](db, MySQL)
+	b.now = func() time.Time { return ledgerNow }
+	return b, mock
+}
+
+func asAlice() context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "alice"})
+}
+
+func TestBase_Audit_CreateFillsAuditColumns(t *testing.T) {
+	b, mock := newLedgerBase(t)
+	mock.ExpectExec(`INSERT INTO ledgers (total, created_at, updated_at, deleted_at, created_by, updated_by, version) VALUES (?, ?, ?, ?, ?, ?, ?)`).
+		WithArgs(int64(10), ledgerNow, ledgerNow, nil, "alice", "alice", int64(1)).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec(`INSERT INTO ledgers (total, created_at, updated_at, deleted_at, created_by, updated_by, version) VALUES (?, ?, ?, ?, ?, ?, ?)`).
+		WithArgs(int64(20), ledgerNow, ledgerNow, nil, SystemActor, SystemActor, int64(1)).
+		WillReturnResult(sqlmock.NewResult(6, 1))
+
+	l := &ledger{Total: 10}
+	_, err := b.Create(asAlice(), l)
+	require.NoError(t, err)
+	require.Equal(t, entities.Audit{CreatedAt: ledgerNow, UpdatedAt: ledgerNow, CreatedBy: "alice", UpdatedBy: "alice", Version: 1}, l.Audit)
+
+	_, err = b.Create(context.Background(), &ledger{Total: 20})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBase_Audit_ReadsHideDeletedRows(t *testing.T) {
+	b, mock := newLedgerBase(t)
+	mock.ExpectQuery(`SELECT ` + ledgerColumns + ` FROM ledgers WHERE id = ? AND deleted_at IS NULL`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT ` + ledgerColumns + ` FROM ledgers WHERE deleted_at IS NULL AND total > ? ORDER BY id LIMIT 50`).WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT COUNT(*) FROM ledgers WHERE deleted_at IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT EXISTS (SELECT 1 FROM ledgers WHERE id = ? AND deleted_at IS NULL)`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT ` + ledgerColumns + ` FROM ledgers WHERE id = ?`).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	ctx := context.Background()
+	l, err := b.GetByID(ctx, 1)
+	require.NoError(t, err)
+	require.Nil(t, l)
+	_, err = b.List(ctx, ListSpec{Filters: []Filter{Where("total", OpGt, 5)}})
+	require.NoError(t, err)
+	_, err = b.Count(ctx)
+	require.NoError(t, err)
+	_, err = b.Exists(ctx, 1)
+	require.NoError(t, err)
+	_, err = b.WithDeleted().GetByID(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBase_Audit_UpdateChecksVersion(t *testing.T) {
+	b, mock := newLedgerBase(t)
+	const update = `UPDATE ledgers SET total = ?, updated_at = ?, updated_by = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	const current = `SELECT version FROM ledgers WHERE id = ? AND deleted_at IS NULL`
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(update).WithArgs(int64(15), ledgerNow, "alice", int64(1), int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	l := &ledger{ID: 1, Total: 15, Audit: entities.Audit{CreatedAt: created, CreatedBy: "bob", UpdatedBy: "bob", Version: 3}}
+	require.NoError(t, b.Update(asAlice(), l))
+	require.Equal(t, int64(4), l.Version)
+	require.Equal(t, "alice", l.UpdatedBy)
+	require.Equal(t, ledgerNow, l.UpdatedAt)
+	require.Equal(t, "bob", l.CreatedBy)
+
+	// Someone else saved version 4 first.
+	mock.ExpectExec(update).WithArgs(int64(16), ledgerNow, "alice", int64(1), int64(4)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(current).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(5))
+	stale := &ledger{ID: 1, Total: 16, Audit: entities.Audit{Version: 4}}
+	err := b.Update(asAlice(), stale)
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	require.ErrorIs(t, err, ErrConflict)
+	require.Equal(t, &ConflictError{Table: "ledgers", ID: int64(1), Expected: 4, Actual: 5}, conflict)
+	require.Equal(t, int64(4), stale.Version, "a rejected update leaves the entity alone")
+
+	// Deleted in the meantime.
+	mock.ExpectExec(update).WithArgs(int64(16), ledgerNow, "alice", int64(2), int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(current).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	require.ErrorIs(t, b.Update(asAlice(), &ledger{ID: 2, Total: 16, Audit: entities.Audit{Version: 1}}), ErrNotFound)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBase_Audit_DeleteIsSoft(t *testing.T) {
+	b, mock := newLedgerBase(t)
+	const del = `UPDATE ledgers SET deleted_at = ?, updated_at = ?, updated_by = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL`
+	mock.ExpectExec(del).WithArgs(ledgerNow, ledgerNow, "alice", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(del).WithArgs(ledgerNow, ledgerNow, "alice", 1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM ledgers WHERE id = ?`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, b.Delete(asAlice(), 1))
+	require.ErrorIs(t, b.Delete(asAlice(), 1), ErrNotFound, "already deleted")
+	require.NoError(t, b.Purge(asAlice(), 1))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+type badAudit struct {
+	ID        int64  `db:"id"`
+	DeletedAt string `db:"deleted_at"`
+}
+
+func (badAudit) TableName() string { return "bad" }
+
+func TestNewBase_PanicsOnBadAuditColumnType(t *testing.T) {
+	require.Panics(t, func() { NewBase[badAudit](nil, MySQL) })
+}
+
+type price struct {
+	Minor    int64  `db:"minor"`
+	Currency string `db:"currency"`
+}
+
+type invoice struct {
+	ID       int64 `db:"id"`
+	Net      price `db:"net_,inline"`
+	Gross    price `db:"gross_,inline"`
+	Discount price `db:",inline"`
+}
+
+func (invoice) TableName() string { return "invoices" }
+
+func TestBase_InlineStructs(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	b := NewBase[invoice](db, MySQL)
+	require.Equal(t, "id, net_minor, net_currency, gross_minor, gross_currency, minor, currency", b.Columns())
+
+	mock.ExpectQuery(`SELECT ` + b.Columns() + ` FROM invoices WHERE id = ?`).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "net_minor", "net_currency", "gross_minor", "gross_currency", "minor", "currency"}).
+			AddRow(1, 1000, "EUR", 1210, "EUR", 0, "EUR"))
+	got, err := b.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, &invoice{ID: 1, Net: price{1000, "EUR"}, Gross: price{1210, "EUR"}, Discount: price{0, "EUR"}}, got)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
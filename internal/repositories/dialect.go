@@ -0,0 +1,32 @@
+package repositories
+
+import "strconv"
+
+// Dialect describes the SQL differences between databases that Base has to care about.
+type Dialect interface {
+	// Name identifies the dialect in logs and errors.
+	Name() string
+	// Placeholder returns the bind parameter for the n-th argument, counting from 1.
+	Placeholder(n int) string
+	// Returning reports whether INSERT ... RETURNING is supported. Without it, the new
+	// primary key is read from sql.Result.LastInsertId.
+	Returning() bool
+}
+
+// Supported dialects.
+var (
+	MySQL    Dialect = mysqlDialect{}
+	Postgres Dialect = postgresDialect{}
+)
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string           { return "mysql" }
+func (mysqlDialect) Placeholder(int) string { return "?" }
+func (mysqlDialect) Returning() bool        { return false }
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string             { return "postgres" }
+func (postgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+func (postgresDialect) Returning() bool          { return true }
@@ -3,7 +3,17 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"go-boilerplate/internal/dbs"
 	"go-boilerplate/internal/entities"
+	"time"
+)
+
+// Example domain events written to the outbox.
+const (
+	ExampleAggregateType   = "example"
+	ExampleCreatedEvent    = "example.created"
+	exampleCreatedRouteKey = "example.created"
 )
 
 // ExampleRepository defines the interface for interacting with the ExampleEntity in the database.
@@ -15,35 +25,121 @@ import (
 type ExampleRepository interface {
 	GetByID(ctx context.Context, id int64) (*entities.ExampleEntity, error)
 	Create(ctx context.Context, u *entities.ExampleEntity) (int64, error)
+	// Update saves u if it still has the version it was read with, and returns a
+	// *ConflictError otherwise.
+	Update(ctx context.Context, u *entities.ExampleEntity) error
+	// Delete soft-deletes the entity; GetByID no longer returns it.
+	Delete(ctx context.Context, id int64) error
+	// CreateBatch inserts es in one transaction, with one multi-row INSERT per chunk of at
+	// most chunkSize rows, and returns their IDs in order. Either all are stored or none.
+	// The audit entries and example.created events of a chunk are written with one INSERT
+	// each as well.
+	CreateBatch(ctx context.Context, es []*entities.ExampleEntity, chunkSize int) ([]int64, error)
 }
 
+// exampleRepository stores examples in the users table:
+//
+//	CREATE TABLE users (
+//	  id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+//	  user_id      VARCHAR(64)  NOT NULL,
+//	  amount_minor BIGINT       NOT NULL,
+//	  currency     CHAR(3)      NOT NULL,
+//	  created_at   DATETIME(6)  NOT NULL,
+//	  updated_at   DATETIME(6)  NOT NULL,
+//	  deleted_at   DATETIME(6)  NULL,
+//	  created_by   VARCHAR(255) NOT NULL,
+//	  updated_by   VARCHAR(255) NOT NULL,
+//	  version      BIGINT       NOT NULL DEFAULT 1
+//	);
 type exampleRepository struct {
-	db *sql.DB
+	db     dbs.Querier
+	base   *Base[entities.ExampleEntity]
+	outbox OutboxRepository
 }
 
 // NewExampleRepository creates a new instance of ExampleRepository using the provided database connection.
 // It is responsible for interacting with the database to perform CRUD operations on ExampleEntity.
-func NewExampleRepository(db *sql.DB) ExampleRepository {
-	return &exampleRepository{db: db}
+// Create also writes an example.created event to the outbox in the same transaction.
+// With a *dbs.DB, GetByID reads from a replica and Create writes to the primary.
+// SQL is generated for dialect d. Changes are recorded in audit unless it is nil.
+func NewExampleRepository(db dbs.Querier, d Dialect, audit AuditRepository) ExampleRepository {
+	return &exampleRepository{
+		db:     db,
+		base:   NewBase[entities.ExampleEntity](db, d).WithAudit(audit, ExampleAggregateType),
+		outbox: NewOutboxRepository(db, d),
+	}
 }
 
 func (r *exampleRepository) GetByID(ctx context.Context, id int64) (*entities.ExampleEntity, error) {
-	row := r.db.QueryRowContext(ctx, `SELECT id, user_id, amount FROM users WHERE id = $1`, id)
-	var u entities.ExampleEntity
-	if err := row.Scan(&u.ID, &u.UserID, &u.Amount); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
+	return r.base.GetByID(ctx, id)
+}
+
+func (r *exampleRepository) Create(ctx context.Context, u *entities.ExampleEntity) (int64, error) {
+	ids, err := r.CreateBatch(ctx, []*entities.ExampleEntity{u}, 1)
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+func (r *exampleRepository) CreateBatch(ctx context.Context, es []*entities.ExampleEntity, chunkSize int) ([]int64, error) {
+	if len(es) == 0 {
+		return nil, nil
+	}
+	if chunkSize <= 0 {
+		chunkSize = len(es)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
 		return nil, err
 	}
-	return &u, nil
+	defer tx.Rollback()
+
+	base := r.base.WithTx(tx)
+	ids := make([]int64, 0, len(es))
+	for start := 0; start < len(es); start += chunkSize {
+		chunk := make([]*entities.ExampleEntity, 0, chunkSize)
+		for _, u := range es[start:min(start+chunkSize, len(es))] {
+			created := *u
+			chunk = append(chunk, &created)
+		}
+		chunkIDs, err := base.CreateMany(ctx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.enqueueCreated(ctx, tx, chunk); err != nil {
+			return nil, err
+		}
+		ids = append(ids, chunkIDs...)
+	}
+	return ids, tx.Commit()
 }
 
-func (r *exampleRepository) Create(ctx context.Context, u *entities.ExampleEntity) (int64, error) {
-	var id int64
-	err := r.db.QueryRowContext(ctx,
-		`INSERT INTO users (user_id, amount) VALUES ($1, $2) RETURNING id`,
-		u.UserID, u.Amount,
-	).Scan(&id)
-	return id, err
+// enqueueCreated writes the example.created events of es to the outbox in tx.
+func (r *exampleRepository) enqueueCreated(ctx context.Context, tx *sql.Tx, es []*entities.ExampleEntity) error {
+	now := time.Now().UTC()
+	events := make([]*entities.OutboxEvent, len(es))
+	for i, e := range es {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		events[i] = &entities.OutboxEvent{
+			AggregateType: ExampleAggregateType,
+			AggregateID:   e.ID,
+			EventType:     ExampleCreatedEvent,
+			RoutingKey:    exampleCreatedRouteKey,
+			Payload:       payload,
+			CreatedAt:     now,
+		}
+	}
+	return r.outbox.EnqueueMany(ctx, tx, events)
+}
+
+func (r *exampleRepository) Update(ctx context.Context, u *entities.ExampleEntity) error {
+	return r.base.Update(ctx, u)
+}
+
+func (r *exampleRepository) Delete(ctx context.Context, id int64) error {
+	return r.base.Delete(ctx, id)
 }
@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"go-boilerplate/internal/dbs"
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/utils/cache"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// exampleCacheName labels the example cache in the cache_lookups_total metric.
+const exampleCacheName = "example"
+
+// CacheOptions tunes a caching repository.
+type CacheOptions struct {
+	// TTL is how long a found entity is cached; defaults to 1m.
+	TTL time.Duration
+	// NegativeTTL is how long "not found" is cached; defaults to 5s. Keep it short: the
+	// entity may be created on another replica that cannot invalidate an in-process cache.
+	NegativeTTL time.Duration
+	// LoadTimeout bounds a shared load, which does not stop when the caller that started it
+	// gives up; defaults to 5s.
+	LoadTimeout time.Duration
+	Log         *zap.Logger
+}
+
+type cachedExampleRepository struct {
+	next  ExampleRepository
+	cache cache.Cache
+	group cache.Group
+	opts  CacheOptions
+
+	mu   sync.Mutex
+	keys map[string]*cacheKeyState // keys with a load in flight
+}
+
+// cacheKeyState tracks the invalidations of a key while it is being loaded.
+type cacheKeyState struct {
+	gen   uint64 // bumped by every invalidation
+	loads int    // callers waiting for a load of the key
+}
+
+// NewCachedExampleRepository wraps next with a read-through cache for GetByID.
+// Concurrent misses for the same ID are collapsed into one load, which reads from the
+// primary so that a lagging replica cannot put a stale row back right after an
+// invalidation. A load that overlaps an invalidation of its key returns its row but does
+// not cache it, and callers arriving after the invalidation start a new load. Loads are
+// not cancelled with the caller that started them, but are bounded by LoadTimeout.
+// Create, CreateBatch, Update and Delete invalidate the entries of the entities they
+// change. Cache failures are logged and fall through to next.
+func NewCachedExampleRepository(next ExampleRepository, c cache.Cache, opts CacheOptions) ExampleRepository {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = 5 * time.Second
+	}
+	if opts.LoadTimeout <= 0 {
+		opts.LoadTimeout = 5 * time.Second
+	}
+	if opts.Log == nil {
+		opts.Log = zap.NewNop()
+	}
+	return &cachedExampleRepository{next: next, cache: c, opts: opts, keys: map[string]*cacheKeyState{}}
+}
+
+func exampleCacheKey(id string) string { return "example:" + id }
+
+func (r *cachedExampleRepository) GetByID(ctx context.Context, id int64) (*entities.ExampleEntity, error) {
+	key := exampleCacheKey(strconv.FormatInt(id, 10))
+	b, ok, err := r.cache.Get(ctx, key)
+	switch {
+	case err != nil:
+		cache.Observe(exampleCacheName, cache.Error)
+		r.opts.Log.Warn("cache get failed", zap.String("key", key), zap.Error(err))
+	case ok:
+		var e *entities.ExampleEntity
+		if err := json.Unmarshal(b, &e); err == nil {
+			cache.Observe(exampleCacheName, cache.Hit)
+			return e, nil
+		}
+		r.opts.Log.Warn("dropping undecodable cache entry", zap.String("key", key), zap.Error(err))
+		cache.Observe(exampleCacheName, cache.Miss)
+	default:
+		cache.Observe(exampleCacheName, cache.Miss)
+	}
+
+	gen := r.beginLoad(key)
+	defer r.endLoad(key)
+	v, err, _ := r.group.Do(key+"@"+strconv.FormatUint(gen, 10), func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.opts.LoadTimeout)
+		defer cancel()
+		e, err := r.next.GetByID(dbs.WithPrimary(ctx), id)
+		if err != nil {
+			return nil, err
+		}
+		r.store(ctx, key, gen, e)
+		return e, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	e, _ := v.(*entities.ExampleEntity)
+	if e == nil {
+		return nil, nil
+	}
+	cp := *e // callers sharing a load must not share the entity
+	return &cp, nil
+}
+
+// store caches e, as loaded for generation gen of key, unless key was invalidated since.
+// The generation is checked again after the write: an invalidation that ran in between
+// may have deleted the entry before it was written.
+func (r *cachedExampleRepository) store(ctx context.Context, key string, gen uint64, e *entities.ExampleEntity) {
+	if r.invalidatedSince(key, gen) {
+		return
+	}
+	ttl := r.opts.NegativeTTL
+	if e != nil {
+		ttl = r.opts.TTL
+	}
+	b, err := json.Marshal(e) // "null" for a miss
+	if err != nil {
+		return
+	}
+	if err := r.cache.Set(ctx, key, b, ttl); err != nil {
+		r.opts.Log.Warn("cache set failed", zap.String("key", key), zap.Error(err))
+		return
+	}
+	if r.invalidatedSince(key, gen) {
+		if err := r.cache.Delete(ctx, key); err != nil {
+			r.opts.Log.Warn("cache invalidation failed", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// beginLoad registers a caller waiting for a load of key and returns the current
+// generation of key. Every beginLoad must be followed by endLoad.
+func (r *cachedExampleRepository) beginLoad(key string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.keys[key]
+	if !ok {
+		st = &cacheKeyState{}
+		r.keys[key] = st
+	}
+	st.loads++
+	return st.gen
+}
+
+func (r *cachedExampleRepository) endLoad(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if st := r.keys[key]; st != nil {
+		if st.loads--; st.loads == 0 {
+			delete(r.keys, key)
+		}
+	}
+}
+
+func (r *cachedExampleRepository) invalidatedSince(key string, gen uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.keys[key]
+	return st != nil && st.gen != gen
+}
+
+func (r *cachedExampleRepository) Create(ctx context.Context, u *entities.ExampleEntity) (int64, error) {
+	id, err := r.next.Create(ctx, u)
+	if err == nil {
+		// Drop a cached "not found" for the new ID.
+		r.invalidate(ctx, strconv.FormatInt(id, 10))
+	}
+	return id, err
+}
+
+func (r *cachedExampleRepository) CreateBatch(ctx context.Context, es []*entities.ExampleEntity, chunkSize int) ([]int64, error) {
+	ids, err := r.next.CreateBatch(ctx, es, chunkSize)
+	for _, id := range ids {
+		r.invalidate(ctx, strconv.FormatInt(id, 10))
+	}
+	return ids, err
+}
+
+func (r *cachedExampleRepository) Update(ctx context.Context, u *entities.ExampleEntity) error {
+	err := r.next.Update(ctx, u)
+	// Also on a conflict: the cached copy is older than the row that won.
+	r.invalidate(ctx, u.ID)
+	return err
+}
+
+func (r *cachedExampleRepository) Delete(ctx context.Context, id int64) error {
+	err := r.next.Delete(ctx, id)
+	r.invalidate(ctx, strconv.FormatInt(id, 10))
+	return err
+}
+
+func (r *cachedExampleRepository) invalidate(ctx context.Context, id string) {
+	key := exampleCacheKey(id)
+	r.mu.Lock()
+	if st := r.keys[key]; st != nil {
+		st.gen++
+	}
+	r.mu.Unlock()
+	if err := r.cache.Delete(ctx, key); err != nil {
+		r.opts.Log.Warn("cache invalidation failed", zap.String("key", key), zap.Error(err))
+	}
+}
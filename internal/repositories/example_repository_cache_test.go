@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/repositories/_mock"
+	"go-boilerplate/internal/utils/cache"
+)
+
+func TestCachedExampleRepository_ReadsThrough(t *testing.T) {
+	var loads atomic.Int32
+	next := &_mock.MockExampleRepository{
+		GetByIDFunc: func(ctx context.Context, id int64) (*entities.ExampleEntity, error) {
+			loads.Add(1)
+			if id == 404 {
+				return nil, nil
+			}
+			return &entities.ExampleEntity{ID: "1", UserID: "u1", Amount: idr(5)}, nil
+		},
+	}
+	repo := NewCachedExampleRepository(next, cache.NewMemoryCache(10), CacheOptions{})
+	ctx := context.Background()
+	hits, misses := cache.Lookups(exampleCacheName, cache.Hit), cache.Lookups(exampleCacheName, cache.Miss)
+
+	for range 3 {
+		e, err := repo.GetByID(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, "u1", e.UserID)
+	}
+	for range 2 {
+		e, err := repo.GetByID(ctx, 404)
+		require.NoError(t, err)
+		require.Nil(t, e, "not found is cached too")
+	}
+
+	require.Equal(t, int32(2), loads.Load())
+	require.Equal(t, hits+3, cache.Lookups(exampleCacheName, cache.Hit))
+	require.Equal(t, misses+2, cache.Lookups(exampleCacheName, cache.Miss))
+}
+
+func TestCachedExampleRepository_CollapsesConcurrentMisses(t *testing.T) {
+	var loads atomic.Int32
+	release := make(chan struct{})
+	next := &_mock.MockExampleRepository{
+		GetByIDFunc: func(ctx context.Context, id int64) (*entities.ExampleEntity, error) {
+			loads.Add(1)
+			<-release
+			return &entities.ExampleEntity{ID: "1", Amount: idr(5)}, nil
+		},
+	}
+	repo := NewCachedExampleRepository(next, cache.NewMemoryCache(10), CacheOptions{})
+
+	var wg sync.WaitGroup
+	got := make([]*entities.ExampleEntity, 8)
+	for i := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i], _ = repo.GetByID(context.Background(), 1)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond) // let every caller miss and queue behind the first load
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), loads.Load())
+	got[0].Amount = idr(99)
+	require.Equal(t, idr(5), got[1].Amount, "callers get their own copy")
+}
+
+func TestCachedExampleRepository_InvalidatesOnWrites(t *testing.T) {
+	stored := map[int64]*entities.ExampleEntity{}
+	next := &_mock.MockExampleRepository{
+		GetByIDFunc: func(ctx context.Context, id int64) (*entities.ExampleEntity, error) {
+			if e, ok := stored[id]; ok {
+				cp := *e
+				return &cp, nil
+			}
+			return nil, nil
+		},
+		CreateFunc: func(ctx context.Context, u *entities.ExampleEntity) (int64, error) {
+			stored[1] = &entities.ExampleEntity{ID: "1", Amount: u.Amount}
+			return 1, nil
+		},
+		UpdateFunc: func(ctx context.Context, u *entities.ExampleEntity) error {
+			stored[1].Amount = u.Amount
+			return nil
+		},
+		DeleteFunc: func(ctx context.Context, id int64) error {
+			delete(stored, id)
+			return nil
+		},
+		CreateBatchFunc: func(ctx context.Context, es []*entities.ExampleEntity, chunkSize int) ([]int64, error) {
+			stored[2] = &entities.ExampleEntity{ID: "2", Amount: es[0].Amount}
+			return []int64{2}, nil
+		},
+	}
+	repo := NewCachedExampleRepository(next, cache.NewMemoryCache(10), CacheOptions{})
+	ctx := context.Background()
+
+	e, _ := repo.GetByID(ctx, 1)
+	require.Nil(t, e) // caches "not found"
+	e, _ = repo.GetByID(ctx, 2)
+	require.Nil(t, e)
+
+	_, err := repo.CreateBatch(ctx, []*entities.ExampleEntity{{Amount: idr(4)}}, 10)
+	require.NoError(t, err)
+	e, _ = repo.GetByID(ctx, 2)
+	require.Equal(t, idr(4), e.Amount)
+
+	_, err = repo.Create(ctx, &entities.ExampleEntity{Amount: idr(5)})
+	require.NoError(t, err)
+	e, _ = repo.GetByID(ctx, 1)
+	require.Equal(t, idr(5), e.Amount)
+
+	require.NoError(t, repo.Update(ctx, &entities.ExampleEntity{ID: "1", Amount: idr(6)}))
+	e, _ = repo.GetByID(ctx, 1)
+	require.Equal(t, idr(6), e.Amount)
+
+	require.NoError(t, repo.Delete(ctx, 1))
+	e, _ = repo.GetByID(ctx, 1)
+	require.Nil(t, e)
+}
+
+func TestCachedExampleRepository_StaleLoadIsNotCached(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		amount  = idr(5)
+		loads   atomic.Int32
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	next := &_mock.MockExampleRepository{
+		GetByIDFunc: func(ctx context.Context, id int64) (*entities.ExampleEntity, error) {
+			mu.Lock()
+			e := &entities.ExampleEntity{ID: "1", Amount: amount}
+			mu.Unlock()
+			if loads.Add(1) == 1 {
+				close(started)
+				<-release // the row read is now outdated
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return e, nil
+		},
+		UpdateFunc: func(ctx context.Context, u *entities.ExampleEntity) error {
+			mu.Lock()
+			amount = u.Amount
+			mu.Unlock()
+			return nil
+		},
+	}
+	repo := NewCachedExampleRepository(next, cache.NewMemoryCache(10), CacheOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stale := make(chan *entities.ExampleEntity)
+	go func() {
+		e, _ := repo.GetByID(ctx, 1)
+		stale <- e
+	}()
+	<-started
+	require.NoError(t, repo.Update(context.Background(), &entities.ExampleEntity{ID: "1", Amount: idr(6)}))
+
+	e, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, idr(6), e.Amount, "callers after the update do not join the stale load")
+
+	cancel() // the load is shared: it outlives the caller that started it
+	close(release)
+	e = <-stale
+	require.NotNil(t, e)
+	require.Equal(t, idr(5), e.Amount)
+
+	e, err = repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, idr(6), e.Amount, "the stale load did not overwrite the cache")
+	require.Equal(t, int32(2), loads.Load())
+}
+
+// brokenCache fails every operation.
+type brokenCache struct{}
+
+func (brokenCache) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("down")
+}
+func (brokenCache) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("down")
+}
+func (brokenCache) Delete(context.Context, ...string) error { return errors.New("down") }
+
+func TestCachedExampleRepository_FallsThroughWhenCacheFails(t *testing.T) {
+	next := &_mock.MockExampleRepository{
+		GetByIDFunc: func(ctx context.Context, id int64) (*entities.ExampleEntity, error) {
+			return &entities.ExampleEntity{ID: "1", Amount: idr(5)}, nil
+		},
+	}
+	repo := NewCachedExampleRepository(next, brokenCache{}, CacheOptions{})
+	errorsBefore := cache.Lookups(exampleCacheName, cache.Error)
+
+	e, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, idr(5), e.Amount)
+	require.NoError(t, repo.Delete(context.Background(), 1))
+	require.Equal(t, errorsBefore+1, cache.Lookups(exampleCacheName, cache.Error))
+}
+
+func idr(minor int64) entities.Money {
+	return entities.Money{Minor: minor, Currency: "IDR"}
+}
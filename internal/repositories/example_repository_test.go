@@ -3,7 +3,9 @@ package repositories
 import (
     "context"
     "database/sql"
+    "database/sql/driver"
     "testing"
+    "time"
 
     "github.com/DATA-DOG/go-sqlmock"
     "github.com/stretchr/testify/require"
@@ -16,11 +18,11 @@ func TestExampleRepository_GetByID_Success(t *testing.T) {
     require.NoError(t, err)
     defer db.Close()
 
-    repo := NewExampleRepository(db)
+    repo := NewExampleRepository(db, MySQL, nil)
 
-    rows := sqlmock.NewRows([]string{"id", "user_id", "amount"}).
-        AddRow("42", "user1", int64(500))
-    mock.ExpectQuery(`SELECT id, user_id, amount FROM users WHERE id = \$1`).
+    rows := sqlmock.NewRows([]string{"id", "user_id", "amount_minor", "currency", "created_at", "updated_at", "deleted_at", "created_by", "updated_by", "version"}).
+        AddRow("42", "user1", int64(500), "IDR", time.Time{}, time.Time{}, nil, "alice", "alice", int64(1))
+    mock.ExpectQuery(`SELECT id, user_id, amount_minor, currency, created_at, updated_at, deleted_at, created_by, updated_by, version FROM users WHERE id = \? AND deleted_at IS NULL`).
         WithArgs(int64(42)).
         WillReturnRows(rows)
 
@@ -28,7 +30,7 @@ func TestExampleRepository_GetByID_Success(t *testing.T) {
     require.NoError(t, err)
     require.NotNil(t, res)
     require.Equal(t, "user1", res.UserID)
-    require.Equal(t, int64(500), res.Amount)
+    require.Equal(t, entities.Money{Minor: 500, Currency: "IDR"}, res.Amount)
     require.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -37,9 +39,9 @@ func TestExampleRepository_GetByID_NoRows(t *testing.T) {
     require.NoError(t, err)
     defer db.Close()
 
-    repo := NewExampleRepository(db)
+    repo := NewExampleRepository(db, MySQL, nil)
 
-    mock.ExpectQuery(`SELECT id, user_id, amount FROM users WHERE id = \$1`).
+    mock.ExpectQuery(`SELECT id, user_id, amount_minor, .* FROM users WHERE id = \? AND deleted_at IS NULL`).
         WithArgs(int64(999)).
         WillReturnError(sql.ErrNoRows)
 
@@ -54,14 +56,123 @@ func TestExampleRepository_Create(t *testing.T) {
     require.NoError(t, err)
     defer db.Close()
 
-    repo := NewExampleRepository(db)
+    repo := NewExampleRepository(db, MySQL, nil)
 
-    mock.ExpectQuery(`INSERT INTO users \(user_id, amount\) VALUES \(\$1, \$2\) RETURNING id`).
-        WithArgs("userX", int64(111)).
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO users \(user_id, amount_minor, currency, created_at, updated_at, deleted_at, created_by, updated_by, version\) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?, \?\)$`).
+        WithArgs("userX", int64(111), "IDR", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "system", "system", int64(1)).
+        WillReturnResult(sqlmock.NewResult(7, 1))
+    mock.ExpectExec(`INSERT INTO outbox_events \(aggregate_type, aggregate_id, event_type, routing_key, payload, status, next_attempt_at, created_at\) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?\)$`).
+        WithArgs("example", "7", "example.created", "example.created", sqlmock.AnyArg(), "pending", sqlmock.AnyArg(), sqlmock.AnyArg()).
+        WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    id, err := repo.Create(context.Background(), &entities.ExampleEntity{UserID: "userX", Amount: entities.Money{Minor: 111, Currency: "IDR"}})
+    require.NoError(t, err)
+    require.Equal(t, int64(7), id)
+    require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExampleRepository_Create_Postgres(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    require.NoError(t, err)
+    defer db.Close()
+
+    repo := NewExampleRepository(db, Postgres, nil)
+
+    mock.ExpectBegin()
+    mock.ExpectQuery(`INSERT INTO users \(user_id, amount_minor, currency, created_at, updated_at, deleted_at, created_by, updated_by, version\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9\) RETURNING id`).
+        WithArgs("userX", int64(111), "IDR", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "system", "system", int64(1)).
         WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+    mock.ExpectQuery(`INSERT INTO outbox_events \(aggregate_type, aggregate_id, event_type, routing_key, payload, status, next_attempt_at, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\) RETURNING id$`).
+        WithArgs("example", "7", "example.created", "example.created", sqlmock.AnyArg(), "pending", sqlmock.AnyArg(), sqlmock.AnyArg()).
+        WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+    mock.ExpectCommit()
 
-    id, err := repo.Create(context.Background(), &entities.ExampleEntity{UserID: "userX", Amount: 111})
+    id, err := repo.Create(context.Background(), &entities.ExampleEntity{UserID: "userX", Amount: entities.Money{Minor: 111, Currency: "IDR"}})
     require.NoError(t, err)
     require.Equal(t, int64(7), id)
     require.NoError(t, mock.ExpectationsWereMet())
-}
\ No newline at end of file
+}
+
+func TestExampleRepository_Create_OutboxFailureRollsBack(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    require.NoError(t, err)
+    defer db.Close()
+
+    repo := NewExampleRepository(db, MySQL, nil)
+
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO users`).
+        WillReturnResult(sqlmock.NewResult(8, 1))
+    mock.ExpectExec(`INSERT INTO outbox_events`).
+        WillReturnError(sql.ErrConnDone)
+    mock.ExpectRollback()
+
+    _, err = repo.Create(context.Background(), &entities.ExampleEntity{UserID: "userY", Amount: entities.Money{Minor: 1, Currency: "IDR"}})
+    require.ErrorIs(t, err, sql.ErrConnDone)
+    require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExampleRepository_CreateBatch_ChunksInOneTransaction(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    require.NoError(t, err)
+    defer db.Close()
+
+    repo := NewExampleRepository(db, MySQL, nil)
+
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO users \(.*\) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?, \?\), \(\?, \?, \?, \?, \?, \?, \?, \?, \?\)$`).
+        WillReturnResult(sqlmock.NewResult(10, 2))
+    any6 := []driver.Value{sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()}
+    outboxArgs := func(ids ...string) []driver.Value {
+        var args []driver.Value
+        for _, id := range ids {
+            args = append(append(args, "example", id), any6...)
+        }
+        return args
+    }
+    mock.ExpectExec(`INSERT INTO outbox_events \(.*\) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?\), \(\?, \?, \?, \?, \?, \?, \?, \?\)$`).
+        WithArgs(outboxArgs("10", "11")...).
+        WillReturnResult(sqlmock.NewResult(1, 2))
+    mock.ExpectExec(`INSERT INTO users \(.*\) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?, \?\)$`).
+        WithArgs("c", int64(3), "IDR", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "system", "system", int64(1)).
+        WillReturnResult(sqlmock.NewResult(12, 1))
+    mock.ExpectExec(`INSERT INTO outbox_events \(.*\) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?\)$`).
+        WithArgs(outboxArgs("12")...).
+        WillReturnResult(sqlmock.NewResult(3, 1))
+    mock.ExpectCommit()
+
+    in := []*entities.ExampleEntity{
+        {UserID: "a", Amount: entities.Money{Minor: 1, Currency: "IDR"}},
+        {UserID: "b", Amount: entities.Money{Minor: 2, Currency: "IDR"}},
+        {UserID: "c", Amount: entities.Money{Minor: 3, Currency: "IDR"}},
+    }
+    ids, err := repo.CreateBatch(context.Background(), in, 2)
+    require.NoError(t, err)
+    require.Equal(t, []int64{10, 11, 12}, ids)
+    require.Empty(t, in[0].ID, "the caller's entities are not modified")
+    require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExampleRepository_CreateBatch_FailedChunkRollsBackEverything(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    require.NoError(t, err)
+    defer db.Close()
+
+    repo := NewExampleRepository(db, MySQL, nil)
+
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(10, 1))
+    mock.ExpectExec(`INSERT INTO outbox_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`INSERT INTO users`).WillReturnError(sql.ErrConnDone)
+    mock.ExpectRollback()
+
+    ids, err := repo.CreateBatch(context.Background(), []*entities.ExampleEntity{
+        {UserID: "a", Amount: entities.Money{Minor: 1, Currency: "IDR"}},
+        {UserID: "b", Amount: entities.Money{Minor: 2, Currency: "IDR"}},
+    }, 1)
+    require.ErrorIs(t, err, sql.ErrConnDone)
+    require.Nil(t, ids)
+    require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-boilerplate/internal/dbs"
+	"go-boilerplate/internal/entities"
+	"strings"
+	"time"
+)
+
+// OutboxRepository defines the interface for the transactional outbox.
+// Events are enqueued inside the caller's transaction, so they are stored if and only if
+// the business change commits, and are later handed to the relay in locked batches.
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, tx *sql.Tx, e *entities.OutboxEvent) error
+	// EnqueueMany enqueues es in tx with multi-row INSERTs, as few as MaxBindParams allows,
+	// and sets their IDs.
+	EnqueueMany(ctx context.Context, tx *sql.Tx, es []*entities.OutboxEvent) error
+	// ProcessBatch locks up to limit pending events that are due at now (skipping rows locked
+	// by other relays), calls fn with them and persists the Status, Attempts, NextAttemptAt,
+	// LastError and SentAt that fn set on each event. It returns the number of events processed.
+	ProcessBatch(ctx context.Context, now time.Time, limit int, fn func(events []entities.OutboxEvent)) (int, error)
+	// Prune deletes sent events older than before.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+// outboxRepository stores events in the outbox_events table:
+//
+//	CREATE TABLE outbox_events (
+//	  id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+//	  aggregate_type  VARCHAR(64)  NOT NULL,
+//	  aggregate_id    VARCHAR(64)  NOT NULL,
+//	  event_type      VARCHAR(128) NOT NULL,
+//	  routing_key     VARCHAR(255) NOT NULL,
+//	  payload         JSON         NOT NULL,
+//	  status          VARCHAR(16)  NOT NULL DEFAULT 'pending',
+//	  attempts        INT          NOT NULL DEFAULT 0,
+//	  next_attempt_at DATETIME(6)  NOT NULL,
+//	  last_error      TEXT         NULL,
+//	  created_at      DATETIME(6)  NOT NULL,
+//	  sent_at         DATETIME(6)  NULL,
+//	  INDEX idx_outbox_events_due (status, next_attempt_at),
+//	  INDEX idx_outbox_events_sent_at (sent_at)
+//	);
+type outboxRepository struct {
+	db      dbs.Querier
+	dialect Dialect
+}
+
+// NewOutboxRepository creates a new OutboxRepository backed by the outbox_events table.
+// SQL is generated for dialect d, which must match the repositories whose transactions
+// the events are enqueued in.
+func NewOutboxRepository(db dbs.Querier, d Dialect) OutboxRepository {
+	return &outboxRepository{db: db, dialect: d}
+}
+
+// binds returns the placeholders for n arguments, starting at argument from.
+func binds(d Dialect, from, n int) string {
+	marks := make([]string, n)
+	for i := range marks {
+		marks[i] = d.Placeholder(from + i)
+	}
+	return strings.Join(marks, ", ")
+}
+
+// outboxRowsPerInsert keeps an EnqueueMany statement within MaxBindParams; an event binds
+// 8 columns.
+const outboxRowsPerInsert = MaxBindParams / 8
+
+func (r *outboxRepository) Enqueue(ctx context.Context, tx *sql.Tx, e *entities.OutboxEvent) error {
+	return r.EnqueueMany(ctx, tx, []*entities.OutboxEvent{e})
+}
+
+func (r *outboxRepository) EnqueueMany(ctx context.Context, tx *sql.Tx, es []*entities.OutboxEvent) error {
+	for start := 0; start < len(es); start += outboxRowsPerInsert {
+		if err := r.insert(ctx, tx, es[start:min(start+outboxRowsPerInsert, len(es))]); err != nil {
+			return fmt.Errorf("enqueue outbox event: %w", err)
+		}
+	}
+	return nil
+}
+
+// insert writes es with a single INSERT. Without RETURNING, the IDs are derived from
+// LastInsertId like in Base.CreateMany.
+func (r *outboxRepository) insert(ctx context.Context, tx *sql.Tx, es []*entities.OutboxEvent) error {
+	var (
+		rows = make([]string, len(es))
+		args = make([]any, 0, 8*len(es))
+	)
+	now := time.Now().UTC()
+	for i, e := range es {
+		if e.Status == "" {
+			e.Status = entities.OutboxStatusPending
+		}
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = now
+		}
+		if e.NextAttemptAt.IsZero() {
+			e.NextAttemptAt = e.CreatedAt
+		}
+		rows[i] = `(` + binds(r.dialect, len(args)+1, 8) + `)`
+		args = append(args, e.AggregateType, e.AggregateID, e.EventType, e.RoutingKey, e.Payload, e.Status, e.NextAttemptAt, e.CreatedAt)
+	}
+	q := `INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, routing_key, payload, status, next_attempt_at, created_at) VALUES ` +
+		strings.Join(rows, ", ")
+
+	if r.dialect.Returning() {
+		// Rows come back in VALUES order
+		res, err := tx.QueryContext(ctx, q+` RETURNING id`, args...)
+		if err != nil {
+			return err
+		}
+		defer res.Close()
+		n := 0
+		for ; res.Next(); n++ {
+			if n == len(es) {
+				return fmt.Errorf("insert returned more ids than events")
+			}
+			if err := res.Scan(&es[n].ID); err != nil {
+				return err
+			}
+		}
+		if err := res.Err(); err != nil {
+			return err
+		}
+		if n != len(es) {
+			return fmt.Errorf("insert returned %d ids for %d events", n, len(es))
+		}
+		return nil
+	}
+	res, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	first, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for i, e := range es {
+		e.ID = first + int64(i)
+	}
+	return nil
+}
+
+func (r *outboxRepository) ProcessBatch(ctx context.Context, now time.Time, limit int, fn func(events []entities.OutboxEvent)) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	ph := r.dialect.Placeholder
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, aggregate_type, aggregate_id, event_type, routing_key, payload, attempts, created_at
+		   FROM outbox_events
+		  WHERE status = `+ph(1)+` AND next_attempt_at <= `+ph(2)+`
+		  ORDER BY id
+		  LIMIT `+ph(3)+`
+		  FOR UPDATE SKIP LOCKED`,
+		entities.OutboxStatusPending, now, limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+	var events []entities.OutboxEvent
+	for rows.Next() {
+		e := entities.OutboxEvent{Status: entities.OutboxStatusPending}
+		if err := rows.Scan(&e.ID, &e.AggregateType, &e.AggregateID, &e.EventType, &e.RoutingKey, &e.Payload, &e.Attempts, &e.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	fn(events)
+
+	for _, e := range events {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE outbox_events SET status = `+ph(1)+`, attempts = `+ph(2)+`, next_attempt_at = `+ph(3)+
+				`, last_error = `+ph(4)+`, sent_at = `+ph(5)+` WHERE id = `+ph(6),
+			e.Status, e.Attempts, e.NextAttemptAt, e.LastError, e.SentAt, e.ID,
+		); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), tx.Commit()
+}
+
+func (r *outboxRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM outbox_events WHERE status = `+r.dialect.Placeholder(1)+` AND sent_at < `+r.dialect.Placeholder(2),
+		entities.OutboxStatusSent, before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"go-boilerplate/internal/entities"
+)
+
+func TestOutboxRepository_Postgres(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewOutboxRepository(db, Postgres)
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, routing_key, payload, status, next_attempt_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`).
+		WithArgs("example", "7", "example.created", "example.created", []byte(`{}`), entities.OutboxStatusPending, now, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(3)))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	e := &entities.OutboxEvent{AggregateType: "example", AggregateID: "7", EventType: "example.created", RoutingKey: "example.created", Payload: []byte(`{}`), CreatedAt: now}
+	require.NoError(t, repo.Enqueue(context.Background(), tx, e))
+	require.NoError(t, tx.Commit())
+	require.Equal(t, int64(3), e.ID)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, aggregate_type, aggregate_id, event_type, routing_key, payload, attempts, created_at
+		   FROM outbox_events
+		  WHERE status = $1 AND next_attempt_at <= $2
+		  ORDER BY id
+		  LIMIT $3
+		  FOR UPDATE SKIP LOCKED`).WithArgs(entities.OutboxStatusPending, now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "aggregate_type", "aggregate_id", "event_type", "routing_key", "payload", "attempts", "created_at"}).
+			AddRow(int64(3), "example", "7", "example.created", "example.created", []byte(`{}`), 0, now))
+	mock.ExpectExec(`UPDATE outbox_events SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, sent_at = $5 WHERE id = $6`).
+		WithArgs(entities.OutboxStatusSent, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), &now, int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := repo.ProcessBatch(context.Background(), now, 10, func(events []entities.OutboxEvent) {
+		for i := range events {
+			events[i].Status, events[i].Attempts, events[i].SentAt = entities.OutboxStatusSent, 1, &now
+		}
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	mock.ExpectExec(`DELETE FROM outbox_events WHERE status = $1 AND sent_at < $2`).
+		WithArgs(entities.OutboxStatusSent, now).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	pruned, err := repo.Prune(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), pruned)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_EnqueueManyUsesOneInsert(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewOutboxRepository(db, Postgres)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, routing_key, payload, status, next_attempt_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8), ($9, $10, $11, $12, $13, $14, $15, $16) RETURNING id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(5)).AddRow(int64(6)))
+	mock.ExpectCommit()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	events := []*entities.OutboxEvent{{AggregateID: "1"}, {AggregateID: "2"}}
+	require.NoError(t, repo.EnqueueMany(context.Background(), tx, events))
+	require.NoError(t, tx.Commit())
+	require.Equal(t, int64(5), events[0].ID)
+	require.Equal(t, int64(6), events[1].ID)
+	require.Equal(t, entities.OutboxStatusPending, events[1].Status)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
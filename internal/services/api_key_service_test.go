@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/configs"
+	apikeydtos "go-boilerplate/internal/dtos/api_key_dtos"
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/repositories/_mock"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newMintingRepo(stored **entities.APIKeyEntity, touched *int) *_mock.MockAPIKeyRepository {
+	return &_mock.MockAPIKeyRepository{
+		CreateFunc: func(ctx context.Context, k *entities.APIKeyEntity) (int64, error) {
+			k.ID = 9
+			*stored = k
+			return 9, nil
+		},
+		GetByPrefixFunc: func(ctx context.Context, prefix string) (*entities.APIKeyEntity, error) {
+			if *stored == nil || (*stored).Prefix != prefix {
+				return nil, nil
+			}
+			cp := **stored
+			return &cp, nil
+		},
+		TouchLastUsedFunc: func(ctx context.Context, id int64, at time.Time) error {
+			*touched++
+			return nil
+		},
+	}
+}
+
+func TestAPIKeyService_MintThenAuthenticate(t *testing.T) {
+	var stored *entities.APIKeyEntity
+	var touched int
+	svc := NewAPIKeyService(newMintingRepo(&stored, &touched), zap.NewNop(), configs.Config{}, validator.New())
+
+	minted, err := svc.Mint(context.Background(), apikeydtos.MintAPIKeyDTO{
+		Name:               "billing-sync",
+		Scopes:             []string{"example:read"},
+		RateLimitPerMinute: 60,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(9), minted.ID)
+	require.True(t, strings.HasPrefix(minted.Key, "ak_"+minted.Prefix+"_"))
+
+	// Only the hash is persisted.
+	require.NotContains(t, stored.Hash, minted.Key)
+	require.Equal(t, HashAPIKey(minted.Key), stored.Hash)
+
+	k, err := svc.Authenticate(context.Background(), minted.Key)
+	require.NoError(t, err)
+	require.Equal(t, "billing-sync", k.Name)
+	require.Equal(t, []string{"example:read"}, k.Scopes)
+	require.Equal(t, 1, touched)
+}
+
+func TestAPIKeyService_AuthenticateRejects(t *testing.T) {
+	var stored *entities.APIKeyEntity
+	var touched int
+	svc := NewAPIKeyService(newMintingRepo(&stored, &touched), zap.NewNop(), configs.Config{}, validator.New())
+
+	minted, err := svc.Mint(context.Background(), apikeydtos.MintAPIKeyDTO{Name: "k"})
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name   string
+		key    string
+		mutate func(*entities.APIKeyEntity)
+	}{
+		{"malformed", "not-a-key", nil},
+		{"unknown prefix", "ak_ffffffffffff_secret", nil},
+		{"wrong secret", minted.Key[:len(minted.Key)-1] + "x", nil},
+		{"revoked", minted.Key, func(k *entities.APIKeyEntity) { k.RevokedAt = &past }},
+		{"expired", minted.Key, func(k *entities.APIKeyEntity) { k.ExpiresAt = &past }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := *stored
+			if tt.mutate != nil {
+				tt.mutate(stored)
+			}
+			defer func() { *stored = saved }()
+
+			_, err := svc.Authenticate(context.Background(), tt.key)
+			require.ErrorIs(t, err, ErrInvalidAPIKey)
+		})
+	}
+	require.Zero(t, touched)
+}
+
+func TestAPIKeyService_MintValidates(t *testing.T) {
+	svc := NewAPIKeyService(&_mock.MockAPIKeyRepository{}, zap.NewNop(), configs.Config{}, validator.New())
+
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name string
+		dto  apikeydtos.MintAPIKeyDTO
+	}{
+		{"missing name", apikeydtos.MintAPIKeyDTO{}},
+		{"scope with comma", apikeydtos.MintAPIKeyDTO{Name: "k", Scopes: []string{"example:read,example:write"}}},
+		{"expired at mint", apikeydtos.MintAPIKeyDTO{Name: "k", ExpiresAt: &past}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Mint(context.Background(), tt.dto)
+			require.Error(t, err)
+		})
+	}
+
+	future := time.Now().Add(time.Hour)
+	_, err := svc.Mint(context.Background(), apikeydtos.MintAPIKeyDTO{Name: "k", Scopes: []string{"example:read"}, ExpiresAt: &future})
+	require.NoError(t, err)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	auditdtos "go-boilerplate/internal/dtos/audit_dtos"
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/repositories"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// AuditService defines the interface for reading the audit trail. Entries are written by
+// the repositories themselves, in the transaction of each change.
+type AuditService interface {
+	List(ctx context.Context, dto auditdtos.ListAuditDTO) (auditdtos.AuditPageDTO, error)
+}
+
+type auditService struct {
+	auditRepo repositories.AuditRepository
+	v         *validator.Validate
+}
+
+// NewAuditService creates a new instance of AuditService.
+func NewAuditService(r repositories.AuditRepository, v *validator.Validate) AuditService {
+	return &auditService{auditRepo: r, v: v}
+}
+
+func (s *auditService) List(ctx context.Context, o auditdtos.ListAuditDTO) (auditdtos.AuditPageDTO, error) {
+	if err := s.v.Struct(o); err != nil {
+		return auditdtos.AuditPageDTO{}, err
+	}
+	if o.Limit == 0 {
+		o.Limit = repositories.DefaultListLimit
+	}
+	entries, total, err := s.auditRepo.List(ctx, o.Entity, o.ID, o.Limit, o.Offset)
+	if err != nil {
+		return auditdtos.AuditPageDTO{}, err
+	}
+	if entries == nil {
+		entries = []entities.AuditEntry{}
+	}
+	return auditdtos.AuditPageDTO{Entries: entries, Total: total, Limit: o.Limit, Offset: o.Offset}, nil
+}
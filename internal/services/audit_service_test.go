@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	auditdtos "go-boilerplate/internal/dtos/audit_dtos"
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/repositories/_mock"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditService_List(t *testing.T) {
+	var gotLimit, gotOffset int
+	repo := &_mock.MockAuditRepository{
+		ListFunc: func(ctx context.Context, entity, entityID string, limit, offset int) ([]entities.AuditEntry, int64, error) {
+			require.Equal(t, "example", entity)
+			require.Equal(t, "42", entityID)
+			gotLimit, gotOffset = limit, offset
+			return []entities.AuditEntry{{ID: 3, Action: entities.AuditActionUpdate}}, 3, nil
+		},
+	}
+	svc := NewAuditService(repo, validator.New())
+
+	page, err := svc.List(context.Background(), auditdtos.ListAuditDTO{Entity: "example", ID: "42", Offset: 2})
+	require.NoError(t, err)
+	require.Equal(t, 50, gotLimit, "default page size")
+	require.Equal(t, 2, gotOffset)
+	require.Equal(t, int64(3), page.Total)
+	require.Len(t, page.Entries, 1)
+}
+
+func TestAuditService_List_Validation(t *testing.T) {
+	svc := NewAuditService(&_mock.MockAuditRepository{}, validator.New())
+
+	_, err := svc.List(context.Background(), auditdtos.ListAuditDTO{Entity: "example"})
+	require.Error(t, err, "id is required")
+	_, err = svc.List(context.Background(), auditdtos.ListAuditDTO{Entity: "example", ID: "1", Limit: 5000})
+	require.Error(t, err)
+
+	page, err := svc.List(context.Background(), auditdtos.ListAuditDTO{Entity: "example", ID: "1"})
+	require.NoError(t, err)
+	require.NotNil(t, page.Entries, "an empty trail is an empty list, not null")
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	exampledtos "go-boilerplate/internal/dtos/example_dtos"
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/utils/auth"
+	"go-boilerplate/internal/utils/jobs"
+	"go-boilerplate/internal/utils/metrics"
+	"io"
+
+	"go.uber.org/zap"
+)
+
+// Errors returned by the example import methods.
+var (
+	// ErrImportNotFound is returned by ImportStatus for a job that does not exist, has
+	// expired, runs on another replica or was started by someone else.
+	ErrImportNotFound = errors.New("import job not found")
+	// ErrAsyncImportsDisabled is returned when a background import is requested but the
+	// service has no job runner.
+	ErrAsyncImportsDisabled = errors.New("background imports are not enabled")
+)
+
+const (
+	exampleImportJob       = "example_import"
+	defaultImportChunkSize = 500
+)
+
+// importedRows counts imported rows by outcome: created, invalid, failed or skipped.
+var importedRows = metrics.NewCounterVec("example_import_rows_total")
+
+// ImportExamples reports rejected rows in the result. Stored rows get example.created
+// through the outbox as usual.
+func (s *exampleService) ImportExamples(ctx context.Context, q exampledtos.BatchQueryDTO, body io.Reader, contentType string) (exampledtos.BatchImportDTO, error) {
+	if err := s.v.Struct(q); err != nil {
+		return exampledtos.BatchImportDTO{}, err
+	}
+	mode := q.Mode
+	if mode == "" {
+		mode = exampledtos.BatchModeAtomic
+	}
+	// The body is read here in full: it is gone once the request returns.
+	rows, err := exampledtos.DecodeBatch(body, contentType, s.cfg.BatchMaxRows)
+	if err != nil {
+		return exampledtos.BatchImportDTO{}, err
+	}
+
+	async := s.imports != nil && len(rows) > s.cfg.BatchAsyncRows
+	if q.Async != nil {
+		async = *q.Async
+	}
+	if !async {
+		res, err := s.importRows(ctx, rows, mode, func(int) {})
+		if err != nil {
+			return exampledtos.BatchImportDTO{}, err
+		}
+		return exampledtos.BatchImportDTO{Result: &res}, nil
+	}
+	if s.imports == nil {
+		return exampledtos.BatchImportDTO{}, ErrAsyncImportsDisabled
+	}
+	j, err := s.imports.Submit(ctx, exampleImportJob, ownerOf(ctx), len(rows), func(ctx context.Context, progress func(int)) (any, error) {
+		res, err := s.importRows(ctx, rows, mode, progress)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	})
+	if err != nil {
+		return exampledtos.BatchImportDTO{}, err
+	}
+	s.log.Info("example import queued", zap.String("job_id", j.ID), zap.Int("rows", len(rows)), zap.String("mode", mode))
+	dto := batchJobDTO(j)
+	return exampledtos.BatchImportDTO{Job: &dto}, nil
+}
+
+func (s *exampleService) ImportStatus(ctx context.Context, id string) (exampledtos.BatchJobDTO, error) {
+	if s.imports == nil {
+		return exampledtos.BatchJobDTO{}, ErrImportNotFound
+	}
+	j, ok := s.imports.Get(id)
+	if !ok || j.Kind != exampleImportJob || j.Owner != ownerOf(ctx) {
+		return exampledtos.BatchJobDTO{}, ErrImportNotFound
+	}
+	return batchJobDTO(j), nil
+}
+
+// importRows validates rows and stores the valid ones. In atomic mode nothing is stored
+// unless every row is valid, and a storage error fails the whole import. In best-effort
+// mode rows are stored chunk by chunk; when a chunk fails, its rows are retried one by
+// one so that only the rows at fault are reported as failed.
+func (s *exampleService) importRows(ctx context.Context, rows []exampledtos.BatchRow, mode string, progress func(done int)) (exampledtos.BatchResultDTO, error) {
+	res := exampledtos.BatchResultDTO{Mode: mode, Total: len(rows), Rows: make([]exampledtos.BatchRowResultDTO, len(rows))}
+	var valid []int // indexes of the valid rows
+	for i, r := range rows {
+		res.Rows[i].Row = r.Row
+		err := r.Err
+		if err == nil {
+			err = s.v.Struct(r.Example)
+		}
+		if err != nil {
+			res.Rows[i].Status, res.Rows[i].Error = exampledtos.RowInvalid, err.Error()
+			continue
+		}
+		valid = append(valid, i)
+	}
+	chunkSize := s.cfg.BatchChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultImportChunkSize
+	}
+	entitiesOf := func(idx []int) []*entities.ExampleEntity {
+		es := make([]*entities.ExampleEntity, len(idx))
+		for k, i := range idx {
+			es[k] = &entities.ExampleEntity{UserID: rows[i].Example.UserID, Amount: rows[i].Example.Amount}
+		}
+		return es
+	}
+
+	switch {
+	case mode == exampledtos.BatchModeAtomic && len(valid) < len(rows):
+		for _, i := range valid {
+			res.Rows[i].Status = exampledtos.RowSkipped
+		}
+	case mode == exampledtos.BatchModeAtomic:
+		ids, err := s.exampleRepo.CreateBatch(ctx, entitiesOf(valid), chunkSize)
+		if err != nil {
+			return exampledtos.BatchResultDTO{}, err
+		}
+		for k, i := range valid {
+			res.Rows[i].Status, res.Rows[i].ID = exampledtos.RowCreated, ids[k]
+		}
+	default:
+		invalid := len(rows) - len(valid)
+		for start := 0; start < len(valid); start += chunkSize {
+			chunk := valid[start:min(start+chunkSize, len(valid))]
+			s.importChunk(ctx, chunk, entitiesOf(chunk), res.Rows)
+			progress(invalid + start + len(chunk))
+		}
+	}
+
+	for _, r := range res.Rows {
+		switch r.Status {
+		case exampledtos.RowCreated:
+			res.Created++
+		case exampledtos.RowInvalid:
+			res.Invalid++
+		case exampledtos.RowFailed:
+			res.Failed++
+		case exampledtos.RowSkipped:
+			res.Skipped++
+		}
+		importedRows.Inc(r.Status)
+	}
+	progress(len(rows))
+	s.log.Info("example import finished",
+		zap.String("mode", mode), zap.Int("rows", res.Total), zap.Int("created", res.Created),
+		zap.Int("invalid", res.Invalid), zap.Int("failed", res.Failed), zap.Int("skipped", res.Skipped))
+	return res, nil
+}
+
+// importChunk stores the rows at idx, whose entities are es, and records their outcome in out.
+func (s *exampleService) importChunk(ctx context.Context, idx []int, es []*entities.ExampleEntity, out []exampledtos.BatchRowResultDTO) {
+	ids, err := s.exampleRepo.CreateBatch(ctx, es, len(es))
+	if err == nil {
+		for k, i := range idx {
+			out[i].Status, out[i].ID = exampledtos.RowCreated, ids[k]
+		}
+		return
+	}
+	if len(es) > 1 && ctx.Err() == nil {
+		s.log.Warn("example import chunk failed, retrying row by row", zap.Int("rows", len(es)), zap.Error(err))
+	}
+	for k, i := range idx {
+		if ctx.Err() != nil {
+			out[i].Status, out[i].Error = exampledtos.RowFailed, ctx.Err().Error()
+			continue
+		}
+		if len(es) == 1 {
+			out[i].Status, out[i].Error = exampledtos.RowFailed, err.Error()
+			continue
+		}
+		id, err := s.exampleRepo.Create(ctx, es[k])
+		if err != nil {
+			out[i].Status, out[i].Error = exampledtos.RowFailed, err.Error()
+			continue
+		}
+		out[i].Status, out[i].ID = exampledtos.RowCreated, id
+	}
+}
+
+// ownerOf returns the subject that import jobs started with ctx belong to; empty when
+// the caller is not authenticated.
+func ownerOf(ctx context.Context) string {
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		return p.Subject
+	}
+	return ""
+}
+
+func batchJobDTO(j jobs.Job) exampledtos.BatchJobDTO {
+	dto := exampledtos.BatchJobDTO{
+		ID:         j.ID,
+		Status:     string(j.Status),
+		Done:       j.Done,
+		Total:      j.Total,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.FinishedAt,
+	}
+	if res, ok := j.Result.(exampledtos.BatchResultDTO); ok {
+		dto.Result = &res
+	}
+	return dto
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/configs"
+	exampledtos "go-boilerplate/internal/dtos/example_dtos"
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/repositories/_mock"
+	"go-boilerplate/internal/utils/auth"
+	"go-boilerplate/internal/utils/jobs"
+	"go-boilerplate/internal/utils/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const importCSV = "user_id,amount,currency\nu1,1.00,USD\nu2,2.00,USD\nu3,3.00,USD\n"
+
+// sequenceRepo hands out increasing IDs and fails to store users listed in bad.
+func sequenceRepo(bad ...string) *_mock.MockExampleRepository {
+	var (
+		mu   sync.Mutex
+		next int64
+	)
+	store := func(u *entities.ExampleEntity) (int64, error) {
+		for _, b := range bad {
+			if u.UserID == b {
+				return 0, errors.New("duplicate user " + b)
+			}
+		}
+		next++
+		return next, nil
+	}
+	return &_mock.MockExampleRepository{
+		CreateFunc: func(ctx context.Context, u *entities.ExampleEntity) (int64, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return store(u)
+		},
+		CreateBatchFunc: func(ctx context.Context, es []*entities.ExampleEntity, chunkSize int) ([]int64, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, u := range es {
+				for _, b := range bad {
+					if u.UserID == b {
+						return nil, errors.New("duplicate user " + b)
+					}
+				}
+			}
+			ids := make([]int64, len(es))
+			for i, u := range es {
+				ids[i], _ = store(u)
+			}
+			return ids, nil
+		},
+	}
+}
+
+func importOf(t *testing.T, svc ExampleService, ctx context.Context, q exampledtos.BatchQueryDTO, body, contentType string) exampledtos.BatchResultDTO {
+	t.Helper()
+	out, err := svc.ImportExamples(ctx, q, strings.NewReader(body), contentType)
+	require.NoError(t, err)
+	require.Nil(t, out.Job)
+	require.NotNil(t, out.Result)
+	return *out.Result
+}
+
+func statuses(res exampledtos.BatchResultDTO) []string {
+	out := make([]string, len(res.Rows))
+	for i, r := range res.Rows {
+		out[i] = r.Status
+	}
+	return out
+}
+
+func TestImportExamples_AtomicStoresAllRowsInChunks(t *testing.T) {
+	repo := sequenceRepo()
+	var gotChunk, gotRows int
+	createBatch := repo.CreateBatchFunc
+	repo.CreateBatchFunc = func(ctx context.Context, es []*entities.ExampleEntity, chunkSize int) ([]int64, error) {
+		gotChunk, gotRows = chunkSize, len(es)
+		return createBatch(ctx, es, chunkSize)
+	}
+	svc := NewExampleService(repo, zap.NewNop(), configs.Config{BatchChunkSize: 2}, validation.GetValidator(), nil, nil)
+
+	res := importOf(t, svc, context.Background(), exampledtos.BatchQueryDTO{}, importCSV, "text/csv")
+	require.Equal(t, exampledtos.BatchModeAtomic, res.Mode)
+	require.Equal(t, 3, res.Created)
+	require.Equal(t, []exampledtos.BatchRowResultDTO{
+		{Row: 1, Status: exampledtos.RowCreated, ID: 1},
+		{Row: 2, Status: exampledtos.RowCreated, ID: 2},
+		{Row: 3, Status: exampledtos.RowCreated, ID: 3},
+	}, res.Rows)
+	require.Equal(t, 2, gotChunk)
+	require.Equal(t, 3, gotRows, "one transaction for the whole upload")
+}
+
+func TestImportExamples_AtomicRejectsBatchWithInvalidRow(t *testing.T) {
+	repo := sequenceRepo()
+	repo.CreateBatchFunc = func(context.Context, []*entities.ExampleEntity, int) ([]int64, error) {
+		t.Fatal("nothing is stored")
+		return nil, nil
+	}
+	svc := NewExampleService(repo, zap.NewNop(), configs.Config{}, validation.GetValidator(), nil, nil)
+
+	res := importOf(t, svc, context.Background(), exampledtos.BatchQueryDTO{Mode: exampledtos.BatchModeAtomic},
+		"user_id,amount,currency\nu1,1.00,USD\nu2,0,USD\nu3,abc,USD\n", "text/csv")
+	require.Equal(t, []string{exampledtos.RowSkipped, exampledtos.RowInvalid, exampledtos.RowInvalid}, statuses(res))
+	require.Contains(t, res.Rows[1].Error, "money_positive")
+	require.Equal(t, 1, res.Skipped)
+	require.Equal(t, 2, res.Invalid)
+}
+
+func TestImportExamples_AtomicStorageErrorFailsTheImport(t *testing.T) {
+	svc := NewExampleService(sequenceRepo("u2"), zap.NewNop(), configs.Config{}, validation.GetValidator(), nil, nil)
+
+	_, err := svc.ImportExamples(context.Background(), exampledtos.BatchQueryDTO{}, strings.NewReader(importCSV), "text/csv")
+	require.EqualError(t, err, "duplicate user u2")
+}
+
+func TestImportExamples_BestEffortIsolatesFailingRows(t *testing.T) {
+	svc := NewExampleService(sequenceRepo("u2"), zap.NewNop(), configs.Config{BatchChunkSize: 2}, validation.GetValidator(), nil, nil)
+
+	res := importOf(t, svc, context.Background(), exampledtos.BatchQueryDTO{Mode: exampledtos.BatchModeBestEffort},
+		importCSV+"u4,1,XXX\n", "text/csv")
+	require.Equal(t, []string{exampledtos.RowCreated, exampledtos.RowFailed, exampledtos.RowCreated, exampledtos.RowInvalid}, statuses(res))
+	require.Equal(t, "duplicate user u2", res.Rows[1].Error)
+	require.Equal(t, 2, res.Created)
+	require.Equal(t, 1, res.Failed)
+	require.Equal(t, 1, res.Invalid)
+}
+
+func TestImportExamples_RejectsBadRequests(t *testing.T) {
+	svc := NewExampleService(sequenceRepo(), zap.NewNop(), configs.Config{BatchMaxRows: 2}, validation.GetValidator(), nil, nil)
+	ctx := context.Background()
+
+	_, err := svc.ImportExamples(ctx, exampledtos.BatchQueryDTO{Mode: "yolo"}, strings.NewReader(importCSV), "text/csv")
+	var invalid validator.ValidationErrors
+	require.ErrorAs(t, err, &invalid)
+
+	_, err = svc.ImportExamples(ctx, exampledtos.BatchQueryDTO{}, strings.NewReader(importCSV), "text/csv")
+	require.ErrorIs(t, err, exampledtos.ErrTooManyRows)
+
+	async := true
+	_, err = svc.ImportExamples(ctx, exampledtos.BatchQueryDTO{Async: &async}, strings.NewReader("user_id,amount,currency\nu1,1,USD\n"), "text/csv")
+	require.ErrorIs(t, err, ErrAsyncImportsDisabled)
+}
+
+func TestImportExamples_LargeUploadsRunInTheBackground(t *testing.T) {
+	runner := jobs.NewRunner(jobs.Options{})
+	t.Cleanup(func() { _ = runner.Shutdown(context.Background()) })
+	svc := NewExampleService(sequenceRepo(), zap.NewNop(), configs.Config{BatchAsyncRows: 2}, validation.GetValidator(), nil, runner)
+	alice := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "alice"})
+
+	out, err := svc.ImportExamples(alice, exampledtos.BatchQueryDTO{}, strings.NewReader(importCSV), "text/csv")
+	require.NoError(t, err)
+	require.Nil(t, out.Result)
+	require.NotNil(t, out.Job)
+	require.Equal(t, 3, out.Job.Total)
+
+	var job exampledtos.BatchJobDTO
+	require.Eventually(t, func() bool {
+		job, err = svc.ImportStatus(alice, out.Job.ID)
+		return err == nil && job.Status == string(jobs.StatusSucceeded)
+	}, time.Second, time.Millisecond)
+	require.Equal(t, 3, job.Done)
+	require.NotNil(t, job.Result)
+	require.Equal(t, 3, job.Result.Created)
+
+	bob := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "bob"})
+	_, err = svc.ImportStatus(bob, out.Job.ID)
+	require.ErrorIs(t, err, ErrImportNotFound, "jobs are only visible to whoever started them")
+	_, err = svc.ImportStatus(alice, "missing")
+	require.ErrorIs(t, err, ErrImportNotFound)
+
+	small := importOf(t, svc, alice, exampledtos.BatchQueryDTO{}, "user_id,amount,currency\nu9,1,USD\n", "text/csv")
+	require.Equal(t, 1, small.Created, "small uploads are imported right away")
+}
@@ -6,6 +6,9 @@ import (
 	exampledtos "go-boilerplate/internal/dtos/example_dtos"
 	"go-boilerplate/internal/entities"
 	"go-boilerplate/internal/repositories"
+	"go-boilerplate/internal/transports/rabbit"
+	"go-boilerplate/internal/utils/jobs"
+	"io"
 
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
@@ -14,6 +17,12 @@ import (
 // ExampleService defines the interface for example-related business logic.
 type ExampleService interface {
 	CreateExample(ctx context.Context, dto exampledtos.ExampleDTO) (int64, error)
+	// ImportExamples decodes an upload of many examples, validates every row and stores
+	// the valid ones in the mode of q. Large uploads are handed to a background job,
+	// whose status is then returned instead of the result.
+	ImportExamples(ctx context.Context, q exampledtos.BatchQueryDTO, body io.Reader, contentType string) (exampledtos.BatchImportDTO, error)
+	// ImportStatus returns the import job with id, if the caller started it.
+	ImportStatus(ctx context.Context, id string) (exampledtos.BatchJobDTO, error)
 }
 
 type exampleService struct {
@@ -21,6 +30,11 @@ type exampleService struct {
 	cfg         configs.Config
 	log         *zap.Logger
 	v           *validator.Validate
+	// pub is for notifications that may be lost. example.created is not published with it:
+	// the repository writes it to the outbox in the same transaction as the row, so it is
+	// never lost or sent for a rolled-back insert.
+	pub     rabbit.MessagePublisher
+	imports *jobs.Runner
 }
 
 // NewExampleService creates a new instance of ExampleService.
@@ -29,12 +43,16 @@ type exampleService struct {
 // It uses the repository to interact with the database and the validator for input validation.
 // The ExampleService interface defines the methods that the service should implement.
 // This allows for easier testing and flexibility in implementation.
-func NewExampleService(r repositories.ExampleRepository, log *zap.Logger, cfg configs.Config, v *validator.Validate) ExampleService {
+// pub may be nil, in which case no events are published.
+// Large imports run on imports; if it is nil, every import runs within its request.
+func NewExampleService(r repositories.ExampleRepository, log *zap.Logger, cfg configs.Config, v *validator.Validate, pub rabbit.MessagePublisher, imports *jobs.Runner) ExampleService {
 	return &exampleService{
 		exampleRepo: r,
 		cfg:         cfg,
 		log:         log,
 		v:           v,
+		pub:         pub,
+		imports:     imports,
 	}
 }
 
@@ -47,5 +65,9 @@ func (s *exampleService) CreateExample(ctx context.Context, o exampledtos.Exampl
 		UserID: o.UserID,
 		Amount: o.Amount,
 	}
-	return s.exampleRepo.Create(ctx, entity)
+	id, err := s.exampleRepo.Create(ctx, entity)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
 }
@@ -2,14 +2,16 @@ package services
 
 import (
     "context"
+    "errors"
     "testing"
 
     "go-boilerplate/internal/configs"
     exampledtos "go-boilerplate/internal/dtos/example_dtos"
     "go-boilerplate/internal/entities"
     "go-boilerplate/internal/repositories/_mock"
+    "go-boilerplate/internal/transports/rabbit"
+    "go-boilerplate/internal/utils/validation"
 
-    "github.com/go-playground/validator/v10"
     "github.com/stretchr/testify/require"
     "go.uber.org/zap"
 )
@@ -21,11 +23,11 @@ func TestCreateExample_Success(t *testing.T) {
         },
     }
 
-    svc := NewExampleService(mockRepo, zap.NewNop(), configs.Config{}, validator.New())
+    svc := NewExampleService(mockRepo, zap.NewNop(), configs.Config{}, validation.GetValidator(), nil, nil)
 
     dto := exampledtos.ExampleDTO{
         UserID: "u1",
-        Amount: 10,
+        Amount: usd(10),
     }
 
     id, err := svc.CreateExample(context.Background(), dto)
@@ -42,11 +44,11 @@ func TestCreateExample_PassesEntityToRepo(t *testing.T) {
         },
     }
 
-    svc := NewExampleService(mockRepo, zap.NewNop(), configs.Config{}, validator.New())
+    svc := NewExampleService(mockRepo, zap.NewNop(), configs.Config{}, validation.GetValidator(), nil, nil)
 
     dto := exampledtos.ExampleDTO{
         UserID: "pass-through",
-        Amount: 77,
+        Amount: usd(77),
     }
 
     id, err := svc.CreateExample(context.Background(), dto)
@@ -54,5 +56,25 @@ func TestCreateExample_PassesEntityToRepo(t *testing.T) {
     require.Equal(t, int64(33), id)
     require.NotNil(t, captured)
     require.Equal(t, "pass-through", captured.UserID)
-    require.Equal(t, int64(77), captured.Amount)
-}
\ No newline at end of file
+    require.Equal(t, usd(77), captured.Amount)
+}
+
+func TestCreateExample_RepositoryFailureIsReturnedWithoutEvents(t *testing.T) {
+    mockRepo := &_mock.MockExampleRepository{
+        CreateFunc: func(ctx context.Context, u *entities.ExampleEntity) (int64, error) {
+            return 0, errors.New("db down")
+        },
+    }
+    pub := rabbit.NewFakePublisher()
+
+    svc := NewExampleService(mockRepo, zap.NewNop(), configs.Config{}, validation.GetValidator(), pub, nil)
+
+    _, err := svc.CreateExample(context.Background(), exampledtos.ExampleDTO{UserID: "u1", Amount: usd(10)})
+    require.EqualError(t, err, "db down")
+    require.Empty(t, pub.Messages(), "failures are not broadcast")
+}
+
+// usd returns minor cents of USD.
+func usd(minor int64) entities.Money {
+    return entities.Money{Minor: minor, Currency: "USD"}
+}
@@ -1,7 +1,13 @@
 package services
 
+import "go-boilerplate/internal/transports/rabbit"
+
 // Register holds the application services.
 type Register struct {
 	ExampleService ExampleService
+	APIKeyService  APIKeyService
+	AuditService   AuditService
+	// Publisher sends events to RabbitMQ; shared by the services above.
+	Publisher rabbit.MessagePublisher
 	// add more services if needed
 }
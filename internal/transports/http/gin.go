@@ -2,12 +2,19 @@ package http
 
 import (
 	"context"
+	"fmt"
 	"go-boilerplate/internal/configs"
 	"go-boilerplate/internal/services"
+	middewares "go-boilerplate/internal/transports/http/middlewares"
+	"go-boilerplate/internal/utils/authz"
+	"go-boilerplate/internal/utils/health"
+	"go-boilerplate/internal/utils/idempotency"
+	"go-boilerplate/internal/utils/ratelimit"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // Server holds the Gin engine and services for the HTTP server.
@@ -18,21 +25,52 @@ type Server struct {
 	eng *gin.Engine
 }
 
+// Deps holds the shared infrastructure used by the HTTP middlewares.
+type Deps struct {
+	Logger      *zap.Logger
+	Authorizer  *authz.Authorizer
+	Limiter     ratelimit.Store
+	Idempotency idempotency.Store
+	// Config, when set, delivers reloaded configuration to the auth and rate limit middlewares.
+	Config *configs.Watcher
+	// Health, when set, backs the /readyz probe.
+	Health *health.Registry
+}
+
 // NewHTTPServer initializes a new HTTP server with the provided services.
 // It sets up the Gin engine, applies middleware, and registers routes.
 // The server is ready to handle incoming HTTP requests.
 // The health check route is also defined here for basic server health monitoring.
-func NewHTTPServer(svcs services.Register, cfg configs.Config) *Server {
+func NewHTTPServer(svcs services.Register, cfg configs.Config, deps Deps) *Server {
 	r := gin.New()
 	r.Use(gin.Recovery())
+	r.Use(middewares.RequestIDMiddleware())
+	// Read-your-writes sessions only matter when reads can go to a replica
+	if len(cfg.DbReplicaHosts) > 0 {
+		r.Use(middewares.DBSessionMiddleware([]byte(cfg.DbSessionKey), time.Duration(cfg.DbReadYourWritesMS)*time.Millisecond))
+	}
+	r.Use(middewares.AccessLogMiddleware(deps.Logger))
 
 	// Health route stays here
 	r.GET("/healthz", func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
 	})
+	// Readiness: 503 while a required dependency is down; optional ones only mark it degraded
+	r.GET("/readyz", func(c *gin.Context) {
+		if deps.Health == nil {
+			c.JSON(http.StatusOK, health.Report{Status: health.StatusReady, Components: []health.Component{}})
+			return
+		}
+		rep := deps.Health.Check(c.Request.Context(), 2*time.Second)
+		status := http.StatusOK
+		if !rep.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, rep)
+	})
 
 	// Load application routes
-	RegisterRoutes(r, svcs, cfg)
+	RegisterRoutes(r, svcs, cfg, deps)
 
 	return &Server{eng: r}
 }
@@ -41,15 +79,22 @@ func NewHTTPServer(svcs services.Register, cfg configs.Config) *Server {
 // It blocks until the context is done, allowing for graceful shutdown.
 // The server listens on the specified address and handles requests using the Gin engine.
 // If the context is canceled, it gracefully shuts down the server.
+// It returns early with an error if the listener fails, for example when addr is in use.
 func (s *Server) Run(ctx context.Context, addr string) error {
 	srv := &http.Server{
 		Addr:    addr,
 		Handler: s.eng,
 	}
+	errCh := make(chan error, 1)
 	go func() {
-		_ = srv.ListenAndServe()
+		errCh <- srv.ListenAndServe()
 	}()
-	<-ctx.Done()
+	select {
+	case err := <-errCh:
+		// The listener failed before shutdown was requested, e.g. the address is in use.
+		return fmt.Errorf("http server: %w", err)
+	case <-ctx.Done():
+	}
 	shCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	return srv.Shutdown(shCtx)
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	exampledtos "go-boilerplate/internal/dtos/example_dtos"
 	"go-boilerplate/internal/services"
+	"go-boilerplate/internal/utils/jobs"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
 // ExampleHandler handles HTTP requests related to examples.
@@ -43,5 +47,66 @@ func (h *ExampleHandler) CreateExample(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"id": id})
 }
 
+// ImportExamples handles POST /example/batch?mode=atomic|best_effort&async=true|false.
+// The body is a JSON array, NDJSON or CSV, as told by Content-Type. It answers 200 with a
+// result per row, or 422 with the same body when an atomic import was rejected because of
+// invalid rows. Imports that run in the background answer 202 with the job, which can be
+// polled at the Location header.
+func (h *ExampleHandler) ImportExamples(c *gin.Context) {
+	var q exampledtos.BatchQueryDTO
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	out, err := h.exampleSrv.ImportExamples(c.Request.Context(), q, c.Request.Body, c.ContentType())
+	if err != nil {
+		c.JSON(importErrorStatus(c, err), gin.H{"error": err.Error()})
+		return
+	}
+	if out.Job != nil {
+		c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+out.Job.ID)
+		c.JSON(http.StatusAccepted, out)
+		return
+	}
+	status := http.StatusOK
+	if out.Result.Mode == exampledtos.BatchModeAtomic && out.Result.Invalid > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, out)
+}
+
+// GetImport handles GET /example/batch/:id, returning the status of a background import
+// and, once it has finished, its result. Only the caller who started it can see it.
+func (h *ExampleHandler) GetImport(c *gin.Context) {
+	job, err := h.exampleSrv.ImportStatus(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(importErrorStatus(c, err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func importErrorStatus(c *gin.Context, err error) int {
+	var (
+		invalid  validator.ValidationErrors
+		tooLarge *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &tooLarge), errors.Is(err, exampledtos.ErrTooManyRows):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, exampledtos.ErrUnsupportedBatchFormat):
+		return http.StatusUnsupportedMediaType
+	case errors.As(err, &invalid), errors.Is(err, exampledtos.ErrMalformedBatch),
+		errors.Is(err, exampledtos.ErrEmptyBatch), errors.Is(err, services.ErrAsyncImportsDisabled):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrImportNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, jobs.ErrBusy), errors.Is(err, jobs.ErrClosed):
+		c.Header("Retry-After", "30")
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
 // Add methods to handle HTTP requests, such as CreateExample, UpdateExample, etc.
 // Each method should correspond to a specific route and handle the request logic.
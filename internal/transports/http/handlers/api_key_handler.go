@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	apikeydtos "go-boilerplate/internal/dtos/api_key_dtos"
+	"go-boilerplate/internal/repositories"
+	"go-boilerplate/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler exposes admin endpoints to mint, list and revoke API keys.
+// The plaintext key is only returned in the response to MintAPIKey.
+type APIKeyHandler struct {
+	apiKeySrv services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler with the provided APIKeyService.
+func NewAPIKeyHandler(apiKeySrv services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeySrv: apiKeySrv,
+	}
+}
+
+// MintAPIKey handles POST requests to create a new API key.
+// It returns 201 Created with the key; the secret cannot be retrieved again afterwards.
+func (h *APIKeyHandler) MintAPIKey(c *gin.Context) {
+	var in apikeydtos.MintAPIKeyDTO
+	if err := c.BindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	out, err := h.apiKeySrv.Mint(c.Request.Context(), in)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+// ListAPIKeys handles GET requests listing API keys without their secrets or hashes.
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeySrv.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// RevokeAPIKey handles DELETE requests revoking the key identified by the :id path parameter.
+// It returns 404 Not Found if there is no such key or it was already revoked.
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := h.apiKeySrv.Revoke(c.Request.Context(), id); err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrReadOnly):
+		return http.StatusConflict
+	case errors.Is(err, repositories.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	auditdtos "go-boilerplate/internal/dtos/audit_dtos"
+	"go-boilerplate/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// AuditHandler exposes the audit trail of entity changes.
+type AuditHandler struct {
+	auditSrv services.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler with the provided AuditService.
+func NewAuditHandler(auditSrv services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditSrv: auditSrv,
+	}
+}
+
+// ListAudit handles GET /audit?entity=example&id=42&limit=50&offset=0, returning the changes
+// made to one entity, newest first.
+func (h *AuditHandler) ListAudit(c *gin.Context) {
+	var in auditdtos.ListAuditDTO
+	if err := c.ShouldBindQuery(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := h.auditSrv.List(c.Request.Context(), in)
+	if err != nil {
+		var invalid validator.ValidationErrors
+		if errors.As(err, &invalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
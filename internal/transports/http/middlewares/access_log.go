@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"go-boilerplate/internal/utils/correlation"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AccessLogMiddleware logs one line per request after the handler chain has run.
+// The authenticated username (if any) is included so requests can be attributed to a caller.
+func AccessLogMiddleware(log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		log.Info("http request",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.FullPath()),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("user", AuthUser(c)),
+			zap.String("request_id", correlation.RequestID(c.Request.Context())),
+		)
+	}
+}
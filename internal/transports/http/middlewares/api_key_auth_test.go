@@ -0,0 +1,126 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/services"
+	"go-boilerplate/internal/utils/auth"
+	"go-boilerplate/internal/utils/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeAPIKeyService authenticates the keys in keys; every other method is unused.
+type fakeAPIKeyService struct {
+	services.APIKeyService
+	keys map[string]*entities.APIKeyEntity
+	err  error
+}
+
+func (s *fakeAPIKeyService) Authenticate(ctx context.Context, rawKey string) (*entities.APIKeyEntity, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	k, ok := s.keys[rawKey]
+	if !ok {
+		return nil, services.ErrInvalidAPIKey
+	}
+	return k, nil
+}
+
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("redis: connection refused")
+}
+
+func newAPIKeyEngine(svc services.APIKeyService, store ratelimit.Store, log *zap.Logger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", APIKeyAuth(svc, store, log), func(c *gin.Context) {
+		p, _ := auth.PrincipalFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"user": AuthUser(c), "id": c.GetInt64(APIKeyIDKey), "method": p.Method, "scopes": p.Scopes})
+	})
+	return r
+}
+
+func doAPIKey(r *gin.Engine, header, value string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	svc := &fakeAPIKeyService{keys: map[string]*entities.APIKeyEntity{
+		"ak_good_secret": {ID: 7, Name: "billing-sync", Scopes: []string{"example:read"}},
+	}}
+	r := newAPIKeyEngine(svc, ratelimit.NewMemoryStore(), zap.NewNop())
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		wantCode int
+	}{
+		{"x-api-key header", APIKeyHeader, "ak_good_secret", http.StatusOK},
+		{"authorization apikey scheme", "Authorization", "ApiKey ak_good_secret", http.StatusOK},
+		{"missing key", "", "", http.StatusUnauthorized},
+		{"bearer is not an api key", "Authorization", "Bearer ak_good_secret", http.StatusUnauthorized},
+		{"unknown key", APIKeyHeader, "ak_bad_secret", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doAPIKey(r, tt.header, tt.value)
+			require.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusOK {
+				require.JSONEq(t, `{"user":"billing-sync","id":7,"method":"apikey","scopes":["example:read"]}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuth_LookupFailureIsUnavailable(t *testing.T) {
+	r := newAPIKeyEngine(&fakeAPIKeyService{err: errors.New("db down")}, ratelimit.NewMemoryStore(), zap.NewNop())
+	w := doAPIKey(r, APIKeyHeader, "ak_good_secret")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestAPIKeyAuth_PerKeyRateLimit(t *testing.T) {
+	svc := &fakeAPIKeyService{keys: map[string]*entities.APIKeyEntity{
+		"ak_good_secret": {ID: 7, Name: "billing-sync", RateLimitPerMinute: 1},
+	}}
+	r := newAPIKeyEngine(svc, ratelimit.NewMemoryStore(), zap.NewNop())
+
+	w := doAPIKey(r, APIKeyHeader, "ak_good_secret")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+
+	w = doAPIKey(r, APIKeyHeader, "ak_good_secret")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+}
+
+func TestAPIKeyAuth_LimiterErrorIsLoggedAndAllowed(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	svc := &fakeAPIKeyService{keys: map[string]*entities.APIKeyEntity{
+		"ak_good_secret": {ID: 7, Name: "billing-sync", RateLimitPerMinute: 1},
+	}}
+	r := newAPIKeyEngine(svc, failingStore{}, zap.New(core))
+
+	require.Equal(t, http.StatusOK, doAPIKey(r, APIKeyHeader, "ak_good_secret").Code)
+	entries := logs.FilterMessage("rate limiter unavailable, allowing api key request").All()
+	require.Len(t, entries, 1)
+	require.Equal(t, int64(7), entries[0].ContextMap()["api_key_id"])
+}
@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"errors"
+	"go-boilerplate/internal/utils/authz"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize attaches a to the request context, so services can call authz.Require, and
+// enforces any route rule from the policy that matches the request's method and route.
+// It must run after the authentication middleware of the route group.
+func Authorize(a *authz.Authorizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := authz.WithAuthorizer(c.Request.Context(), a)
+		c.Request = c.Request.WithContext(ctx)
+
+		if rule, ok := a.RouteRule(c.Request.Method, c.FullPath()); ok {
+			if err := a.Check(ctx, rule); err != nil {
+				abortAuthz(c, err)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequirePermissions returns a middleware that requires every permission in perms
+// (e.g. "example:write") for a single route.
+func RequirePermissions(perms ...string) gin.HandlerFunc {
+	return requireRule(authz.Rule{Permissions: perms})
+}
+
+// RequireRoles returns a middleware that requires at least one of roles for a single route.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return requireRule(authz.Rule{Roles: roles})
+}
+
+func requireRule(rule authz.Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if err := authz.FromContext(ctx).Check(ctx, rule); err != nil {
+			abortAuthz(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortAuthz(c *gin.Context, err error) {
+	status := http.StatusForbidden
+	if errors.Is(err, authz.ErrUnauthenticated) {
+		status = http.StatusUnauthorized
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": http.StatusText(status)})
+}
@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-boilerplate/internal/utils/auth"
+	"go-boilerplate/internal/utils/authz"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testPolicy = &authz.Policy{
+	Roles:    map[string][]string{"admin": {"example:read", "example:write"}},
+	Subjects: map[string][]string{"alice": {"admin"}},
+	Routes: []authz.RouteRule{
+		{Method: http.MethodPost, Path: "/example/", Rule: authz.Rule{Permissions: []string{"example:write"}}},
+	},
+}
+
+// withPrincipal stands in for the authentication middleware; an empty subject means anonymous.
+func withPrincipal(subject string, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if subject != "" {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{Subject: subject, Scopes: scopes}))
+		}
+		c.Next()
+	}
+}
+
+func doAuthorize(r *gin.Engine, method, path string) int {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w.Code
+}
+
+func TestAuthorize_EnforcesRouteRules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a := authz.New(testPolicy, zap.NewNop())
+
+	tests := []struct {
+		name     string
+		subject  string
+		scopes   []string
+		method   string
+		wantCode int
+	}{
+		{"anonymous", "", nil, http.MethodPost, http.StatusUnauthorized},
+		{"role through subject", "alice", nil, http.MethodPost, http.StatusOK},
+		{"scope", "svc", []string{"example:write"}, http.MethodPost, http.StatusOK},
+		{"missing permission", "bob", []string{"example:read"}, http.MethodPost, http.StatusForbidden},
+		{"route without rule", "bob", nil, http.MethodGet, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			g := r.Group("/example", withPrincipal(tt.subject, tt.scopes...), Authorize(a))
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			g.POST("/", ok)
+			g.GET("/", ok)
+
+			require.Equal(t, tt.wantCode, doAuthorize(r, tt.method, "/example/"))
+		})
+	}
+}
+
+func TestAuthorize_AttachesAuthorizerForServices(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", withPrincipal("alice"), Authorize(authz.New(testPolicy, zap.NewNop())), func(c *gin.Context) {
+		if err := authz.Require(c.Request.Context(), "example:write"); err != nil {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	require.Equal(t, http.StatusOK, doAuthorize(r, http.MethodGet, "/"))
+}
+
+func TestRequirePermissionsAndRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	g := r.Group("/", withPrincipal("bob", "example:read"), Authorize(authz.New(testPolicy, zap.NewNop())))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	g.GET("/read", RequirePermissions("example:read"), ok)
+	g.GET("/write", RequirePermissions("example:write"), ok)
+	g.GET("/admin", RequireRoles("admin"), ok)
+
+	require.Equal(t, http.StatusOK, doAuthorize(r, http.MethodGet, "/read"))
+	require.Equal(t, http.StatusForbidden, doAuthorize(r, http.MethodGet, "/write"))
+	require.Equal(t, http.StatusForbidden, doAuthorize(r, http.MethodGet, "/admin"))
+}
@@ -1,30 +1,134 @@
 package middlewares
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"go-boilerplate/internal/configs"
+	"go-boilerplate/internal/utils/auth"
 	"net/http"
 	"strings"
-	"go-boilerplate/internal/configs"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
+
+// AuthUserKey is the Gin context key holding the authenticated username.
+const AuthUserKey = "auth_user"
+
+// dummyHash is compared against when the username is unknown so that the
+// response time does not reveal which usernames exist.
+const dummyHash = "$2a$10$QdV3G6dekvXDb8x8ARgGyOVZuor0hJh84Ul5OZYP1Rp2ZQSvjsJBS"
+
 // BasicAuthMiddleware returns a middleware that enforces HTTP Basic Auth using
 // credentials from the provided Config.
-// If either credential in cfg is empty, the middleware is a no-op.
+// Credentials come from BasicAuthUser/BasicAuthPass and from the htpasswd-style
+// BasicAuthUsers entries. Passwords may be bcrypt ($2a$, $2b$, $2y$) or argon2id
+// ($argon2id$) hashes; anything else is treated as plain text and compared in constant time.
+// On success the username is stored on the Gin context under AuthUserKey and an
+// auth.Principal is attached to the request context.
+// If no credentials are configured, the middleware is a no-op.
 func BasicAuthMiddleware(cfg configs.Config) gin.HandlerFunc {
-    user := strings.TrimSpace(cfg.BasicAuthUser)
-    pass := cfg.BasicAuthPass
-
-    if user == "" || pass == "" {
-        return func(c *gin.Context) { c.Next() }
-    }
-
-    return func(c *gin.Context) {
-        username, password, ok := c.Request.BasicAuth()
-        if !ok || username != user || password != pass {
-            c.Header("WWW-Authenticate", `Basic realm="Restricted"`)
-            c.AbortWithStatus(http.StatusUnauthorized)
-            return
-        }
-        c.Next()
-    }
-}
\ No newline at end of file
+	return NewBasicAuth(cfg).Middleware()
+}
+
+// BasicAuth holds the Basic Auth credentials and can swap them at runtime, e.g. when the
+// configuration is reloaded.
+type BasicAuth struct {
+	state atomic.Pointer[basicAuthState]
+}
+
+type basicAuthState struct {
+	users     map[string]string
+	challenge string
+}
+
+// NewBasicAuth creates a BasicAuth with the credentials in cfg.
+func NewBasicAuth(cfg configs.Config) *BasicAuth {
+	b := &BasicAuth{}
+	b.Update(cfg)
+	return b
+}
+
+// Update replaces the credentials and realm with those in cfg.
+func (b *BasicAuth) Update(cfg configs.Config) {
+	users := make(map[string]string, len(cfg.BasicAuthUsers)+1)
+	if user := strings.TrimSpace(cfg.BasicAuthUser); user != "" && cfg.BasicAuthPass != "" {
+		users[user] = cfg.BasicAuthPass
+	}
+	for _, entry := range cfg.BasicAuthUsers {
+		user, hash, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		users[strings.TrimSpace(user)] = hash
+	}
+
+	realm := cfg.BasicAuthRealm
+	if realm == "" {
+		realm = "Restricted"
+	}
+	b.state.Store(&basicAuthState{users: users, challenge: fmt.Sprintf("Basic realm=%q", realm)})
+}
+
+// Middleware returns the Gin handler enforcing the current credentials.
+func (b *BasicAuth) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		st := b.state.Load()
+		if len(st.users) == 0 {
+			c.Next()
+			return
+		}
+		username, password, ok := c.Request.BasicAuth()
+		if !ok {
+			c.Header("WWW-Authenticate", st.challenge)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		hash, known := st.users[username]
+		if !known {
+			hash = dummyHash
+		}
+		if !verifyPassword(hash, password) || !known {
+			c.Header("WWW-Authenticate", st.challenge)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set(AuthUserKey, username)
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), &auth.Principal{
+			Subject: username,
+			Method:  auth.MethodBasic,
+		}))
+		c.Next()
+	}
+}
+
+// AuthUser returns the username stored by BasicAuthMiddleware or JWTAuth, or "" when the
+// request was not authenticated.
+func AuthUser(c *gin.Context) string {
+	return c.GetString(AuthUserKey)
+}
+
+// verifyPassword reports whether password matches the stored hash.
+func verifyPassword(hash, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return verifyArgon2id(hash, password)
+	default:
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(password)) == 1
+	}
+}
+
+// verifyArgon2id checks password against a PHC-formatted argon2id hash (see
+// auth.ParseArgon2id). Hashes with unusable or excessive parameters never match.
+func verifyArgon2id(hash, password string) bool {
+	h, err := auth.ParseArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	got := argon2.IDKey([]byte(password), h.Salt, h.Time, h.Memory, h.Threads, uint32(len(h.Key)))
+	return subtle.ConstantTimeCompare(got, h.Key) == 1
+}
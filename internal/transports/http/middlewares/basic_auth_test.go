@@ -0,0 +1,121 @@
+package middlewares
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-boilerplate/internal/configs"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newBasicAuthEngine(cfg configs.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", BasicAuthMiddleware(cfg), func(c *gin.Context) {
+		c.String(http.StatusOK, AuthUser(c))
+	})
+	return r
+}
+
+func doBasicAuth(r *gin.Engine, user, pass string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if user != "" {
+		req.SetBasicAuth(user, pass)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func argon2idHash(password string) string {
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte(password), salt, 1, 64*1024, 2, 32)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, 64*1024, 1, 2,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func TestBasicAuthMiddleware_NoCredentialsIsNoop(t *testing.T) {
+	r := newBasicAuthEngine(configs.Config{})
+	w := doBasicAuth(r, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBasicAuthMiddleware_HashedUsers(t *testing.T) {
+	bc, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	r := newBasicAuthEngine(configs.Config{
+		BasicAuthUser: "legacy",
+		BasicAuthPass: "plain",
+		BasicAuthUsers: []string{
+			"alice:" + string(bc),
+			"bob:" + argon2idHash("hunter2"),
+		},
+	})
+
+	tests := []struct {
+		name     string
+		user     string
+		pass     string
+		wantCode int
+		wantUser string
+	}{
+		{"bcrypt ok", "alice", "s3cret", http.StatusOK, "alice"},
+		{"bcrypt wrong", "alice", "nope", http.StatusUnauthorized, ""},
+		{"argon2id ok", "bob", "hunter2", http.StatusOK, "bob"},
+		{"argon2id wrong", "bob", "hunter3", http.StatusUnauthorized, ""},
+		{"plain ok", "legacy", "plain", http.StatusOK, "legacy"},
+		{"unknown user", "mallory", "s3cret", http.StatusUnauthorized, ""},
+		{"missing header", "", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doBasicAuth(r, tt.user, tt.pass)
+			require.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusOK {
+				require.Equal(t, tt.wantUser, w.Body.String())
+			} else {
+				require.Equal(t, `Basic realm="Restricted"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestBasicAuthMiddleware_UnsafeArgon2idParamsNeverMatch(t *testing.T) {
+	salt := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	key := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	r := newBasicAuthEngine(configs.Config{BasicAuthUsers: []string{
+		"nothreads:$argon2id$v=19$m=65536,t=1,p=0$" + salt + "$" + key,
+		"notime:$argon2id$v=19$m=65536,t=0,p=1$" + salt + "$" + key,
+		"hog:$argon2id$v=19$m=4194304,t=1,p=1$" + salt + "$" + key,
+	}})
+
+	for _, user := range []string{"nothreads", "notime", "hog"} {
+		t.Run(user, func(t *testing.T) {
+			require.NotPanics(t, func() {
+				require.Equal(t, http.StatusUnauthorized, doBasicAuth(r, user, "pw").Code)
+			})
+		})
+	}
+}
+
+func TestBasicAuth_Update(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	b := NewBasicAuth(configs.Config{BasicAuthUsers: []string{"alice:old"}})
+	r := gin.New()
+	r.GET("/", b.Middleware(), func(c *gin.Context) { c.String(http.StatusOK, AuthUser(c)) })
+
+	require.Equal(t, http.StatusOK, doBasicAuth(r, "alice", "old").Code)
+
+	b.Update(configs.Config{BasicAuthUsers: []string{"alice:new", "carol:pw"}})
+	require.Equal(t, http.StatusUnauthorized, doBasicAuth(r, "alice", "old").Code)
+	require.Equal(t, http.StatusOK, doBasicAuth(r, "alice", "new").Code)
+	require.Equal(t, http.StatusOK, doBasicAuth(r, "carol", "pw").Code)
+}
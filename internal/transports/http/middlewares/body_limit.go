@@ -0,0 +1,25 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBodyBytes returns a middleware that caps the request body at limit bytes. Reading
+// past the limit fails with an *http.MaxBytesError, which handlers report as 413. A limit
+// <= 0 leaves the body unbounded.
+func MaxBodyBytes(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxBodyBytes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/upload", MaxBodyBytes(4), func(c *gin.Context) {
+		_, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	do := func(body io.Reader) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload", body))
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, do(strings.NewReader("1234")))
+	require.Equal(t, http.StatusRequestEntityTooLarge, do(strings.NewReader("12345")), "declared length")
+	require.Equal(t, http.StatusRequestEntityTooLarge, do(io.MultiReader(strings.NewReader("123"), strings.NewReader("45"))), "streamed body")
+}
@@ -0,0 +1,115 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"go-boilerplate/internal/dbs"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DBSessionCookie carries the read-your-writes session of a client between requests.
+const DBSessionCookie = "db_session"
+
+// dbSessionSkew is how far in the future a session timestamp may be, to allow for clock
+// differences between instances.
+const dbSessionSkew = time.Second
+
+// DBSessionMiddleware gives every client a read-your-writes session (see dbs.WithSession)
+// that lasts across its requests: when a request writes to the primary database, the time
+// of the write is returned in a cookie signed with key, and the client's following requests
+// read from the primary until stickyFor has passed since then.
+//
+// Instances behind the same load balancer must share key. When key is empty a random one
+// is used, and sessions only stick to the instance that issued them.
+func DBSessionMiddleware(key []byte, stickyFor time.Duration) gin.HandlerFunc {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic("db session: generate key: " + err.Error())
+		}
+	}
+	return func(c *gin.Context) {
+		var resumed time.Time
+		if ck, err := c.Cookie(DBSessionCookie); err == nil {
+			if t, ok := parseDBSession(key, ck); ok && time.Since(t) < stickyFor && time.Until(t) < dbSessionSkew {
+				resumed = t
+			}
+		}
+		ctx := dbs.ResumeSession(c.Request.Context(), resumed)
+		c.Request = c.Request.WithContext(ctx)
+
+		w := &dbSessionWriter{ResponseWriter: c.Writer, set: func() {
+			if last := dbs.LastWrite(ctx); last.After(resumed) {
+				http.SetCookie(c.Writer, &http.Cookie{
+					Name:     DBSessionCookie,
+					Value:    signDBSession(key, last),
+					Path:     "/",
+					MaxAge:   max(1, int((stickyFor+time.Second-1)/time.Second)),
+					HttpOnly: true,
+					Secure:   c.Request.TLS != nil,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+		}}
+		c.Writer = w
+		c.Next()
+		// Responses without a body have not sent their headers yet.
+		w.before()
+	}
+}
+
+func signDBSession(key []byte, t time.Time) string {
+	ts := strconv.FormatInt(t.UnixNano(), 10)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ts))
+	return ts + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func parseDBSession(key []byte, v string) (time.Time, bool) {
+	ts, _, ok := strings.Cut(v, ".")
+	if !ok {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t := time.Unix(0, n)
+	return t, hmac.Equal([]byte(v), []byte(signDBSession(key, t)))
+}
+
+// dbSessionWriter sets the session cookie right before the response headers are sent.
+type dbSessionWriter struct {
+	gin.ResponseWriter
+	set  func()
+	done bool
+}
+
+func (w *dbSessionWriter) before() {
+	if !w.done && !w.ResponseWriter.Written() {
+		w.done = true
+		w.set()
+	}
+}
+
+func (w *dbSessionWriter) WriteHeaderNow() {
+	w.before()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *dbSessionWriter) Write(b []byte) (int, error) {
+	w.before()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *dbSessionWriter) WriteString(s string) (int, error) {
+	w.before()
+	return w.ResponseWriter.WriteString(s)
+}
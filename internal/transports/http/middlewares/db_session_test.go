@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/dbs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDBSessionMiddleware_ReadsOwnWritesAcrossRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	primary, pm, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primary.Close()
+	replica, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replica.Close()
+	db := dbs.NewDB(primary, []dbs.Replica{{Name: "r1", DB: replica}}, zap.NewNop(), dbs.DBOptions{StickyFor: time.Minute})
+
+	r := gin.New()
+	r.Use(DBSessionMiddleware([]byte("k1"), time.Minute))
+	r.POST("/write", func(c *gin.Context) {
+		if _, err := db.ExecContext(c.Request.Context(), "UPDATE users SET amount = 1"); err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/read", func(c *gin.Context) {
+		if db.Reader(c.Request.Context()) == primary {
+			c.String(http.StatusOK, "primary")
+			return
+		}
+		c.String(http.StatusOK, "replica")
+	})
+	do := func(method, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for _, ck := range cookies {
+			req.AddCookie(ck)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	pm.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+	w := do(http.MethodPost, "/write")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, DBSessionCookie, cookies[0].Name)
+
+	w = do(http.MethodGet, "/read", cookies[0])
+	require.Equal(t, "primary", w.Body.String(), "the next request reads its own write")
+	require.Empty(t, w.Result().Cookies(), "reads do not renew the session")
+
+	require.Equal(t, "replica", do(http.MethodGet, "/read").Body.String(), "other clients are not affected")
+	forged := &http.Cookie{Name: DBSessionCookie, Value: cookies[0].Value + "x"}
+	require.Equal(t, "replica", do(http.MethodGet, "/read", forged).Body.String(), "unsigned sessions are ignored")
+	require.NoError(t, pm.ExpectationsWereMet())
+}
+
+func TestDBSession_Signature(t *testing.T) {
+	now := time.Unix(0, time.Now().UnixNano())
+	v := signDBSession([]byte("k1"), now)
+
+	got, ok := parseDBSession([]byte("k1"), v)
+	require.True(t, ok)
+	require.True(t, now.Equal(got))
+
+	_, ok = parseDBSession([]byte("k2"), v)
+	require.False(t, ok, "signed with another key")
+	_, ok = parseDBSession([]byte("k1"), "123")
+	require.False(t, ok)
+}
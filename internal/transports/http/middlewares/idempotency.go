@@ -0,0 +1,129 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"go-boilerplate/internal/utils/idempotency"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client-generated idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLen bounds the header value clients may send.
+const maxIdempotencyKeyLen = 255
+
+// maxIdempotentBody bounds the request body read for hashing.
+const maxIdempotentBody = 1 << 20
+
+// idempotencyStoreTimeout bounds storing or releasing a key once the handler has run; it
+// does not depend on the request, which may already be cancelled.
+const idempotencyStoreTimeout = 5 * time.Second
+
+// Idempotency returns a middleware that makes a route safe to retry. Requests carrying an
+// Idempotency-Key header are recorded in store together with a hash of the request; a retry
+// with the same key and payload replays the stored response (with "Idempotent-Replayed: true"),
+// a retry with a different payload gets 422, and a retry while the first request is still in
+// flight gets 409. Responses with a 5xx status are not stored so the client may retry.
+// Keys are scoped to the authenticated user, so it must run after authentication.
+func Idempotency(store idempotency.Store, log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key too long"})
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+		if err != nil || len(body) > maxIdempotentBody {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		route := c.Request.Method + " " + c.FullPath()
+		storeKey := sha256Hex(AuthUser(c) + "\x00" + route + "\x00" + key)
+		requestHash := sha256Hex(route + "\x00" + string(body))
+
+		rec, token, err := store.Begin(c.Request.Context(), storeKey, requestHash)
+		if err != nil {
+			log.Error("idempotency store unavailable", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "idempotency store unavailable"})
+			return
+		}
+		if token == "" {
+			switch {
+			case rec.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key reused with a different request"})
+			case !rec.Completed:
+				c.Header("Retry-After", "1")
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is in progress"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(rec.Status, rec.ContentType, rec.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		w := &bodyCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		detached := context.WithoutCancel(c.Request.Context())
+		completed := false
+		defer func() {
+			// Runs on panic too: free the key so the client can retry.
+			if !completed {
+				ctx, cancel := context.WithTimeout(detached, idempotencyStoreTimeout)
+				defer cancel()
+				if err := store.Release(ctx, storeKey, token); err != nil {
+					log.Warn("failed to release idempotency key", zap.Error(err))
+				}
+			}
+		}()
+
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+		ctx, cancel := context.WithTimeout(detached, idempotencyStoreTimeout)
+		defer cancel()
+		if err := store.Complete(ctx, storeKey, token, w.Status(), w.Header().Get("Content-Type"), w.buf.Bytes()); err != nil {
+			log.Warn("failed to store idempotent response", zap.Error(err))
+			return
+		}
+		completed = true
+	}
+}
+
+// bodyCaptureWriter copies everything written to the response so it can be stored.
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,122 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go-boilerplate/internal/utils/idempotency"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newIdempotentEngine(store idempotency.Store, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/example/", Idempotency(store, zap.NewNop()), handler)
+	return r
+}
+
+func postIdempotent(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/example/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysAndRejectsMismatch(t *testing.T) {
+	var calls atomic.Int64
+	r := newIdempotentEngine(idempotency.NewMemoryStore(idempotency.Options{}), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"id": calls.Add(1)})
+	})
+
+	first := postIdempotent(r, "k-1", `{"amount":10}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.JSONEq(t, `{"id":1}`, first.Body.String())
+
+	retry := postIdempotent(r, "k-1", `{"amount":10}`)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	require.JSONEq(t, `{"id":1}`, retry.Body.String())
+	require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	require.Equal(t, int64(1), calls.Load())
+
+	mismatch := postIdempotent(r, "k-1", `{"amount":99}`)
+	require.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+
+	// No key: not deduplicated.
+	require.Equal(t, http.StatusCreated, postIdempotent(r, "", `{"amount":10}`).Code)
+	require.Equal(t, int64(2), calls.Load())
+}
+
+func TestIdempotency_InFlightDuplicateGetsConflict(t *testing.T) {
+	store := idempotency.NewMemoryStore(idempotency.Options{})
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	r := newIdempotentEngine(store, func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postIdempotent(r, "k-2", `{}`) }()
+	<-entered
+
+	dup := postIdempotent(r, "k-2", `{}`)
+	require.Equal(t, http.StatusConflict, dup.Code)
+
+	close(release)
+	require.Equal(t, http.StatusCreated, (<-done).Code)
+}
+
+func TestIdempotency_ServerErrorsAreNotStored(t *testing.T) {
+	var calls atomic.Int64
+	r := newIdempotentEngine(idempotency.NewMemoryStore(idempotency.Options{}), func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": 5})
+	})
+
+	require.Equal(t, http.StatusInternalServerError, postIdempotent(r, "k-3", `{}`).Code)
+	w := postIdempotent(r, "k-3", `{}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Empty(t, w.Header().Get("Idempotent-Replayed"))
+}
+
+// ctxStore fails like a database driver once the context is done.
+type ctxStore struct{ idempotency.Store }
+
+func (s ctxStore) Release(ctx context.Context, key, token string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Store.Release(ctx, key, token)
+}
+
+func TestIdempotency_CancelledRequestStillReleasesKey(t *testing.T) {
+	var calls atomic.Int64
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newIdempotentEngine(ctxStore{idempotency.NewMemoryStore(idempotency.Options{})}, func(c *gin.Context) {
+		calls.Add(1)
+		cancel()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "client went away"})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/example/", strings.NewReader(`{}`)).WithContext(ctx)
+	req.Header.Set(IdempotencyKeyHeader, "k-4")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Equal(t, http.StatusServiceUnavailable, postIdempotent(r, "k-4", `{}`).Code)
+	require.Equal(t, int64(2), calls.Load(), "the key was released, so the retry ran")
+}
@@ -0,0 +1,181 @@
+package middlewares
+
+import (
+	"fmt"
+	"go-boilerplate/internal/configs"
+	"go-boilerplate/internal/utils/auth"
+	"go-boilerplate/internal/utils/jwks"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTClaimsKey is the Gin context key holding the validated jwt.MapClaims.
+const JWTClaimsKey = "jwt_claims"
+
+// JWTAuth returns a middleware that authenticates requests carrying an
+// "Authorization: Bearer <token>" header.
+// HS256 tokens are verified with cfg.JWTHMACSecret (or an "oct" key from the JWKS);
+// RS256 and ES256 tokens are verified with the JWKS loaded from cfg.JWTJWKSFile or
+// fetched, cached and rotated from cfg.JWTJWKSURL. The JWKS file is read here; if that
+// fails (configs.Load already rejects an unreadable file), it is read again on the next
+// token that needs it.
+// The iss, aud, exp and nbf claims are checked with cfg.JWTClockSkewMS of leeway.
+// On success the claims are stored on the Gin context under JWTClaimsKey and an
+// auth.Principal is attached to the request context.
+// If neither a secret nor a JWKS source is configured, the middleware is a no-op.
+func JWTAuth(cfg configs.Config) gin.HandlerFunc {
+	if cfg.JWTHMACSecret == "" && cfg.JWTJWKSFile == "" && cfg.JWTJWKSURL == "" {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	keys := newJWTKeySource(cfg)
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(cfg.JWTAlgorithms),
+		jwt.WithLeeway(time.Duration(cfg.JWTClockSkewMS) * time.Millisecond),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if len(cfg.JWTAudience) > 0 {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience...))
+	}
+	parser := jwt.NewParser(opts...)
+
+	return func(c *gin.Context) {
+		raw, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			abortBearer(c, "missing bearer token")
+			return
+		}
+
+		claims := jwt.MapClaims{}
+		_, err := parser.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+			return keys.key(c, t)
+		})
+		if err != nil {
+			abortBearer(c, "invalid token")
+			return
+		}
+
+		sub, _ := claims.GetSubject()
+		p := &auth.Principal{
+			Subject: sub,
+			Method:  auth.MethodJWT,
+			Scopes:  claimStrings(claims, "scope", "scp"),
+			Roles:   claimStrings(claims, "roles"),
+			Claims:  claims,
+		}
+		c.Set(JWTClaimsKey, claims)
+		c.Set(AuthUserKey, sub)
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+// JWTClaims returns the claims stored by JWTAuth, or nil when the request was not
+// authenticated with a bearer token.
+func JWTClaims(c *gin.Context) jwt.MapClaims {
+	v, ok := c.Get(JWTClaimsKey)
+	if !ok {
+		return nil
+	}
+	claims, _ := v.(jwt.MapClaims)
+	return claims
+}
+
+// jwtKeySource resolves the verification key for a parsed (but not yet verified) token.
+type jwtKeySource struct {
+	secret []byte
+	load   func() (*jwks.KeySet, error)
+
+	mu  sync.Mutex
+	set *jwks.KeySet // nil until load succeeds
+}
+
+func newJWTKeySource(cfg configs.Config) *jwtKeySource {
+	ks := &jwtKeySource{secret: []byte(cfg.JWTHMACSecret)}
+	switch {
+	case cfg.JWTJWKSFile != "":
+		ks.load = func() (*jwks.KeySet, error) { return jwks.NewFromFile(cfg.JWTJWKSFile) }
+	case cfg.JWTJWKSURL != "":
+		refresh := time.Duration(cfg.JWTJWKSRefreshIntervalMS) * time.Millisecond
+		ks.load = func() (*jwks.KeySet, error) {
+			return jwks.NewFromURL(cfg.JWTJWKSURL, jwks.WithRefreshInterval(refresh)), nil
+		}
+	}
+	if ks.load != nil {
+		ks.set, _ = ks.load()
+	}
+	return ks
+}
+
+// keySet returns the JWKS, loading it if the previous attempt failed.
+func (s *jwtKeySource) keySet() (*jwks.KeySet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.set == nil {
+		set, err := s.load()
+		if err != nil {
+			return nil, err
+		}
+		s.set = set
+	}
+	return s.set, nil
+}
+
+func (s *jwtKeySource) key(c *gin.Context, t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	if _, isHMAC := t.Method.(*jwt.SigningMethodHMAC); isHMAC && len(s.secret) > 0 {
+		return s.secret, nil
+	}
+	if s.load == nil {
+		return nil, fmt.Errorf("no key configured for %s", t.Method.Alg())
+	}
+	set, err := s.keySet()
+	if err != nil {
+		return nil, err
+	}
+	return set.Key(c.Request.Context(), kid)
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func abortBearer(c *gin.Context, reason string) {
+	c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, reason))
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": reason})
+}
+
+// claimStrings reads the first present claim among names as a list of strings.
+// It accepts both a space-separated string (OAuth2 "scope") and a JSON array.
+func claimStrings(claims jwt.MapClaims, names ...string) []string {
+	for _, name := range names {
+		switch v := claims[name].(type) {
+		case string:
+			return strings.Fields(v)
+		case []any:
+			out := make([]string, 0, len(v))
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					out = append(out, s)
+				}
+			}
+			return out
+		}
+	}
+	return nil
+}
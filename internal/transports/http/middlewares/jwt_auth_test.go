@@ -0,0 +1,189 @@
+package middlewares
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/configs"
+	"go-boilerplate/internal/utils/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newJWTEngine(cfg configs.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", JWTAuth(cfg), func(c *gin.Context) {
+		p, _ := auth.PrincipalFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"sub": p.Subject, "scopes": p.Scopes})
+	})
+	return r
+}
+
+func doBearer(r *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func jwtCfg() configs.Config {
+	return configs.Config{
+		JWTIssuer:      "https://issuer.test",
+		JWTAudience:    []string{"example-api"},
+		JWTAlgorithms:  []string{"HS256", "RS256", "ES256"},
+		JWTClockSkewMS: 30_000,
+	}
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   "https://issuer.test",
+		"aud":   "example-api",
+		"exp":   now.Add(time.Minute).Unix(),
+		"nbf":   now.Unix(),
+		"scope": "example:read example:write",
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func TestJWTAuth_HS256(t *testing.T) {
+	cfg := jwtCfg()
+	cfg.JWTHMACSecret = "top-secret"
+	r := newJWTEngine(cfg)
+
+	w := doBearer(r, sign(t, jwt.SigningMethodHS256, "", []byte("top-secret"), validClaims()))
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Sub    string   `json:"sub"`
+		Scopes []string `json:"scopes"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Equal(t, "user-1", body.Sub)
+	require.Equal(t, []string{"example:read", "example:write"}, body.Scopes)
+
+	w = doBearer(r, sign(t, jwt.SigningMethodHS256, "", []byte("wrong"), validClaims()))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doBearer(r, "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestJWTAuth_ClaimChecks(t *testing.T) {
+	cfg := jwtCfg()
+	cfg.JWTHMACSecret = "top-secret"
+	r := newJWTEngine(cfg)
+
+	tests := []struct {
+		name   string
+		mutate func(jwt.MapClaims)
+		want   int
+	}{
+		{"expired beyond skew", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, http.StatusUnauthorized},
+		{"expired within skew", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-10 * time.Second).Unix() }, http.StatusOK},
+		{"not yet valid", func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Minute).Unix() }, http.StatusUnauthorized},
+		{"missing exp", func(c jwt.MapClaims) { delete(c, "exp") }, http.StatusUnauthorized},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.test" }, http.StatusUnauthorized},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other-api" }, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.mutate(claims)
+			w := doBearer(r, sign(t, jwt.SigningMethodHS256, "", []byte("top-secret"), claims))
+			require.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestJWTAuth_RS256FromJWKSFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	set := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA", "kid": "rsa-1", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	raw, err := json.Marshal(set)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	cfg := jwtCfg()
+	cfg.JWTJWKSFile = path
+	r := newJWTEngine(cfg)
+
+	w := doBearer(r, sign(t, jwt.SigningMethodRS256, "rsa-1", key, validClaims()))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// HS256 must not be accepted when only asymmetric keys are configured.
+	w = doBearer(r, sign(t, jwt.SigningMethodHS256, "rsa-1", []byte("guess"), validClaims()))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestJWTAuth_RetriesUnreadableJWKSFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+
+	cfg := jwtCfg()
+	cfg.JWTJWKSFile = path
+	r := newJWTEngine(cfg) // the file does not exist yet
+	token := sign(t, jwt.SigningMethodRS256, "rsa-1", key, validClaims())
+	require.Equal(t, http.StatusUnauthorized, doBearer(r, token).Code)
+
+	raw, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA", "kid": "rsa-1", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+	require.Equal(t, http.StatusOK, doBearer(r, token).Code, "the failed load is not remembered")
+}
+
+func TestJWTAuth_ES256FromJWKSURL(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "EC", "kid": "ec-1", "crv": "P-256",
+			"x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	}))
+	defer srv.Close()
+
+	cfg := jwtCfg()
+	cfg.JWTJWKSURL = srv.URL
+	cfg.JWTJWKSRefreshIntervalMS = 60_000
+	r := newJWTEngine(cfg)
+
+	w := doBearer(r, sign(t, jwt.SigningMethodES256, "ec-1", key, validClaims()))
+	require.Equal(t, http.StatusOK, w.Code)
+}
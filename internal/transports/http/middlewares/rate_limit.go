@@ -0,0 +1,168 @@
+package middlewares
+
+import (
+	"fmt"
+	"go-boilerplate/internal/configs"
+	"go-boilerplate/internal/utils/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimitPolicy describes which limit applies to a request and who it is counted against.
+type RateLimitPolicy struct {
+	// Default applies to every route without an entry in Routes. Disabled when zero.
+	Default ratelimit.Limit
+	// Routes holds per-route limits keyed by "METHOD /route/pattern", e.g. "POST /example/".
+	Routes map[string]ratelimit.Limit
+	// KeyBy selects the client identity: "ip", "user", "apikey" or "auto"
+	// (API key, then authenticated user, then client IP).
+	KeyBy string
+	// Name prefixes the buckets, so limiters sharing a store do not count against each other.
+	Name string
+}
+
+// RateLimit returns a middleware enforcing policy against store. It sets the RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers on every limited response
+// and Retry-After on 429s. It must run after authentication when keying by user or API key.
+// If the store fails the request is let through and the error is logged.
+func RateLimit(store ratelimit.Store, policy RateLimitPolicy, log *zap.Logger) gin.HandlerFunc {
+	return NewRateLimiter(store, policy, log).Middleware()
+}
+
+// RateLimiter enforces a RateLimitPolicy that can be replaced at runtime, e.g. when the
+// configuration is reloaded. Buckets already in the store are kept.
+type RateLimiter struct {
+	store  ratelimit.Store
+	log    *zap.Logger
+	policy atomic.Pointer[RateLimitPolicy]
+}
+
+// NewRateLimiter creates a RateLimiter enforcing policy against store.
+func NewRateLimiter(store ratelimit.Store, policy RateLimitPolicy, log *zap.Logger) *RateLimiter {
+	l := &RateLimiter{store: store, log: log}
+	l.SetPolicy(policy)
+	return l
+}
+
+// SetPolicy replaces the enforced policy.
+func (l *RateLimiter) SetPolicy(policy RateLimitPolicy) {
+	l.policy.Store(&policy)
+}
+
+// Middleware returns the Gin handler enforcing the current policy. See RateLimit.
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	store, log := l.store, l.log
+	return func(c *gin.Context) {
+		policy := l.policy.Load()
+		route := c.Request.Method + " " + c.FullPath()
+		limit, ok := policy.Routes[route]
+		bucket := route
+		if !ok {
+			limit, bucket = policy.Default, "default"
+		}
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		if policy.Name != "" {
+			bucket = policy.Name + "|" + bucket
+		}
+		res, err := store.Allow(c.Request.Context(), bucket+"|"+rateLimitClient(c, policy.KeyBy), limit)
+		if err != nil {
+			log.Warn("rate limiter unavailable, allowing request", zap.Error(err))
+			c.Next()
+			return
+		}
+		writeRateLimitHeaders(c, limit, res)
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func rateLimitClient(c *gin.Context, keyBy string) string {
+	apiKey := func() string {
+		if id, ok := c.Get(APIKeyIDKey); ok {
+			return fmt.Sprintf("apikey:%v", id)
+		}
+		return ""
+	}
+	user := func() string {
+		if u := AuthUser(c); u != "" {
+			return "user:" + u
+		}
+		return ""
+	}
+	switch keyBy {
+	case "ip":
+	case "user":
+		if k := user(); k != "" {
+			return k
+		}
+	case "apikey":
+		if k := apiKey(); k != "" {
+			return k
+		}
+	default:
+		if k := apiKey(); k != "" {
+			return k
+		}
+		if k := user(); k != "" {
+			return k
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func writeRateLimitHeaders(c *gin.Context, limit ratelimit.Limit, res ratelimit.Result) {
+	c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+	c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Window)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// RateLimitPolicyFromConfig builds a RateLimitPolicy from the RATE_LIMIT_* settings.
+// Route entries are validated when the config is loaded; malformed ones are skipped here.
+func RateLimitPolicyFromConfig(cfg configs.Config) RateLimitPolicy {
+	policy := RateLimitPolicy{
+		Default: ratelimit.Limit{
+			Requests: cfg.RateLimitRequests,
+			Window:   time.Duration(cfg.RateLimitWindowMS) * time.Millisecond,
+		},
+		Routes: make(map[string]ratelimit.Limit, len(cfg.RateLimitRoutes)),
+		KeyBy:  cfg.RateLimitKeyBy,
+	}
+	for _, entry := range cfg.RateLimitRoutes {
+		route, raw, _ := strings.Cut(entry, "=")
+		fields := strings.Fields(route)
+		limit, err := ratelimit.ParseLimit(raw)
+		if len(fields) != 2 || err != nil {
+			continue
+		}
+		policy.Routes[strings.ToUpper(fields[0])+" "+fields[1]] = limit
+	}
+	return policy
+}
+
+// PreAuthRateLimitPolicyFromConfig builds the per-IP policy from RATE_LIMIT_IP. It is meant
+// to run before authentication, so failed logins count against the client as well.
+func PreAuthRateLimitPolicyFromConfig(cfg configs.Config) RateLimitPolicy {
+	// Validated when the config is loaded; a malformed value disables the limit here.
+	limit, _ := ratelimit.ParseLimit(cfg.RateLimitIP)
+	return RateLimitPolicy{Default: limit, KeyBy: "ip", Name: "preauth"}
+}
@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/utils/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRateLimit_PerRouteLimitAndHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := RateLimitPolicy{
+		Default: ratelimit.Limit{Requests: 100, Window: time.Minute},
+		Routes:  map[string]ratelimit.Limit{"POST /limited": {Requests: 2, Window: time.Minute}},
+		KeyBy:   "ip",
+	}
+	r := gin.New()
+	r.Use(RateLimit(ratelimit.NewMemoryStore(), policy, zap.NewNop()))
+	r.POST("/limited", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/open", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := do(http.MethodPost, "/limited")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/limited").Code)
+
+	w = do(http.MethodPost, "/limited")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	// The default bucket is separate from the route's bucket.
+	w = do(http.MethodGet, "/open")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "99", w.Header().Get("RateLimit-Remaining"))
+}
+
+func TestRateLimit_PreAuthLimitCountsFailedLogins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := ratelimit.NewMemoryStore()
+	preAuth := RateLimit(store, RateLimitPolicy{Default: ratelimit.Limit{Requests: 2, Window: time.Minute}, KeyBy: "ip", Name: "preauth"}, zap.NewNop())
+	perUser := RateLimit(store, RateLimitPolicy{Default: ratelimit.Limit{Requests: 100, Window: time.Minute}, KeyBy: "ip"}, zap.NewNop())
+	deny := func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) }
+	r := gin.New()
+	r.GET("/private", preAuth, deny, perUser, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/private", nil))
+		return w
+	}
+	require.Equal(t, http.StatusUnauthorized, do().Code)
+	require.Equal(t, http.StatusUnauthorized, do().Code)
+	require.Equal(t, http.StatusTooManyRequests, do().Code, "failed logins are limited")
+
+	res, err := store.Allow(t.Context(), "default|ip:192.0.2.1", ratelimit.Limit{Requests: 100, Window: time.Minute})
+	require.NoError(t, err)
+	require.Equal(t, 99, res.Remaining, "the pre-auth buckets are separate from the others")
+}
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"go-boilerplate/internal/utils/correlation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRequestIDLen bounds client-supplied request IDs so they cannot bloat logs and headers.
+const maxRequestIDLen = 128
+
+// RequestIDMiddleware puts the request ID and W3C trace context on the request context so
+// they can be logged and forwarded to downstream calls and published messages. A valid
+// incoming X-Request-ID is reused; otherwise a new one is generated. The ID is echoed back
+// in the response.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(correlation.HeaderRequestID)
+		if id == "" || len(id) > maxRequestIDLen {
+			id = correlation.NewRequestID()
+		}
+		ids := correlation.IDs{
+			RequestID:   id,
+			TraceParent: c.GetHeader(correlation.HeaderTraceParent),
+			TraceState:  c.GetHeader(correlation.HeaderTraceState),
+			Transport:   correlation.TransportHTTP,
+		}
+		c.Request = c.Request.WithContext(correlation.WithIDs(c.Request.Context(), ids))
+		c.Header(correlation.HeaderRequestID, id)
+		c.Next()
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"expvar"
 	"go-boilerplate/internal/configs"
 	"go-boilerplate/internal/services"
 	"go-boilerplate/internal/transports/http/handlers"
@@ -10,22 +11,85 @@ import (
 )
 
 // RegisterRoutes sets up all API routes grouped by version/module
-func RegisterRoutes(r *gin.Engine, svcs services.Register, cfg configs.Config) {
+// Route-level authorization comes from the authorizer's policy file; individual routes can
+// also declare requirements in code with middewares.RequirePermissions / RequireRoles.
+func RegisterRoutes(r *gin.Engine, svcs services.Register, cfg configs.Config, deps Deps) {
 	// inisiate ExampleHandler with the ExampleService from services.Register
 	// This allows the handler to use the service for business logic operations.
 	// The handler methods will call the service methods to perform actions like creating, updating, or deleting examples.
 	// This approach promotes separation of concerns and makes the code more maintainable.
 	exampleHandler := handlers.NewExampleHandler(svcs.ExampleService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(svcs.APIKeyService)
+	auditHandler := handlers.NewAuditHandler(svcs.AuditService)
 	// add more handlers if needed
 
 	// Build middleware from config
-	basicAuthMiddleware := middewares.BasicAuthMiddleware(cfg)
+	basicAuth := middewares.NewBasicAuth(cfg)
+	basicAuthMiddleware := basicAuth.Middleware()
+	jwtAuthMiddleware := middewares.JWTAuth(cfg)
+	apiKeyAuthMiddleware := middewares.APIKeyAuth(svcs.APIKeyService, deps.Limiter, deps.Logger)
+	authorizeMiddleware := middewares.Authorize(deps.Authorizer)
+	rateLimiter := middewares.NewRateLimiter(deps.Limiter, middewares.RateLimitPolicyFromConfig(cfg), deps.Logger)
+	rateLimitMiddleware := rateLimiter.Middleware()
+	// Counts every request per client IP before authentication, including failed logins
+	preAuthLimiter := middewares.NewRateLimiter(deps.Limiter, middewares.PreAuthRateLimitPolicyFromConfig(cfg), deps.Logger)
+	preAuthLimitMiddleware := preAuthLimiter.Middleware()
+	idempotencyMiddleware := middewares.Idempotency(deps.Idempotency, deps.Logger)
 	// add more middewares if needed
 
+	// Pick up Basic Auth users and rate limits from reloaded configuration
+	if deps.Config != nil {
+		deps.Config.Subscribe(func(c configs.Config) {
+			basicAuth.Update(c)
+			rateLimiter.SetPolicy(middewares.RateLimitPolicyFromConfig(c))
+			preAuthLimiter.SetPolicy(middewares.PreAuthRateLimitPolicyFromConfig(c))
+		})
+	}
+
+	// Authentication is selectable per route group via config (basic, jwt, apikey or none).
+	authBy := map[string]gin.HandlerFunc{
+		"basic":  basicAuthMiddleware,
+		"jwt":    jwtAuthMiddleware,
+		"apikey": apiKeyAuthMiddleware,
+		"none":   func(c *gin.Context) { c.Next() },
+	}
+	authFor := func(scheme string) gin.HandlerFunc {
+		if h, ok := authBy[scheme]; ok {
+			return h
+		}
+		return basicAuthMiddleware
+	}
+
+	// Creating examples needs example:write wherever callers can be granted it: JWT and API
+	// key credentials carry scopes, and a policy file can grant it through roles. Basic Auth
+	// users without a policy, and groups without authentication, keep full access.
+	exampleWrite := func(c *gin.Context) { c.Next() }
+	if cfg.AuthzPolicyFile != "" || cfg.HTTPExampleAuth == "jwt" || cfg.HTTPExampleAuth == "apikey" {
+		exampleWrite = middewares.RequirePermissions("example:write")
+	}
+
 	// Define the routes for the example module
-	exampleRoute := r.Group("/example", basicAuthMiddleware)
+	exampleRoute := r.Group("/example", preAuthLimitMiddleware, authFor(cfg.HTTPExampleAuth), rateLimitMiddleware, authorizeMiddleware)
 	{
 		// Users
-		exampleRoute.POST("/", exampleHandler.CreateExample)
+		exampleRoute.POST("/", exampleWrite, idempotencyMiddleware, exampleHandler.CreateExample)
+		// Bulk imports; uploads exceed what Idempotency-Key can hash, so it is not applied
+		exampleRoute.POST("/batch", exampleWrite, middewares.MaxBodyBytes(cfg.BatchMaxBytes), exampleHandler.ImportExamples)
+		exampleRoute.GET("/batch/:id", exampleHandler.GetImport)
+	}
+
+	// Runtime metrics published through expvar, e.g. db_connect_attempts_total; callers need
+	// the metrics:read permission
+	r.GET("/debug/vars", preAuthLimitMiddleware, authFor(cfg.HTTPAdminAuth), authorizeMiddleware, middewares.RequirePermissions("metrics:read"), gin.WrapH(expvar.Handler()))
+
+	// Audit trail of entity changes; callers need the audit:read permission
+	r.GET("/audit", preAuthLimitMiddleware, authFor(cfg.HTTPAdminAuth), rateLimitMiddleware, authorizeMiddleware, middewares.RequirePermissions("audit:read"), auditHandler.ListAudit)
+
+	// Admin routes for managing API keys; callers need the apikeys:admin permission
+	adminRoute := r.Group("/admin", preAuthLimitMiddleware, authFor(cfg.HTTPAdminAuth), rateLimitMiddleware, authorizeMiddleware, middewares.RequirePermissions("apikeys:admin"))
+	{
+		adminRoute.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+		adminRoute.POST("/api-keys", apiKeyHandler.MintAPIKey)
+		adminRoute.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
 	}
 }
@@ -0,0 +1,43 @@
+package rabbit
+
+import (
+	"context"
+	"sync"
+)
+
+// FakePublisher is an in-memory MessagePublisher for unit tests. It records every message,
+// with the same message ID and context headers the real Publisher would add.
+type FakePublisher struct {
+	// Err, if set, is called for each message; a non-nil result fails the publish and the
+	// message is not recorded.
+	Err func(msg Message) error
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewFakePublisher creates an empty FakePublisher.
+func NewFakePublisher() *FakePublisher {
+	return &FakePublisher{}
+}
+
+// Publish records msg unless Err rejects it.
+func (f *FakePublisher) Publish(ctx context.Context, msg Message) error {
+	msg = withContextHeaders(ctx, msg)
+	if f.Err != nil {
+		if err := f.Err(msg); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the messages published so far.
+func (f *FakePublisher) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.messages...)
+}
@@ -0,0 +1,348 @@
+package rabbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-boilerplate/internal/configs"
+	"go-boilerplate/internal/utils/correlation"
+	"maps"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNacked is returned when the broker negatively acknowledges a published message.
+	ErrNacked = errors.New("rabbit: message nacked by broker")
+	// ErrUnroutable is returned for mandatory messages that no queue was bound to receive.
+	ErrUnroutable = errors.New("rabbit: message returned as unroutable")
+	// ErrPublisherClosed is returned by Publish after Close.
+	ErrPublisherClosed = errors.New("rabbit: publisher closed")
+)
+
+// Header keys added to every message from the publishing context.
+const (
+	HeaderRequestID   = "x-request-id"
+	HeaderTraceParent = correlation.HeaderTraceParent
+	HeaderTraceState  = correlation.HeaderTraceState
+)
+
+// Message is a single message to publish.
+type Message struct {
+	RoutingKey string
+	Body       []byte
+	// MessageID lets consumers deduplicate redeliveries. A random ID is used when empty.
+	MessageID string
+	Type      string
+	Headers   map[string]any
+}
+
+// MessagePublisher is implemented by *Publisher and *FakePublisher so services can be
+// tested without a broker.
+type MessagePublisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Publisher publishes persistent messages to cfg.RabbitExchange and waits for publisher
+// confirms, so a nil error means the broker has taken responsibility for the message.
+//
+// Up to cfg.RabbitPublisherChannels confirm-mode channels are pooled over one connection,
+// so concurrent publishers do not serialise on a single channel. The connection is opened
+// lazily and re-opened after a failure; a publish that fails because the connection or
+// channel went away is retried once on a fresh channel.
+type Publisher struct {
+	cfg       configs.Config
+	log       *zap.Logger
+	mandatory bool
+	timeout   time.Duration
+	dial      func() (amqpConnection, error)
+
+	// pool holds one slot per channel; a nil slot means the channel must be (re)opened.
+	pool chan *pooledChannel
+
+	mu     sync.Mutex // guards conn and closed
+	conn   amqpConnection
+	closed bool
+}
+
+// amqpConnection, amqpChannel and confirmation are the parts of amqp091 the publisher
+// uses, so it can be tested against a fake broker.
+type amqpConnection interface {
+	Channel() (amqpChannel, error)
+	IsClosed() bool
+	Close() error
+}
+
+type amqpChannel interface {
+	Confirm(noWait bool) error
+	NotifyReturn(c chan amqp.Return) chan amqp.Return
+	PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (confirmation, error)
+	IsClosed() bool
+	Close() error
+}
+
+type confirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+type amqpConn struct{ *amqp.Connection }
+
+func (c amqpConn) Channel() (amqpChannel, error) {
+	ch, err := c.Connection.Channel()
+	if err != nil {
+		return nil, err
+	}
+	return amqpChan{ch}, nil
+}
+
+type amqpChan struct{ *amqp.Channel }
+
+func (c amqpChan) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (confirmation, error) {
+	return c.Channel.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, immediate, msg)
+}
+
+// pooledChannel is a confirm-mode channel whose returns are read continuously by
+// watchReturns, so a burst of them never blocks the connection's dispatcher.
+type pooledChannel struct {
+	ch amqpChannel
+	// claims asks watchReturns for the return of a message ID, if one arrived.
+	claims chan returnClaim
+	// done is closed when the channel's returns stop, i.e. the channel closed.
+	done chan struct{}
+}
+
+type returnClaim struct {
+	messageID string
+	reply     chan *amqp.Return
+}
+
+func newPooledChannel(ch amqpChannel) *pooledChannel {
+	pc := &pooledChannel{ch: ch, claims: make(chan returnClaim), done: make(chan struct{})}
+	go pc.watchReturns(ch.NotifyReturn(make(chan amqp.Return)))
+	return pc
+}
+
+// watchReturns keeps the returns received since the last claim. amqp091 hands a return
+// over before it dispatches the confirm that follows it, so once a message is confirmed
+// its return, if any, has been received here and is answered by the next claim.
+func (pc *pooledChannel) watchReturns(returns <-chan amqp.Return) {
+	defer close(pc.done)
+	pending := map[string]amqp.Return{}
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				return
+			}
+			pending[r.MessageId] = r
+		case c := <-pc.claims:
+			r, ok := pending[c.messageID]
+			// One message is in flight per channel: any other return belongs to a publish
+			// that was abandoned, so it is dropped.
+			clear(pending)
+			if ok {
+				c.reply <- &r
+			} else {
+				c.reply <- nil
+			}
+		}
+	}
+}
+
+// returned reports the return received for messageID, if any.
+func (pc *pooledChannel) returned(messageID string) *amqp.Return {
+	c := returnClaim{messageID: messageID, reply: make(chan *amqp.Return, 1)}
+	select {
+	case pc.claims <- c:
+		return <-c.reply
+	case <-pc.done:
+		return nil
+	}
+}
+
+// NewPublisher creates a Publisher for the exchange in cfg. It does not connect until the
+// first Publish.
+func NewPublisher(cfg configs.Config, log *zap.Logger) *Publisher {
+	size := max(cfg.RabbitPublisherChannels, 1)
+	p := &Publisher{
+		cfg:       cfg,
+		log:       log,
+		mandatory: cfg.RabbitPublishMandatory,
+		timeout:   time.Duration(cfg.RabbitPublishTimeoutMS) * time.Millisecond,
+		pool:      make(chan *pooledChannel, size),
+	}
+	p.dial = func() (amqpConnection, error) {
+		conn, err := amqp.Dial(cfg.RabbitURL)
+		if err != nil {
+			return nil, err
+		}
+		go func(closed <-chan *amqp.Error) {
+			if err := <-closed; err != nil {
+				log.Warn("rabbit publisher connection lost", zap.Error(err))
+			}
+		}(conn.NotifyClose(make(chan *amqp.Error, 1)))
+		return amqpConn{conn}, nil
+	}
+	for range size {
+		p.pool <- nil
+	}
+	return p
+}
+
+// Publish sends msg and blocks until the broker confirms it, the publish timeout expires
+// or ctx is done. The request ID and trace context in ctx are added as message headers.
+func (p *Publisher) Publish(ctx context.Context, msg Message) error {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	msg = withContextHeaders(ctx, msg)
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var pc *pooledChannel
+		pc, err = p.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		err = p.publishOn(ctx, pc, msg)
+		if pc.ch.IsClosed() {
+			// The channel (or connection) died under us: drop it and retry on a fresh one.
+			p.pool <- nil
+			if ctx.Err() == nil && !errors.Is(err, ErrUnroutable) {
+				p.log.Warn("rabbit channel closed during publish, retrying", zap.String("message_id", msg.MessageID), zap.Error(err))
+				continue
+			}
+			return err
+		}
+		p.pool <- pc
+		return err
+	}
+	return err
+}
+
+func (p *Publisher) publishOn(ctx context.Context, pc *pooledChannel, msg Message) error {
+	confirm, err := pc.ch.PublishWithDeferredConfirmWithContext(ctx, p.cfg.RabbitExchange, msg.RoutingKey, p.mandatory, false, amqp.Publishing{
+		ContentType:   "application/json",
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     msg.MessageID,
+		CorrelationId: correlation.RequestID(ctx),
+		Type:          msg.Type,
+		Timestamp:     time.Now().UTC(),
+		Headers:       amqp.Table(msg.Headers),
+		Body:          msg.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("rabbit: publish: %w", err)
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("rabbit: wait for confirm: %w", err)
+	}
+	if !acked {
+		return ErrNacked
+	}
+	// The broker sends basic.return before the ack of an unroutable mandatory message.
+	if r := pc.returned(msg.MessageID); r != nil {
+		return fmt.Errorf("%w: %d %s (exchange %q, routing key %q)", ErrUnroutable, r.ReplyCode, r.ReplyText, r.Exchange, r.RoutingKey)
+	}
+	return nil
+}
+
+// acquire takes a channel slot from the pool, opening a channel if the slot is empty or
+// its channel has been closed. It blocks while all channels are in use.
+func (p *Publisher) acquire(ctx context.Context) (*pooledChannel, error) {
+	var pc *pooledChannel
+	select {
+	case pc = <-p.pool:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if pc != nil && !pc.ch.IsClosed() {
+		return pc, nil
+	}
+	pc, err := p.openChannel()
+	if err != nil {
+		p.pool <- nil
+		return nil, err
+	}
+	return pc, nil
+}
+
+// openChannel opens a confirm-mode channel, dialling the connection if needed.
+func (p *Publisher) openChannel() (*pooledChannel, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPublisherClosed
+	}
+
+	if p.conn == nil || p.conn.IsClosed() {
+		conn, err := p.dial()
+		if err != nil {
+			return nil, fmt.Errorf("rabbit: dial: %w", err)
+		}
+		p.conn = conn
+		p.log.Info("rabbit publisher connected", zap.String("exchange", p.cfg.RabbitExchange))
+	}
+
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("rabbit: open channel: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		return nil, fmt.Errorf("rabbit: enable confirms: %w", err)
+	}
+	return newPooledChannel(ch), nil
+}
+
+// Check connects to the broker if needed and reports whether a channel can be opened.
+// It is used to connect eagerly at startup and by readiness probes.
+func (p *Publisher) Check(ctx context.Context) error {
+	pc, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	p.pool <- pc
+	return nil
+}
+
+// Close closes the connection and all pooled channels. Publish fails afterwards.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// withContextHeaders returns msg with a message ID and the correlation headers from ctx.
+// Headers already set on msg take precedence.
+func withContextHeaders(ctx context.Context, msg Message) Message {
+	if msg.MessageID == "" {
+		msg.MessageID = correlation.NewRequestID()
+	}
+	headers := make(map[string]any, len(msg.Headers)+3)
+	ids := correlation.FromContext(ctx)
+	for k, v := range map[string]string{
+		HeaderRequestID:   ids.RequestID,
+		HeaderTraceParent: ids.TraceParent,
+		HeaderTraceState:  ids.TraceState,
+	} {
+		if v != "" {
+			headers[k] = v
+		}
+	}
+	maps.Copy(headers, msg.Headers)
+	msg.Headers = headers
+	return msg
+}
@@ -0,0 +1,269 @@
+package rabbit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/configs"
+	"go-boilerplate/internal/utils/correlation"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWithContextHeaders(t *testing.T) {
+	ctx := correlation.WithIDs(context.Background(), correlation.IDs{
+		RequestID:   "req-1",
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+
+	msg := withContextHeaders(ctx, Message{RoutingKey: "k", Headers: map[string]any{HeaderRequestID: "explicit", "x": 1}})
+
+	require.NotEmpty(t, msg.MessageID)
+	require.Equal(t, "explicit", msg.Headers[HeaderRequestID])
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", msg.Headers[HeaderTraceParent])
+	require.NotContains(t, msg.Headers, HeaderTraceState)
+	require.Equal(t, 1, msg.Headers["x"])
+}
+
+func TestWithContextHeaders_KeepsMessageID(t *testing.T) {
+	msg := withContextHeaders(context.Background(), Message{MessageID: "m-1"})
+	require.Equal(t, "m-1", msg.MessageID)
+	require.Empty(t, msg.Headers)
+}
+
+// fakeBroker stands in for a RabbitMQ connection. publish decides what the broker does with
+// each message: ack or nack it, return it as unroutable first, or fail the publish.
+type fakeBroker struct {
+	mu       sync.Mutex
+	dials    int
+	channels []*fakeChannel
+	conn     *fakeConn
+	publish  func(ch *fakeChannel, msg amqp.Publishing) (ack, returned bool, err error)
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{publish: func(*fakeChannel, amqp.Publishing) (bool, bool, error) { return true, false, nil }}
+}
+
+func (b *fakeBroker) dial() (amqpConnection, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dials++
+	b.conn = &fakeConn{b: b}
+	return b.conn, nil
+}
+
+// dropConnection closes the connection, as a broker restart would.
+func (b *fakeBroker) dropConnection() {
+	b.mu.Lock()
+	conn := b.conn
+	b.mu.Unlock()
+	_ = conn.Close()
+}
+
+func (b *fakeBroker) stats() (dials, channels int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dials, len(b.channels)
+}
+
+func newTestPublisher(b *fakeBroker, channels int) *Publisher {
+	p := NewPublisher(configs.Config{RabbitExchange: "events", RabbitPublisherChannels: channels, RabbitPublishMandatory: true, RabbitPublishTimeoutMS: 1000}, zap.NewNop())
+	p.dial = b.dial
+	return p
+}
+
+type fakeConn struct {
+	b      *fakeBroker
+	closed atomic.Bool
+}
+
+func (c *fakeConn) Channel() (amqpChannel, error) {
+	if c.closed.Load() {
+		return nil, amqp.ErrClosed
+	}
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	ch := &fakeChannel{b: c.b, conn: c}
+	c.b.channels = append(c.b.channels, ch)
+	return ch, nil
+}
+
+func (c *fakeConn) IsClosed() bool { return c.closed.Load() }
+
+// Close closes the connection and its channels.
+func (c *fakeConn) Close() error {
+	c.closed.Store(true)
+	c.b.mu.Lock()
+	defer c.b.mu.Unlock()
+	for _, ch := range c.b.channels {
+		if ch.conn == c {
+			_ = ch.Close()
+		}
+	}
+	return nil
+}
+
+type fakeChannel struct {
+	b       *fakeBroker
+	conn    *fakeConn
+	mu      sync.Mutex
+	closed  bool
+	returns []chan amqp.Return
+}
+
+func (ch *fakeChannel) Confirm(bool) error { return nil }
+
+func (ch *fakeChannel) NotifyReturn(c chan amqp.Return) chan amqp.Return {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.returns = append(ch.returns, c)
+	return c
+}
+
+func (ch *fakeChannel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (confirmation, error) {
+	if ch.IsClosed() {
+		return nil, amqp.ErrClosed
+	}
+	ack, returned, err := ch.b.publish(ch, msg)
+	if err != nil {
+		return nil, err
+	}
+	if returned {
+		ch.sendReturn(amqp.Return{ReplyCode: 312, ReplyText: "NO_ROUTE", Exchange: exchange, RoutingKey: key, MessageId: msg.MessageId})
+	}
+	return fakeConfirmation(ack), nil
+}
+
+// sendReturn hands r to the listeners before the confirm, like amqp091's dispatcher.
+func (ch *fakeChannel) sendReturn(r amqp.Return) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	for _, c := range ch.returns {
+		c <- r
+	}
+}
+
+func (ch *fakeChannel) IsClosed() bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.closed
+}
+
+func (ch *fakeChannel) Close() error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if !ch.closed {
+		ch.closed = true
+		for _, c := range ch.returns {
+			close(c)
+		}
+	}
+	return nil
+}
+
+type fakeConfirmation bool
+
+func (c fakeConfirmation) WaitContext(context.Context) (bool, error) { return bool(c), nil }
+
+func TestPublisher_ConfirmsOnPooledChannels(t *testing.T) {
+	b := newFakeBroker()
+	p := newTestPublisher(b, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Publish(ctx, Message{RoutingKey: "example.created"}))
+	}
+	dials, channels := b.stats()
+	require.Equal(t, 1, dials)
+	require.Equal(t, 2, channels, "channels are opened up to the pool size, then reused")
+
+	b.publish = func(*fakeChannel, amqp.Publishing) (bool, bool, error) { return false, false, nil }
+	require.ErrorIs(t, p.Publish(ctx, Message{RoutingKey: "example.created"}), ErrNacked)
+}
+
+func TestPublisher_PoolBoundsConcurrentPublishes(t *testing.T) {
+	b := newFakeBroker()
+	entered, release := make(chan struct{}), make(chan struct{})
+	b.publish = func(*fakeChannel, amqp.Publishing) (bool, bool, error) {
+		entered <- struct{}{}
+		<-release
+		return true, false, nil
+	}
+	p := newTestPublisher(b, 2)
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- p.Publish(context.Background(), Message{RoutingKey: "k"}) }()
+		<-entered
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, p.Publish(ctx, Message{RoutingKey: "k"}), context.DeadlineExceeded, "both channels are busy")
+
+	close(release)
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	_, channels := b.stats()
+	require.Equal(t, 2, channels)
+}
+
+func TestPublisher_MandatoryReturns(t *testing.T) {
+	b := newFakeBroker()
+	b.publish = func(ch *fakeChannel, msg amqp.Publishing) (bool, bool, error) {
+		// Returns left over from abandoned publishes arrive in a burst first.
+		for i := 0; i < 5; i++ {
+			ch.sendReturn(amqp.Return{MessageId: fmt.Sprintf("old-%d", i)})
+		}
+		return true, msg.Type == "unroutable", nil
+	}
+	p := newTestPublisher(b, 1)
+
+	require.NoError(t, p.Publish(context.Background(), Message{RoutingKey: "k"}))
+	err := p.Publish(context.Background(), Message{RoutingKey: "nowhere", Type: "unroutable"})
+	require.ErrorIs(t, err, ErrUnroutable)
+	require.ErrorContains(t, err, `312 NO_ROUTE (exchange "events", routing key "nowhere")`)
+}
+
+func TestPublisher_RetriesOnceOnAFreshChannel(t *testing.T) {
+	b := newFakeBroker()
+	var calls atomic.Int32
+	b.publish = func(ch *fakeChannel, msg amqp.Publishing) (bool, bool, error) {
+		if calls.Add(1) == 1 {
+			_ = ch.Close()
+			return false, false, amqp.ErrClosed
+		}
+		return true, false, nil
+	}
+	p := newTestPublisher(b, 1)
+
+	require.NoError(t, p.Publish(context.Background(), Message{RoutingKey: "k"}))
+	_, channels := b.stats()
+	require.Equal(t, 2, channels)
+
+	b.publish = func(ch *fakeChannel, msg amqp.Publishing) (bool, bool, error) {
+		_ = ch.Close()
+		return false, false, amqp.ErrClosed
+	}
+	require.ErrorIs(t, p.Publish(context.Background(), Message{RoutingKey: "k"}), amqp.ErrClosed, "only one retry")
+}
+
+func TestPublisher_ReconnectsAfterConnectionLoss(t *testing.T) {
+	b := newFakeBroker()
+	p := newTestPublisher(b, 1)
+
+	require.NoError(t, p.Publish(context.Background(), Message{RoutingKey: "k"}))
+	b.dropConnection()
+	require.NoError(t, p.Publish(context.Background(), Message{RoutingKey: "k"}))
+	dials, _ := b.stats()
+	require.Equal(t, 2, dials)
+
+	require.NoError(t, p.Close())
+	require.ErrorIs(t, p.Publish(context.Background(), Message{RoutingKey: "k"}), ErrPublisherClosed)
+}
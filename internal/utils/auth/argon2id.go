@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// MaxArgon2idMemoryKiB caps the memory an argon2id hash may ask for, since every login
+// against it allocates that much (256 MiB).
+const MaxArgon2idMemoryKiB = 256 * 1024
+
+// Argon2idHash is a parsed argon2id password hash.
+type Argon2idHash struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	Salt    []byte
+	Key     []byte
+}
+
+// ParseArgon2id parses a PHC-formatted argon2id hash:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+// It rejects parameters argon2 cannot run with (t or p of 0) and memory above
+// MaxArgon2idMemoryKiB.
+func ParseArgon2id(hash string) (Argon2idHash, error) {
+	var h Argon2idHash
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return h, errors.New("argon2id: malformed hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return h, fmt.Errorf("argon2id: unsupported version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.Memory, &h.Time, &h.Threads); err != nil {
+		return h, fmt.Errorf("argon2id: malformed parameters %q", parts[3])
+	}
+	switch {
+	case h.Time < 1 || h.Threads < 1:
+		return h, fmt.Errorf("argon2id: t and p must be at least 1 in %q", parts[3])
+	case h.Memory > MaxArgon2idMemoryKiB:
+		return h, fmt.Errorf("argon2id: m must be at most %d in %q", MaxArgon2idMemoryKiB, parts[3])
+	}
+	var err error
+	if h.Salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return h, errors.New("argon2id: malformed salt")
+	}
+	if h.Key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.Key) == 0 {
+		return h, errors.New("argon2id: malformed key")
+	}
+	return h, nil
+}
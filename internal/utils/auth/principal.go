@@ -0,0 +1,38 @@
+package auth
+
+import "context"
+
+// Principal describes the caller that was authenticated for the current request.
+// It is transport-agnostic so services can read it the same way whether the call
+// came in over HTTP, gRPC or RabbitMQ.
+type Principal struct {
+	// Subject is the username, token subject or API key name.
+	Subject string
+	// Method is the authentication scheme that produced the principal (basic, jwt, ...).
+	Method string
+	// Scopes and Roles granted to the caller.
+	Scopes []string
+	Roles  []string
+	// Claims holds the raw token claims when Method is jwt.
+	Claims map[string]any
+}
+
+// Authentication methods.
+const (
+	MethodBasic  = "basic"
+	MethodJWT    = "jwt"
+	MethodAPIKey = "apikey"
+)
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
@@ -0,0 +1,199 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"go-boilerplate/internal/utils/auth"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrUnauthenticated is returned when the context carries no auth.Principal.
+	ErrUnauthenticated = errors.New("authz: unauthenticated")
+	// ErrForbidden is returned when the principal lacks a required permission or role.
+	ErrForbidden = errors.New("authz: forbidden")
+)
+
+// Rule describes what a caller needs to perform an action.
+// A caller satisfies the rule when it holds every permission in Permissions (directly as a
+// token scope or through one of its roles) and, if Roles is non-empty, at least one of Roles.
+type Rule struct {
+	Permissions []string `yaml:"permissions"`
+	Roles       []string `yaml:"roles"`
+}
+
+// RouteRule binds a Rule to an HTTP method and route pattern (as registered in Gin, e.g. "/example/").
+// Method "*" matches any method.
+type RouteRule struct {
+	Method string `yaml:"method"`
+	Path   string `yaml:"path"`
+	Rule   `yaml:",inline"`
+}
+
+// Policy is the declarative authorization policy, usually loaded from a YAML file:
+//
+//	roles:
+//	  admin: ["example:read", "example:write"]
+//	subjects:
+//	  alice: ["admin"]
+//	routes:
+//	  - method: POST
+//	    path: /example/
+//	    permissions: ["example:write"]
+type Policy struct {
+	// Roles maps a role name to the permissions it grants.
+	Roles map[string][]string `yaml:"roles"`
+	// Subjects assigns roles to principals by subject, for callers whose credentials
+	// carry no roles of their own (e.g. Basic Auth users).
+	Subjects map[string][]string `yaml:"subjects"`
+	// Routes lists per-route requirements enforced by the HTTP transport.
+	Routes []RouteRule `yaml:"routes"`
+}
+
+// LoadPolicy reads a Policy from a YAML file.
+func LoadPolicy(path string) (*Policy, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("authz: read policy %s: %w", path, err)
+	}
+	var p Policy
+	if err := yaml.Unmarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("authz: parse policy %s: %w", path, err)
+	}
+	for i, r := range p.Routes {
+		if r.Path == "" {
+			return nil, fmt.Errorf("authz: policy %s: route #%d has no path", path, i)
+		}
+		p.Routes[i].Method = strings.ToUpper(strings.TrimSpace(r.Method))
+		if p.Routes[i].Method == "" {
+			p.Routes[i].Method = "*"
+		}
+	}
+	return &p, nil
+}
+
+// Authorizer evaluates rules against the principal found in a context and audits denials.
+type Authorizer struct {
+	policy *Policy
+	log    *zap.Logger
+}
+
+// New creates an Authorizer. A nil policy grants permissions through token scopes only.
+func New(policy *Policy, log *zap.Logger) *Authorizer {
+	if policy == nil {
+		policy = &Policy{}
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &Authorizer{policy: policy, log: log}
+}
+
+// RouteRule returns the rule configured for method and path, if any.
+func (a *Authorizer) RouteRule(method, path string) (Rule, bool) {
+	for _, r := range a.policy.Routes {
+		if r.Path == path && (r.Method == "*" || r.Method == method) {
+			return r.Rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Check returns nil when the principal in ctx satisfies rule, ErrUnauthenticated when there
+// is no principal, and ErrForbidden otherwise. Every denial is logged.
+func (a *Authorizer) Check(ctx context.Context, rule Rule) error {
+	p, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		a.audit(nil, rule, "no principal")
+		return ErrUnauthenticated
+	}
+
+	roles := a.rolesOf(p)
+	if len(rule.Roles) > 0 && !slices.ContainsFunc(rule.Roles, func(r string) bool { return slices.Contains(roles, r) }) {
+		a.audit(p, rule, "missing role")
+		return fmt.Errorf("%w: requires one of roles %v", ErrForbidden, rule.Roles)
+	}
+	for _, perm := range rule.Permissions {
+		if !a.grants(p, roles, perm) {
+			a.audit(p, rule, "missing permission "+perm)
+			return fmt.Errorf("%w: requires %s", ErrForbidden, perm)
+		}
+	}
+	return nil
+}
+
+func (a *Authorizer) rolesOf(p *auth.Principal) []string {
+	roles := slices.Clone(p.Roles)
+	return append(roles, a.policy.Subjects[p.Subject]...)
+}
+
+func (a *Authorizer) grants(p *auth.Principal, roles []string, perm string) bool {
+	if slices.Contains(p.Scopes, perm) {
+		return true
+	}
+	for _, role := range roles {
+		if slices.Contains(a.policy.Roles[role], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authorizer) audit(p *auth.Principal, rule Rule, reason string) {
+	fields := []zap.Field{
+		zap.String("reason", reason),
+		zap.Strings("required_permissions", rule.Permissions),
+		zap.Strings("required_roles", rule.Roles),
+	}
+	if p != nil {
+		fields = append(fields, zap.String("subject", p.Subject), zap.String("auth_method", p.Method))
+	}
+	a.log.Warn("authorization denied", fields...)
+}
+
+type authorizerKey struct{}
+
+// WithAuthorizer returns a copy of ctx carrying a. Transports attach the Authorizer once per
+// request or message so services can call Require without holding a reference to it.
+func WithAuthorizer(ctx context.Context, a *Authorizer) context.Context {
+	return context.WithValue(ctx, authorizerKey{}, a)
+}
+
+var defaultAuthorizer atomic.Pointer[Authorizer]
+
+// SetDefault sets the Authorizer returned for contexts that carry none, e.g. in transports
+// that do not attach one. The application sets it at startup so those denials are logged too.
+func SetDefault(a *Authorizer) {
+	defaultAuthorizer.Store(a)
+}
+
+// FromContext returns the Authorizer stored in ctx, or the default set with SetDefault.
+// Without a default it returns one that only honours token scopes and logs nothing.
+func FromContext(ctx context.Context) *Authorizer {
+	if a, ok := ctx.Value(authorizerKey{}).(*Authorizer); ok && a != nil {
+		return a
+	}
+	if a := defaultAuthorizer.Load(); a != nil {
+		return a
+	}
+	return New(nil, nil)
+}
+
+// Require checks that the caller in ctx holds every permission in perms.
+// It behaves the same under HTTP, gRPC and RabbitMQ as long as the transport has stored an
+// auth.Principal (and optionally an Authorizer) on the context:
+//
+//	if err := authz.Require(ctx, "example:write"); err != nil {
+//		return 0, err
+//	}
+func Require(ctx context.Context, perms ...string) error {
+	return FromContext(ctx).Check(ctx, Rule{Permissions: perms})
+}
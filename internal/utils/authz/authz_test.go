@@ -0,0 +1,116 @@
+package authz
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-boilerplate/internal/utils/auth"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const testPolicy = `
+roles:
+  admin: ["example:read", "example:write"]
+  viewer: ["example:read"]
+subjects:
+  alice: ["admin"]
+routes:
+  - method: post
+    path: /example/
+    permissions: ["example:write"]
+  - path: /admin
+    roles: ["admin"]
+`
+
+func loadTestPolicy(t *testing.T) *Policy {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testPolicy), 0o600))
+	p, err := LoadPolicy(path)
+	require.NoError(t, err)
+	return p
+}
+
+func TestAuthorizer_Check(t *testing.T) {
+	a := New(loadTestPolicy(t), zap.NewNop())
+
+	tests := []struct {
+		name    string
+		p       *auth.Principal
+		rule    Rule
+		wantErr error
+	}{
+		{"no principal", nil, Rule{Permissions: []string{"example:read"}}, ErrUnauthenticated},
+		{"scope grants", &auth.Principal{Subject: "svc", Scopes: []string{"example:write"}}, Rule{Permissions: []string{"example:write"}}, nil},
+		{"token role grants", &auth.Principal{Subject: "bob", Roles: []string{"viewer"}}, Rule{Permissions: []string{"example:read"}}, nil},
+		{"token role lacks", &auth.Principal{Subject: "bob", Roles: []string{"viewer"}}, Rule{Permissions: []string{"example:write"}}, ErrForbidden},
+		{"subject role grants", &auth.Principal{Subject: "alice"}, Rule{Permissions: []string{"example:write"}}, nil},
+		{"required role present", &auth.Principal{Subject: "alice"}, Rule{Roles: []string{"admin"}}, nil},
+		{"required role missing", &auth.Principal{Subject: "bob", Roles: []string{"viewer"}}, Rule{Roles: []string{"admin"}}, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.p != nil {
+				ctx = auth.WithPrincipal(ctx, tt.p)
+			}
+			err := a.Check(ctx, tt.rule)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizer_RouteRule(t *testing.T) {
+	a := New(loadTestPolicy(t), nil)
+
+	rule, ok := a.RouteRule("POST", "/example/")
+	require.True(t, ok)
+	require.Equal(t, []string{"example:write"}, rule.Permissions)
+
+	_, ok = a.RouteRule("GET", "/example/")
+	require.False(t, ok)
+
+	rule, ok = a.RouteRule("DELETE", "/admin")
+	require.True(t, ok)
+	require.Equal(t, []string{"admin"}, rule.Roles)
+}
+
+func TestRequire_UsesAuthorizerFromContextAndAuditsDenials(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	a := New(loadTestPolicy(t), zap.New(core))
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "alice", Method: auth.MethodBasic})
+	require.ErrorIs(t, Require(ctx, "example:write"), ErrForbidden, "no authorizer: only token scopes count")
+
+	ctx = WithAuthorizer(ctx, a)
+	require.NoError(t, Require(ctx, "example:write"))
+	require.ErrorIs(t, Require(ctx, "billing:read"), ErrForbidden)
+
+	entries := logs.FilterMessage("authorization denied").All()
+	require.Len(t, entries, 1)
+	require.Equal(t, "alice", entries[0].ContextMap()["subject"])
+}
+
+func TestRequire_FallbackAuthorizerAuditsDenials(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	SetDefault(New(nil, zap.New(core)))
+	t.Cleanup(func() { SetDefault(nil) })
+
+	require.ErrorIs(t, Require(context.Background(), "example:write"), ErrUnauthenticated)
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "bob", Method: auth.MethodBasic})
+	require.ErrorIs(t, Require(ctx, "example:write"), ErrForbidden)
+
+	entries := logs.FilterMessage("authorization denied").All()
+	require.Len(t, entries, 2)
+	require.Equal(t, "no principal", entries[0].ContextMap()["reason"])
+	require.Equal(t, "bob", entries[1].ContextMap()["subject"])
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"go-boilerplate/internal/utils/metrics"
+)
+
+// Cache stores byte values under string keys for a limited time.
+// Implementations are safe for concurrent use. A cache is an optimisation: callers should
+// treat errors as misses rather than fail.
+type Cache interface {
+	// Get returns the value stored under key and whether there was one.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for ttl; a ttl <= 0 means no expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys; missing keys are ignored.
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// lookups counts cache lookups labelled "<name>:hit", "<name>:miss" or "<name>:error".
+var lookups = metrics.NewCounterVec("cache_lookups_total")
+
+// Lookup outcomes recorded by Observe.
+const (
+	Hit   = "hit"
+	Miss  = "miss"
+	Error = "error"
+)
+
+// Observe records the outcome of a lookup in the cache_lookups_total metric under name,
+// e.g. Observe("example", Hit).
+func Observe(name, outcome string) {
+	lookups.Inc(name + ":" + outcome)
+}
+
+// Lookups returns the number of lookups recorded for name with outcome.
+func Lookups(name, outcome string) int64 {
+	return lookups.Value(name + ":" + outcome)
+}
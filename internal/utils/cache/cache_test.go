@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaches_SetGetDelete(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	for name, c := range map[string]Cache{
+		"memory": NewMemoryCache(10),
+		"redis":  NewRedisCache(client, "cache:"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, ok, err := c.Get(ctx, "a")
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+			require.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+			v, ok, err := c.Get(ctx, "a")
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, []byte("1"), v)
+
+			require.NoError(t, c.Delete(ctx, "a", "b", "missing"))
+			_, ok, _ = c.Get(ctx, "a")
+			require.False(t, ok)
+			_, ok, _ = c.Get(ctx, "b")
+			require.False(t, ok)
+		})
+	}
+	require.False(t, mr.Exists("cache:a"), "keys are prefixed")
+}
+
+func TestRedisCache_TTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	c := NewRedisCache(client, "cache:")
+
+	require.NoError(t, c.Set(context.Background(), "a", []byte("1"), time.Second))
+	require.Equal(t, time.Second, mr.TTL("cache:a"))
+	mr.FastForward(time.Second)
+	_, ok, err := c.Get(context.Background(), "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestMemoryCache_ExpiresEntries(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := NewMemoryCache(10)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Second))
+	now = now.Add(999 * time.Millisecond)
+	_, ok, _ := c.Get(ctx, "a")
+	require.True(t, ok)
+	now = now.Add(time.Millisecond)
+	_, ok, _ = c.Get(ctx, "a")
+	require.False(t, ok)
+	require.Equal(t, 0, c.Len(), "expired entries are dropped on access")
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMemoryCache(2)
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+	_, _, _ = c.Get(ctx, "a") // b is now the least recently used
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ := c.Get(ctx, "b")
+	require.False(t, ok)
+	_, ok, _ = c.Get(ctx, "a")
+	require.True(t, ok)
+	_, ok, _ = c.Get(ctx, "c")
+	require.True(t, ok)
+	require.Equal(t, 2, c.Len())
+}
+
+func TestMemoryCache_CopiesValues(t *testing.T) {
+	c := NewMemoryCache(1)
+	v := []byte("abc")
+	require.NoError(t, c.Set(context.Background(), "a", v, 0))
+	v[0] = 'x'
+	got, _, _ := c.Get(context.Background(), "a")
+	require.Equal(t, []byte("abc"), got)
+}
+
+func TestGroup_CollapsesConcurrentCalls(t *testing.T) {
+	var (
+		g       Group
+		calls   atomic.Int32
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+		results = make([]any, 10)
+	)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _, _ = g.Do("k", func() (any, error) {
+				calls.Add(1)
+				<-release
+				return "v", nil
+			})
+		}()
+	}
+	// Let the callers pile up behind the first one before it returns.
+	require.Eventually(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		c, ok := g.calls["k"]
+		return ok && c.dups == len(results)-1
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls.Load())
+	for _, r := range results {
+		require.Equal(t, "v", r)
+	}
+
+	// Once done, the next call runs again.
+	v, err, shared := g.Do("k", func() (any, error) { return "w", nil })
+	require.NoError(t, err)
+	require.False(t, shared)
+	require.Equal(t, "w", v)
+}
+
+func TestObserve(t *testing.T) {
+	before := Lookups("test", Hit)
+	Observe("test", Hit)
+	require.Equal(t, before+1, Lookups("test", Hit))
+}
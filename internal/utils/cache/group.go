@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+)
+
+// errPanicked is returned to the callers waiting on a call whose fn panicked.
+var errPanicked = errors.New("cache: call panicked")
+
+// Group collapses concurrent calls for the same key into one: while a call for key is in
+// flight, later callers wait for it and receive its result instead of making their own.
+// The zero value is ready to use.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	wg   sync.WaitGroup
+	val  any
+	err  error
+	dups int
+}
+
+// Do calls fn for key unless a call for key is already in flight, and returns its result.
+// shared reports whether the result was given to more than one caller.
+func (g *Group) Do(key string, fn func() (any, error)) (v any, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*call{}
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := &call{err: errPanicked}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.val, c.err = fn()
+
+	g.mu.Lock()
+	shared = c.dups > 0
+	g.mu.Unlock()
+	return c.val, c.err, shared
+}
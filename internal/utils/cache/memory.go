@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries bounds a MemoryCache created with maxEntries <= 0.
+const DefaultMaxEntries = 10000
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // zero: never
+}
+
+// MemoryCache is an in-process cache that evicts the least recently used entry once it
+// holds maxEntries, and drops entries when their TTL passes. Entries are per instance, so
+// replicas may serve different values until the TTL runs out.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List // front is most recently used
+	items      map[string]*list.Element
+	now        func() time.Time
+}
+
+// NewMemoryCache creates an empty MemoryCache holding up to maxEntries values.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &MemoryCache{maxEntries: maxEntries, ll: list.New(), items: map[string]*list.Element{}, now: time.Now}
+}
+
+// Get returns the value stored under key.
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*memoryEntry)
+	if !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set stores a copy of value under key for ttl.
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &memoryEntry{key: key, value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expiresAt = c.now().Add(ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return nil
+	}
+	c.items[key] = c.ll.PushFront(e)
+	for c.ll.Len() > c.maxEntries {
+		c.remove(c.ll.Back())
+	}
+	return nil
+}
+
+// Delete removes keys.
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range keys {
+		if el, ok := c.items[k]; ok {
+			c.remove(el)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries held, including expired ones not yet dropped.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *MemoryCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*memoryEntry).key)
+}
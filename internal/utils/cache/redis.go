@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache stores values in Redis (or any server speaking the Redis protocol), so all
+// replicas share them and an invalidation is seen everywhere.
+type RedisCache struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisCache creates a RedisCache. Keys are namespaced with prefix (e.g. "cache:").
+func NewRedisCache(client redis.Cmdable, prefix string) *RedisCache {
+	return &RedisCache{client: client, prefix: prefix}
+}
+
+// Get returns the value stored under key.
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("cache: redis: %w", err)
+	}
+	return b, true, nil
+}
+
+// Set stores value under key for ttl.
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+	if err := c.client.Set(ctx, c.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("cache: redis: %w", err)
+	}
+	return nil
+}
+
+// Delete removes keys.
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	full := make([]string, len(keys))
+	for i, k := range keys {
+		full[i] = c.prefix + k
+	}
+	if err := c.client.Del(ctx, full...).Err(); err != nil {
+		return fmt.Errorf("cache: redis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header names used to propagate correlation data across HTTP and message boundaries.
+// TraceParent and TraceState follow the W3C Trace Context specification.
+const (
+	HeaderRequestID   = "X-Request-ID"
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+)
+
+// Transports that set IDs, recorded so that work can be traced back to where it came from.
+const (
+	TransportHTTP   = "http"
+	TransportRabbit = "rabbit"
+)
+
+// IDs holds the correlation identifiers of the current unit of work.
+type IDs struct {
+	RequestID   string
+	TraceParent string
+	TraceState  string
+	// Transport is the transport the unit of work arrived on, e.g. TransportHTTP; empty for
+	// background jobs.
+	Transport string
+}
+
+type idsKey struct{}
+
+// WithIDs returns a copy of ctx carrying ids.
+func WithIDs(ctx context.Context, ids IDs) context.Context {
+	return context.WithValue(ctx, idsKey{}, ids)
+}
+
+// FromContext returns the identifiers stored in ctx; the zero value if none were set.
+func FromContext(ctx context.Context) IDs {
+	ids, _ := ctx.Value(idsKey{}).(IDs)
+	return ids
+}
+
+// RequestID returns the request ID stored in ctx, or "".
+func RequestID(ctx context.Context) string {
+	return FromContext(ctx).RequestID
+}
+
+// NewRequestID returns a random 128-bit hex identifier.
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,114 @@
+// Package health tracks the state of the dependencies an application runs with, for
+// readiness probes.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status values reported by Registry.Check.
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not_ready"
+)
+
+// CheckFunc reports whether a dependency is usable; a nil error means it is.
+type CheckFunc func(ctx context.Context) error
+
+// Registry holds the readiness checks of an application. The zero value is not usable; use
+// NewRegistry.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[string]check
+}
+
+type check struct {
+	required bool
+	fn       CheckFunc
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{checks: map[string]check{}}
+}
+
+// Register adds or replaces the check for name. A failing required check makes the
+// application not ready; a failing optional one only makes it degraded.
+func (r *Registry) Register(name string, required bool, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check{required: required, fn: fn}
+}
+
+// Component is the state of one dependency in a Report.
+type Component struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Required bool   `json:"required"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the outcome of running every check.
+type Report struct {
+	// Status is StatusReady, StatusDegraded (an optional dependency is down) or
+	// StatusNotReady (a required dependency is down).
+	Status     string      `json:"status"`
+	Components []Component `json:"components"`
+}
+
+// Ready reports whether the application can serve traffic, possibly degraded.
+func (r Report) Ready() bool {
+	return r.Status != StatusNotReady
+}
+
+// Check runs every check concurrently, each bounded by timeout, and returns the report
+// with components sorted by name.
+func (r *Registry) Check(ctx context.Context, timeout time.Duration) Report {
+	r.mu.RLock()
+	checks := make(map[string]check, len(r.checks))
+	for name, c := range r.checks {
+		checks[name] = c
+	}
+	r.mu.RUnlock()
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		rep = Report{Status: StatusReady, Components: make([]Component, 0, len(checks))}
+	)
+	for name, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			comp := Component{Name: name, Status: StatusUp, Required: c.required}
+			if err := c.fn(cctx); err != nil {
+				comp.Status, comp.Error = StatusDown, err.Error()
+			}
+			mu.Lock()
+			rep.Components = append(rep.Components, comp)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(rep.Components, func(i, j int) bool { return rep.Components[i].Name < rep.Components[j].Name })
+	for _, comp := range rep.Components {
+		if comp.Status == StatusUp {
+			continue
+		}
+		if comp.Required {
+			rep.Status = StatusNotReady
+			break
+		}
+		rep.Status = StatusDegraded
+	}
+	return rep
+}
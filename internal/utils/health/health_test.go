@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ok(context.Context) error { return nil }
+
+func down(context.Context) error { return errors.New("connection refused") }
+
+func TestRegistry_Check(t *testing.T) {
+	cases := []struct {
+		name   string
+		setup  func(r *Registry)
+		status string
+		ready  bool
+	}{
+		{"empty", func(*Registry) {}, StatusReady, true},
+		{"all up", func(r *Registry) {
+			r.Register("database", true, ok)
+			r.Register("rabbit", false, ok)
+		}, StatusReady, true},
+		{"optional down", func(r *Registry) {
+			r.Register("database", true, ok)
+			r.Register("rabbit", false, down)
+		}, StatusDegraded, true},
+		{"required down", func(r *Registry) {
+			r.Register("database", true, down)
+			r.Register("rabbit", false, down)
+		}, StatusNotReady, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRegistry()
+			tc.setup(r)
+			rep := r.Check(context.Background(), time.Second)
+			require.Equal(t, tc.status, rep.Status)
+			require.Equal(t, tc.ready, rep.Ready())
+		})
+	}
+}
+
+func TestRegistry_CheckReportsComponents(t *testing.T) {
+	r := NewRegistry()
+	r.Register("rabbit", false, down)
+	r.Register("database", true, ok)
+	r.Register("slow", false, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	rep := r.Check(context.Background(), 20*time.Millisecond)
+	require.Equal(t, []Component{
+		{Name: "database", Status: StatusUp, Required: true},
+		{Name: "rabbit", Status: StatusDown, Error: "connection refused"},
+		{Name: "slow", Status: StatusDown, Error: context.DeadlineExceeded.Error()},
+	}, rep.Components)
+}
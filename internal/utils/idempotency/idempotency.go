@@ -0,0 +1,72 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrNotClaimed is returned by Complete and Release when the key is not held by the caller,
+// e.g. because its lock lapsed and another request took it over.
+var ErrNotClaimed = errors.New("idempotency: key not claimed")
+
+// Record is what a Store keeps for one idempotency key.
+type Record struct {
+	Key         string
+	RequestHash string
+	// Token identifies the claim of the request holding the key.
+	Token string
+	// Completed is false while the first request is still being processed.
+	Completed   bool
+	Status      int
+	ContentType string
+	Body        []byte
+	LockedUntil time.Time
+	ExpiresAt   time.Time
+}
+
+// Store persists idempotency records. Implementations must be safe for concurrent use
+// and Begin must be atomic across all application instances sharing the store.
+type Store interface {
+	// Begin claims key for a request whose payload hashes to requestHash. When the key is
+	// new, expired, or held by an in-flight request whose lock has lapsed, it is claimed and
+	// (nil, token) is returned, token being a new lock token for Complete and Release.
+	// Otherwise the existing record is returned with an empty token.
+	Begin(ctx context.Context, key, requestHash string) (existing *Record, token string, err error)
+	// Complete stores the response for key while it is still claimed with token, so retries
+	// can replay it.
+	Complete(ctx context.Context, key, token string, status int, contentType string, body []byte) error
+	// Release drops key without a response while it is still claimed with token, so the
+	// request may be retried.
+	Release(ctx context.Context, key, token string) error
+	// Purge deletes records that expired before now and returns how many were removed.
+	Purge(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Options controls record lifetimes.
+type Options struct {
+	// TTL is how long a completed response is kept for replay.
+	TTL time.Duration
+	// LockTimeout is how long an in-flight request holds its key before another request
+	// may take it over (e.g. after a crash).
+	LockTimeout time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.TTL <= 0 {
+		o.TTL = 24 * time.Hour
+	}
+	if o.LockTimeout <= 0 {
+		o.LockTimeout = time.Minute
+	}
+	return o
+}
+
+// newToken returns a random lock token.
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,83 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps records in process memory. It is meant for tests and single-instance
+// deployments; records are lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	opts    Options
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore(opts Options) *MemoryStore {
+	return &MemoryStore{records: map[string]*Record{}, opts: opts.withDefaults(), now: time.Now}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, requestHash string) (*Record, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if rec, ok := s.records[key]; ok && now.Before(rec.ExpiresAt) && (rec.Completed || now.Before(rec.LockedUntil)) {
+		cp := *rec
+		return &cp, "", nil
+	}
+	token := newToken()
+	s.records[key] = &Record{
+		Key:         key,
+		RequestHash: requestHash,
+		Token:       token,
+		LockedUntil: now.Add(s.opts.LockTimeout),
+		ExpiresAt:   now.Add(s.opts.TTL),
+	}
+	return nil, token, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key, token string, status int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[key]
+	if !ok || rec.Completed || rec.Token != token {
+		return ErrNotClaimed
+	}
+	rec.Completed = true
+	rec.Status = status
+	rec.ContentType = contentType
+	rec.Body = append([]byte(nil), body...)
+	rec.ExpiresAt = s.now().Add(s.opts.TTL)
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[key]
+	if !ok || rec.Completed || rec.Token != token {
+		return ErrNotClaimed
+	}
+	delete(s.records, key)
+	return nil
+}
+
+func (s *MemoryStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for k, rec := range s.records {
+		if !now.Before(rec.ExpiresAt) {
+			delete(s.records, k)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,35 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_LapsedClaimCannotTouchTheNewOne(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore(Options{TTL: time.Hour, LockTimeout: time.Minute})
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, first, err := s.Begin(ctx, "k", "h")
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+
+	now = now.Add(2 * time.Minute)
+	_, second, err := s.Begin(ctx, "k", "h")
+	require.NoError(t, err)
+	require.NotEmpty(t, second, "the lapsed lock is taken over")
+	require.NotEqual(t, first, second)
+
+	require.ErrorIs(t, s.Release(ctx, "k", first), ErrNotClaimed)
+	require.ErrorIs(t, s.Complete(ctx, "k", first, 201, "application/json", []byte(`{"id":1}`)), ErrNotClaimed)
+
+	require.NoError(t, s.Complete(ctx, "k", second, 201, "application/json", []byte(`{"id":2}`)))
+	rec, token, err := s.Begin(ctx, "k", "h")
+	require.NoError(t, err)
+	require.Empty(t, token)
+	require.Equal(t, `{"id":2}`, string(rec.Body))
+}
@@ -0,0 +1,120 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDuplicateEntry is the MySQL error number for a primary/unique key violation.
+const mysqlDuplicateEntry = 1062
+
+// SQLStore keeps records in the idempotency_keys table. The primary key makes Begin atomic
+// across instances, and lock_token keeps a request whose lock lapsed from completing or
+// releasing the claim of the request that took over:
+//
+//	CREATE TABLE idempotency_keys (
+//	  id_key       CHAR(64)     NOT NULL PRIMARY KEY,
+//	  request_hash CHAR(64)     NOT NULL,
+//	  lock_token   CHAR(32)     NOT NULL,
+//	  completed    BOOLEAN      NOT NULL DEFAULT FALSE,
+//	  status       INT          NOT NULL DEFAULT 0,
+//	  content_type VARCHAR(255) NOT NULL DEFAULT '',
+//	  body         MEDIUMBLOB   NULL,
+//	  locked_until DATETIME(6)  NOT NULL,
+//	  expires_at   DATETIME(6)  NOT NULL,
+//	  INDEX idx_idempotency_keys_expires_at (expires_at)
+//	);
+//
+// Tables created before lock_token existed are upgraded with:
+//
+//	ALTER TABLE idempotency_keys ADD COLUMN lock_token CHAR(32) NOT NULL DEFAULT '' AFTER request_hash;
+type SQLStore struct {
+	db       *sql.DB
+	opts     Options
+	now      func() time.Time
+	newToken func() string
+}
+
+// NewSQLStore creates a SQLStore on db.
+func NewSQLStore(db *sql.DB, opts Options) *SQLStore {
+	return &SQLStore{db: db, opts: opts.withDefaults(), now: time.Now, newToken: newToken}
+}
+
+func (s *SQLStore) Begin(ctx context.Context, key, requestHash string) (*Record, string, error) {
+	now := s.now().UTC()
+	lockedUntil := now.Add(s.opts.LockTimeout)
+	expiresAt := now.Add(s.opts.TTL)
+	token := s.newToken()
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO idempotency_keys (id_key, request_hash, lock_token, locked_until, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		key, requestHash, token, lockedUntil, expiresAt,
+	)
+	if err == nil {
+		return nil, token, nil
+	}
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) || myErr.Number != mysqlDuplicateEntry {
+		return nil, "", fmt.Errorf("idempotency: claim: %w", err)
+	}
+
+	// The key exists: take it over if it has expired or its in-flight request was abandoned.
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE idempotency_keys
+		    SET request_hash = ?, lock_token = ?, completed = FALSE, status = 0, content_type = '', body = NULL, locked_until = ?, expires_at = ?
+		  WHERE id_key = ? AND (expires_at <= ? OR (completed = FALSE AND locked_until <= ?))`,
+		requestHash, token, lockedUntil, expiresAt, key, now, now,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("idempotency: take over: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 1 {
+		return nil, token, nil
+	}
+
+	rec := Record{Key: key}
+	err = s.db.QueryRowContext(ctx,
+		`SELECT request_hash, completed, status, content_type, body, locked_until, expires_at FROM idempotency_keys WHERE id_key = ?`,
+		key,
+	).Scan(&rec.RequestHash, &rec.Completed, &rec.Status, &rec.ContentType, &rec.Body, &rec.LockedUntil, &rec.ExpiresAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("idempotency: load: %w", err)
+	}
+	return &rec, "", nil
+}
+
+func (s *SQLStore) Complete(ctx context.Context, key, token string, status int, contentType string, body []byte) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET completed = TRUE, status = ?, content_type = ?, body = ?, expires_at = ? WHERE id_key = ? AND lock_token = ? AND completed = FALSE`,
+		status, contentType, body, s.now().UTC().Add(s.opts.TTL), key, token,
+	)
+	return claimedResult(res, err)
+}
+
+func (s *SQLStore) Release(ctx context.Context, key, token string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE id_key = ? AND lock_token = ? AND completed = FALSE`, key, token)
+	return claimedResult(res, err)
+}
+
+func (s *SQLStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("idempotency: purge: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func claimedResult(res sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("idempotency: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotClaimed
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLStore(t *testing.T) (*SQLStore, sqlmock.Sqlmock, time.Time) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSQLStore(db, Options{TTL: time.Hour, LockTimeout: time.Minute})
+	s.now = func() time.Time { return now }
+	s.newToken = func() string { return "t1" }
+	return s, mock, now
+}
+
+func TestSQLStore_BeginClaimsNewKey(t *testing.T) {
+	s, mock, now := newTestSQLStore(t)
+
+	mock.ExpectExec(`INSERT INTO idempotency_keys \(id_key, request_hash, lock_token, locked_until, expires_at\) VALUES \(\?, \?, \?, \?, \?\)`).
+		WithArgs("k", "h", "t1", now.Add(time.Minute), now.Add(time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec, token, err := s.Begin(context.Background(), "k", "h")
+	require.NoError(t, err)
+	require.Equal(t, "t1", token)
+	require.Nil(t, rec)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLStore_BeginReturnsExistingRecord(t *testing.T) {
+	s, mock, now := newTestSQLStore(t)
+
+	mock.ExpectExec(`INSERT INTO idempotency_keys`).
+		WillReturnError(&mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry"})
+	mock.ExpectExec(`UPDATE idempotency_keys\s+SET request_hash = \?, lock_token = \?`).
+		WithArgs("h", "t1", now.Add(time.Minute), now.Add(time.Hour), "k", now, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT request_hash, completed, status, content_type, body, locked_until, expires_at FROM idempotency_keys WHERE id_key = \?`).
+		WithArgs("k").
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "completed", "status", "content_type", "body", "locked_until", "expires_at"}).
+			AddRow("h", true, 201, "application/json", []byte(`{"id":1}`), now, now.Add(time.Hour)))
+
+	rec, token, err := s.Begin(context.Background(), "k", "h")
+	require.NoError(t, err)
+	require.Empty(t, token)
+	require.True(t, rec.Completed)
+	require.Equal(t, 201, rec.Status)
+	require.Equal(t, `{"id":1}`, string(rec.Body))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLStore_CompleteRequiresClaim(t *testing.T) {
+	s, mock, _ := newTestSQLStore(t)
+
+	mock.ExpectExec(`UPDATE idempotency_keys SET completed = TRUE, .* WHERE id_key = \? AND lock_token = \? AND completed = FALSE`).
+		WithArgs(201, "application/json", []byte(`{}`), sqlmock.AnyArg(), "k", "stale").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := s.Complete(context.Background(), "k", "stale", 201, "application/json", []byte(`{}`))
+	require.ErrorIs(t, err, ErrNotClaimed)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLStore_ReleaseRequiresToken(t *testing.T) {
+	s, mock, _ := newTestSQLStore(t)
+
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE id_key = \? AND lock_token = \? AND completed = FALSE`).
+		WithArgs("k", "stale").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.ErrorIs(t, s.Release(context.Background(), "k", "stale"), ErrNotClaimed)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
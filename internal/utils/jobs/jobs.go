@@ -0,0 +1,233 @@
+// Package jobs runs long requests in the background and keeps their status for polling.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status is the state of a job.
+type Status string
+
+// Job states.
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Errors returned by Runner.
+var (
+	// ErrBusy is returned by Submit when MaxQueued jobs are already waiting to run.
+	ErrBusy = errors.New("jobs: too many jobs queued")
+	// ErrClosed is returned by Submit once Shutdown has been called.
+	ErrClosed = errors.New("jobs: runner is shut down")
+)
+
+// Job is a snapshot of a submitted job.
+type Job struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Owner  string `json:"owner"`
+	Status Status `json:"status"`
+	// Done counts the units of work finished so far, out of Total.
+	Done       int        `json:"done"`
+	Total      int        `json:"total"`
+	Result     any        `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Func does the work of a job. It reports progress by calling progress with the number
+// of units done so far and should return early when ctx is cancelled.
+type Func func(ctx context.Context, progress func(done int)) (any, error)
+
+// Options tunes a Runner.
+type Options struct {
+	// Workers is how many jobs run at the same time; defaults to 2.
+	Workers int
+	// MaxQueued is how many jobs may wait for a worker; defaults to 100.
+	MaxQueued int
+	// Retention is how long a finished job can still be looked up; defaults to 1h.
+	Retention time.Duration
+}
+
+// Runner runs jobs in the background on a fixed number of workers. Jobs and their results
+// live in memory, so they are only known to the replica that accepted them and are lost
+// on restart.
+type Runner struct {
+	opts  Options
+	now   func() time.Time
+	slots chan struct{}
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	cancels map[string]context.CancelFunc
+	queued  int
+	closed  bool
+}
+
+// NewRunner returns a Runner with no jobs.
+func NewRunner(opts Options) *Runner {
+	if opts.Workers <= 0 {
+		opts.Workers = 2
+	}
+	if opts.MaxQueued <= 0 {
+		opts.MaxQueued = 100
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = time.Hour
+	}
+	return &Runner{
+		opts:    opts,
+		now:     func() time.Time { return time.Now().UTC() },
+		slots:   make(chan struct{}, opts.Workers),
+		jobs:    map[string]*Job{},
+		cancels: map[string]context.CancelFunc{},
+	}
+}
+
+// Submit queues fn as a job of kind on behalf of owner, with total units of work, and
+// returns it. fn runs with the values of ctx, such as the principal and request ID, but
+// not its deadline or cancellation: the job outlives the request that submitted it.
+func (r *Runner) Submit(ctx context.Context, kind, owner string, total int, fn Func) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return Job{}, ErrClosed
+	}
+	if r.queued >= r.opts.MaxQueued {
+		return Job{}, ErrBusy
+	}
+	r.prune()
+	j := &Job{ID: id, Kind: kind, Owner: owner, Status: StatusQueued, Total: total, CreatedAt: r.now()}
+	jctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r.jobs[id] = j
+	r.cancels[id] = cancel
+	r.queued++
+
+	r.wg.Add(1)
+	go r.run(jctx, j, fn)
+	return *j, nil
+}
+
+func (r *Runner) run(ctx context.Context, j *Job, fn Func) {
+	defer r.wg.Done()
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+	}
+
+	r.mu.Lock()
+	r.queued--
+	started := r.now()
+	j.Status, j.StartedAt = StatusRunning, &started
+	r.mu.Unlock()
+
+	var (
+		res any
+		err = ctx.Err() // cancelled while queued
+	)
+	if err == nil {
+		res, err = r.call(ctx, fn, func(done int) {
+			r.mu.Lock()
+			j.Done = done
+			r.mu.Unlock()
+		})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	finished := r.now()
+	j.FinishedAt, j.Result = &finished, res
+	j.Status = StatusSucceeded
+	if err != nil {
+		j.Status, j.Error = StatusFailed, err.Error()
+	}
+	r.cancels[j.ID]()
+	delete(r.cancels, j.ID)
+}
+
+// call runs fn, turning a panic into an error so that one bad job cannot take the
+// process down.
+func (r *Runner) call(ctx context.Context, fn Func, progress func(int)) (res any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("jobs: job panicked: %v", p)
+		}
+	}()
+	return fn(ctx, progress)
+}
+
+// Get returns the job with id, if it is known and has not expired.
+func (r *Runner) Get(id string) (Job, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+	j, ok := r.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+// prune forgets jobs that finished more than Retention ago. r.mu must be held.
+func (r *Runner) prune() {
+	cutoff := r.now().Add(-r.opts.Retention)
+	for id, j := range r.jobs {
+		if j.FinishedAt != nil && j.FinishedAt.Before(cutoff) {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+// Shutdown stops accepting jobs and waits for the submitted ones to finish. When ctx is
+// done first, the remaining jobs are cancelled and Shutdown returns once they have
+// returned, with ctx's error.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	r.mu.Lock()
+	for _, cancel := range r.cancels {
+		cancel()
+	}
+	r.mu.Unlock()
+	<-done
+	return ctx.Err()
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("jobs: generate id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go-boilerplate/internal/utils/auth"
+)
+
+func waitFor(t *testing.T, r *Runner, id string, status Status) Job {
+	t.Helper()
+	var j Job
+	require.Eventually(t, func() bool {
+		j, _ = r.Get(id)
+		return j.Status == status
+	}, time.Second, time.Millisecond)
+	return j
+}
+
+func TestRunner_RunsJobsInTheBackground(t *testing.T) {
+	r := NewRunner(Options{})
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "alice"}))
+
+	j, err := r.Submit(ctx, "import", "alice", 3, func(ctx context.Context, progress func(int)) (any, error) {
+		progress(2)
+		<-release
+		p, _ := auth.PrincipalFromContext(ctx)
+		return p.Subject, ctx.Err()
+	})
+	require.NoError(t, err)
+	require.Equal(t, StatusQueued, j.Status)
+	cancel() // the request is over; the job keeps going
+
+	require.Eventually(t, func() bool {
+		j, _ = r.Get(j.ID)
+		return j.Done == 2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, StatusRunning, j.Status)
+	close(release)
+
+	j = waitFor(t, r, j.ID, StatusSucceeded)
+	require.Equal(t, "alice", j.Result, "the job keeps the request's values")
+	require.Equal(t, 3, j.Total)
+	require.NotNil(t, j.FinishedAt)
+	require.NoError(t, r.Shutdown(context.Background()))
+}
+
+func TestRunner_RecordsFailuresAndPanics(t *testing.T) {
+	r := NewRunner(Options{})
+	failed, err := r.Submit(context.Background(), "import", "", 1, func(context.Context, func(int)) (any, error) {
+		return nil, errors.New("boom")
+	})
+	require.NoError(t, err)
+	panicked, err := r.Submit(context.Background(), "import", "", 1, func(context.Context, func(int)) (any, error) {
+		panic("oops")
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "boom", waitFor(t, r, failed.ID, StatusFailed).Error)
+	require.Contains(t, waitFor(t, r, panicked.ID, StatusFailed).Error, "oops")
+}
+
+func TestRunner_LimitsQueue(t *testing.T) {
+	r := NewRunner(Options{Workers: 1, MaxQueued: 1})
+	release := make(chan struct{})
+	block := func(context.Context, func(int)) (any, error) { <-release; return nil, nil }
+
+	first, err := r.Submit(context.Background(), "import", "", 1, block)
+	require.NoError(t, err)
+	waitFor(t, r, first.ID, StatusRunning)
+	_, err = r.Submit(context.Background(), "import", "", 1, block)
+	require.NoError(t, err)
+	_, err = r.Submit(context.Background(), "import", "", 1, block)
+	require.ErrorIs(t, err, ErrBusy)
+
+	close(release)
+	require.NoError(t, r.Shutdown(context.Background()))
+	_, err = r.Submit(context.Background(), "import", "", 1, block)
+	require.ErrorIs(t, err, ErrClosed)
+}
+
+func TestRunner_ShutdownCancelsJobsAfterDeadline(t *testing.T) {
+	r := NewRunner(Options{})
+	j, err := r.Submit(context.Background(), "import", "", 1, func(ctx context.Context, _ func(int)) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, r.Shutdown(ctx), context.DeadlineExceeded)
+	j, _ = r.Get(j.ID)
+	require.Equal(t, StatusFailed, j.Status)
+}
+
+func TestRunner_ForgetsFinishedJobsAfterRetention(t *testing.T) {
+	r := NewRunner(Options{Retention: time.Minute})
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	j, err := r.Submit(context.Background(), "import", "", 0, func(context.Context, func(int)) (any, error) { return nil, nil })
+	require.NoError(t, err)
+	waitFor(t, r, j.ID, StatusSucceeded)
+
+	now = now.Add(2 * time.Minute)
+	_, ok := r.Get(j.ID)
+	require.False(t, ok)
+}
@@ -0,0 +1,282 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound is returned when no key matches the requested key ID.
+var ErrKeyNotFound = errors.New("jwks: key not found")
+
+// jsonWebKey is the subset of RFC 7517 fields needed for RSA, EC and HMAC keys.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// oct
+	K string `json:"k"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// KeySet holds public (or shared) keys indexed by key ID.
+// Keys are loaded from a static JWKS file or fetched from a JWKS URL. URL-backed sets are
+// refreshed lazily: once RefreshInterval has passed, or when an unknown key ID is requested
+// (key rotation), but never more often than MinRefreshInterval. When a refresh fails the
+// keys fetched before keep being served, and the next attempt waits MinRefreshInterval.
+type KeySet struct {
+	mu          sync.RWMutex
+	keys        map[string]any
+	fetchedAt   time.Time
+	attemptedAt time.Time  // last fetch, successful or not
+	fetchErr    error      // error of the last fetch, if it failed
+	fetchMu     sync.Mutex // serialises fetches so concurrent misses hit the endpoint once
+
+	url                string
+	client             *http.Client
+	fetchTimeout       time.Duration
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	now                func() time.Time
+}
+
+// Option customises a KeySet.
+type Option func(*KeySet)
+
+// WithHTTPClient sets the client used to fetch a JWKS URL.
+func WithHTTPClient(c *http.Client) Option {
+	return func(k *KeySet) { k.client = c }
+}
+
+// WithRefreshInterval sets how long fetched keys are cached before being re-fetched.
+func WithRefreshInterval(d time.Duration) Option {
+	return func(k *KeySet) { k.refreshInterval = d }
+}
+
+// WithMinRefreshInterval limits how often an unknown key ID may trigger a re-fetch.
+func WithMinRefreshInterval(d time.Duration) Option {
+	return func(k *KeySet) { k.minRefreshInterval = d }
+}
+
+// NewFromFile returns a KeySet backed by a static JWKS file. The file is read once.
+func NewFromFile(path string) (*KeySet, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: read %s: %w", path, err)
+	}
+	keys, err := parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{keys: keys, now: time.Now}, nil
+}
+
+// NewFromURL returns a KeySet backed by a remote JWKS endpoint.
+// Nothing is fetched until the first Key call.
+func NewFromURL(url string, opts ...Option) *KeySet {
+	k := &KeySet{
+		keys:               map[string]any{},
+		url:                url,
+		client:             &http.Client{Timeout: 5 * time.Second},
+		fetchTimeout:       5 * time.Second,
+		refreshInterval:    5 * time.Minute,
+		minRefreshInterval: 10 * time.Second,
+		now:                time.Now,
+	}
+	for _, o := range opts {
+		o(k)
+	}
+	return k
+}
+
+// Key returns the key for kid. An empty kid matches the only key of a single-key set.
+// Fetches are not cancelled with ctx, so one caller giving up does not fail the others.
+func (k *KeySet) Key(ctx context.Context, kid string) (any, error) {
+	if k.url != "" && k.stale() {
+		// A failed refresh is retried later; until then the cached keys are served.
+		_ = k.refresh(ctx, k.stale)
+	}
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	// Unknown kid: the issuer may have rotated its keys since the last fetch.
+	if k.url != "" && k.canRefresh() {
+		if err := k.refresh(ctx, k.canRefresh); err != nil {
+			return nil, err
+		}
+		if key, ok := k.lookup(kid); ok {
+			return key, nil
+		}
+	}
+	if err := k.unavailable(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+}
+
+func (k *KeySet) lookup(kid string) (any, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *KeySet) stale() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	expired := k.fetchedAt.IsZero() || k.now().Sub(k.fetchedAt) >= k.refreshInterval
+	return expired && k.now().Sub(k.attemptedAt) >= k.minRefreshInterval
+}
+
+func (k *KeySet) canRefresh() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.now().Sub(k.attemptedAt) >= k.minRefreshInterval
+}
+
+// unavailable returns the error of the last fetch while no fetch has succeeded yet.
+func (k *KeySet) unavailable() error {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.fetchedAt.IsZero() {
+		return k.fetchErr
+	}
+	return nil
+}
+
+// refresh fetches the key set unless another goroutine already did so while
+// this one waited for fetchMu, as reported by need. The outcome is recorded either way.
+func (k *KeySet) refresh(ctx context.Context, need func() bool) error {
+	k.fetchMu.Lock()
+	defer k.fetchMu.Unlock()
+	if !need() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), k.fetchTimeout)
+	defer cancel()
+	keys, err := k.fetch(ctx)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.attemptedAt, k.fetchErr = k.now(), err
+	if err != nil {
+		return err
+	}
+	k.keys, k.fetchedAt = keys, k.attemptedAt
+	return nil
+}
+
+func (k *KeySet) fetch(ctx context.Context) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	res, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: fetch %s: %w", k.url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: fetch %s: unexpected status %d", k.url, res.StatusCode)
+	}
+	raw, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("jwks: read %s: %w", k.url, err)
+	}
+	return parse(raw)
+}
+
+func parse(raw []byte) (map[string]any, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("jwks: decode: %w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.decode()
+		if err != nil {
+			return nil, fmt.Errorf("jwks: key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (j jsonWebKey) decode() (any, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(j.K)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,135 @@
+package jwks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func rsaJWK(t *testing.T, kid string) (map[string]string, *rsa.PublicKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}, &key.PublicKey
+}
+
+func TestKeySet_URLRotation(t *testing.T) {
+	first, firstPub := rsaJWK(t, "k1")
+	second, secondPub := rsaJWK(t, "k2")
+
+	var served atomic.Value
+	served.Store([]map[string]string{first})
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": served.Load()})
+	}))
+	defer srv.Close()
+
+	ks := NewFromURL(srv.URL, WithMinRefreshInterval(0))
+	ctx := context.Background()
+
+	got, err := ks.Key(ctx, "k1")
+	require.NoError(t, err)
+	require.Equal(t, 0, firstPub.N.Cmp(got.(*rsa.PublicKey).N))
+
+	// Cached: a known kid does not trigger another fetch.
+	_, err = ks.Key(ctx, "k1")
+	require.NoError(t, err)
+	require.Equal(t, int32(1), fetches.Load())
+
+	// The issuer rotates to k2; an unknown kid forces a refresh.
+	served.Store([]map[string]string{second})
+	got, err = ks.Key(ctx, "k2")
+	require.NoError(t, err)
+	require.Equal(t, 0, secondPub.N.Cmp(got.(*rsa.PublicKey).N))
+	require.Equal(t, int32(2), fetches.Load())
+
+	_, err = ks.Key(ctx, "k1")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestKeySet_MinRefreshIntervalLimitsFetches(t *testing.T) {
+	jwk, _ := rsaJWK(t, "k1")
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{jwk}})
+	}))
+	defer srv.Close()
+
+	ks := NewFromURL(srv.URL, WithMinRefreshInterval(time.Hour))
+	for i := 0; i < 5; i++ {
+		_, err := ks.Key(context.Background(), "unknown")
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	}
+	require.Equal(t, int32(1), fetches.Load())
+}
+
+func TestKeySet_ServesCachedKeysWhileRefreshFails(t *testing.T) {
+	jwk, pub := rsaJWK(t, "k1")
+	var down atomic.Bool
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{jwk}})
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	ks := NewFromURL(srv.URL, WithRefreshInterval(time.Minute), WithMinRefreshInterval(10*time.Second))
+	ks.now = func() time.Time { return now }
+
+	_, err := ks.Key(context.Background(), "k1")
+	require.NoError(t, err)
+
+	down.Store(true)
+	now = now.Add(time.Minute)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 3; i++ {
+		got, err := ks.Key(cancelled, "k1")
+		require.NoError(t, err, "the cached key outlives a failed refresh")
+		require.Equal(t, 0, pub.N.Cmp(got.(*rsa.PublicKey).N))
+	}
+	require.Equal(t, int32(2), fetches.Load(), "failed refreshes back off")
+
+	down.Store(false)
+	now = now.Add(10 * time.Second)
+	_, err = ks.Key(cancelled, "k1")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), fetches.Load())
+}
+
+func TestKeySet_ReportsFetchErrorUntilFirstSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	ks := NewFromURL(srv.URL)
+	for i := 0; i < 2; i++ {
+		_, err := ks.Key(context.Background(), "k1")
+		require.ErrorContains(t, err, "unexpected status 502")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"sync"
 
 	"github.com/elastic/go-elasticsearch/v8"
@@ -37,6 +38,9 @@ type ESOpts struct {
 	Password      string
 	FlushBytes    int
 	FlushInterval time.Duration
+	// Level, when set, is the minimum level of every core (stdout and Elasticsearch) and can
+	// be changed at runtime. Defaults to info.
+	Level zap.AtomicLevel
 }
 
 // bulkSink buffers ECS/JSON logs and sends them to Elasticsearch via Bulk API.
@@ -47,49 +51,61 @@ type bulkSink struct {
 	mu       sync.Mutex
 	maxBytes int
 	interval time.Duration
-	ctx      context.Context
-	cancel   context.CancelFunc
 	flushCh  chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
 }
 
+// flushTimeout bounds a single background bulk request.
+const flushTimeout = 10 * time.Second
+
 func newBulkSink(cli *elasticsearch.Client, index string, maxBytes int, interval time.Duration) *bulkSink {
-	ctx, cancel := context.WithCancel(context.Background())
 	bs := &bulkSink{
 		cli:      cli,
 		index:    index,
 		buf:      &bytes.Buffer{},
 		maxBytes: maxBytes,
 		interval: interval,
-		ctx:      ctx,
-		cancel:   cancel,
 		flushCh:  make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 	go bs.loop()
 	return bs
 }
 
 func (b *bulkSink) loop() {
-	ticker := time.NewTicker(b.interval)
-	defer ticker.Stop()
+	defer close(b.done)
+	var tick <-chan time.Time
+	if b.interval > 0 {
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
 	for {
 		select {
-		case <-b.ctx.Done():
-			b.flush()
+		case <-b.stop:
 			return
-		case <-ticker.C:
-			b.flush()
+		case <-tick:
+			b.flushInBackground()
 		case <-b.flushCh:
-			b.flush()
+			b.flushInBackground()
 		}
 	}
 }
 
+func (b *bulkSink) flushInBackground() {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	_ = b.flush(ctx)
+}
+
 func (b *bulkSink) Write(doc json.RawMessage) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	meta := []byte(`{"index":{"_index":"` + b.index + `"}}\n`)
-	b.buf.Write(meta)
-	b.buf.Write(doc)
+	b.buf.WriteString(`{"index":{"_index":"` + b.index + `"}}` + "\n")
+	b.buf.Write(bytes.TrimRight(doc, "\n"))
 	b.buf.WriteByte('\n')
 	if b.buf.Len() >= b.maxBytes {
 		select {
@@ -100,11 +116,13 @@ func (b *bulkSink) Write(doc json.RawMessage) error {
 	return nil
 }
 
-func (b *bulkSink) flush() {
+// flush sends the buffered documents in one bulk request. Documents are dropped if the
+// request fails; logging must not block or grow without bound while Elasticsearch is down.
+func (b *bulkSink) flush(ctx context.Context) error {
 	b.mu.Lock()
 	if b.buf.Len() == 0 {
 		b.mu.Unlock()
-		return
+		return nil
 	}
 	payload := make([]byte, b.buf.Len())
 	copy(payload, b.buf.Bytes())
@@ -112,13 +130,28 @@ func (b *bulkSink) flush() {
 	b.mu.Unlock()
 
 	req := esapi.BulkRequest{Body: bytes.NewReader(payload)}
-	res, err := req.Do(b.ctx, b.cli)
-	if err == nil {
-		res.Body.Close()
+	res, err := req.Do(ctx, b.cli)
+	if err != nil {
+		return fmt.Errorf("elasticsearch bulk: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch bulk: %s", res.Status())
 	}
+	return nil
 }
 
-func (b *bulkSink) Stop() { b.cancel() }
+// Close stops the background flushing and sends whatever is still buffered, giving up when
+// ctx is done. It is safe to call more than once.
+func (b *bulkSink) Close(ctx context.Context) error {
+	b.once.Do(func() { close(b.stop) })
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return b.flush(ctx)
+}
 
 type elasticCore struct {
 	enc  zapcore.Encoder
@@ -136,6 +169,10 @@ func (c *elasticCore) Enabled(l zapcore.Level) bool {
 
 func (c *elasticCore) With(fields []zapcore.Field) zapcore.Core {
 	clone := *c
+	clone.enc = c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
 	return &clone
 }
 
@@ -155,7 +192,11 @@ func (c *elasticCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 	return c.sink.Write(json.RawMessage(buf.Bytes()))
 }
 
-func (c *elasticCore) Sync() error { return nil }
+func (c *elasticCore) Sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	return c.sink.flush(ctx)
+}
 
 // --- structured logger helpers ---
 
@@ -200,8 +241,9 @@ func levelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
 // The serviceName is used to tag the logs, and tzName specifies the timezone for timestamps.
 // The ESOpts struct contains configuration options for Elasticsearch logging, including addresses,
 // index, authentication details, and buffering settings.
-// It returns the configured logger, a stopper function to clean up resources, and an error if any.
-func NewWithElastic(serviceName string, tzName string, es ESOpts) (*zap.Logger, func(), error) {
+// It returns the configured logger, a stopper that flushes buffered logs to Elasticsearch
+// until its context is done, and an error if any. Call the stopper once, after the last log.
+func NewWithElastic(serviceName string, tzName string, es ESOpts) (*zap.Logger, func(context.Context) error, error) {
 	if tzName == "" {
 		tzName = "UTC"
 	}
@@ -224,14 +266,19 @@ func NewWithElastic(serviceName string, tzName string, es ESOpts) (*zap.Logger,
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
+	level := es.Level
+	if level == (zap.AtomicLevel{}) {
+		level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	}
+
 	stdoutCore := zapcore.NewCore(
 		zapcore.NewJSONEncoder(encCfg),
 		zapcore.AddSync(os.Stdout),
-		zap.NewAtomicLevelAt(zapcore.InfoLevel),
+		level,
 	)
 
 	cores := []zapcore.Core{stdoutCore}
-	stopper := func() {}
+	stopper := func(context.Context) error { return nil }
 
 	if es.Enabled {
 		cfg := elasticsearch.Config{Addresses: es.Addresses}
@@ -243,9 +290,9 @@ func NewWithElastic(serviceName string, tzName string, es ESOpts) (*zap.Logger,
 		cli, err := elasticsearch.NewClient(cfg)
 		if err == nil {
 			sink := newBulkSink(cli, es.Index, es.FlushBytes, es.FlushInterval)
-			esCore := newElasticCore(zapcore.NewJSONEncoder(encCfg), sink, zap.NewAtomicLevelAt(zapcore.InfoLevel))
+			esCore := newElasticCore(zapcore.NewJSONEncoder(encCfg), sink, level)
 			cores = append(cores, esCore)
-			stopper = func() { sink.Stop() }
+			stopper = sink.Close
 		}
 	}
 
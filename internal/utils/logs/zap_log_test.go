@@ -0,0 +1,127 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeElastic records the documents sent to the Bulk API.
+type fakeElastic struct {
+	*httptest.Server
+	mu   sync.Mutex
+	docs []map[string]any
+}
+
+func newFakeElastic(t *testing.T) *fakeElastic {
+	t.Helper()
+	f := &fakeElastic{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		if r.URL.Path != "/_bulk" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		sc := bufio.NewScanner(strings.NewReader(string(body)))
+		f.mu.Lock()
+		for sc.Scan() {
+			var doc map[string]any
+			if err := json.Unmarshal(sc.Bytes(), &doc); err != nil {
+				t.Errorf("invalid bulk line %q: %v", sc.Text(), err)
+				continue
+			}
+			if _, isMeta := doc["index"]; !isMeta {
+				f.docs = append(f.docs, doc)
+			}
+		}
+		f.mu.Unlock()
+		_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeElastic) messages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for _, d := range f.docs {
+		out = append(out, d["message"].(string))
+	}
+	return out
+}
+
+func TestNewWithElastic_StopFlushesBufferedLogs(t *testing.T) {
+	es := newFakeElastic(t)
+	logger, stop, err := NewWithElastic("svc", "UTC", ESOpts{
+		Enabled:       true,
+		Addresses:     []string{es.URL},
+		Index:         "logs",
+		FlushBytes:    1 << 20,
+		FlushInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	logger.Info("first")
+	logger.With().Named("child").Warn("second")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, stop(ctx))
+	require.Equal(t, []string{"first", "second"}, es.messages())
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	require.Equal(t, "svc", es.docs[0]["service_name"], "fields added with With must reach Elasticsearch")
+}
+
+func TestNewWithElastic_FlushesWhenBufferIsFull(t *testing.T) {
+	es := newFakeElastic(t)
+	logger, stop, err := NewWithElastic("svc", "UTC", ESOpts{
+		Enabled:       true,
+		Addresses:     []string{es.URL},
+		Index:         "logs",
+		FlushBytes:    1,
+		FlushInterval: 0,
+	})
+	require.NoError(t, err)
+	defer func() { _ = stop(context.Background()) }()
+
+	logger.Info("hello")
+	require.Eventually(t, func() bool { return len(es.messages()) == 1 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestNewWithElastic_StopHonoursDeadline(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	logger, stop, err := NewWithElastic("svc", "UTC", ESOpts{
+		Enabled:       true,
+		Addresses:     []string{srv.URL},
+		Index:         "logs",
+		FlushBytes:    1 << 20,
+		FlushInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	logger.Info("stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	require.Error(t, stop(ctx))
+	require.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,147 @@
+// Package metrics provides labelled counters and histograms published through expvar, so
+// they are served as JSON by expvar.Handler (GET /debug/vars).
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DurationBuckets are histogram upper bounds, in seconds, suited to network calls and
+// database queries.
+var DurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// CounterVec is a set of counters keyed by label.
+type CounterVec struct {
+	m *expvar.Map
+}
+
+// NewCounterVec publishes a CounterVec under name. Calling it again with the same name
+// returns the already published counters.
+func NewCounterVec(name string) *CounterVec {
+	return &CounterVec{m: publish(name, func() *expvar.Map { return new(expvar.Map).Init() })}
+}
+
+// Inc adds one to the counter for label.
+func (c *CounterVec) Inc(label string) {
+	c.m.Add(label, 1)
+}
+
+// Value returns the count for label.
+func (c *CounterVec) Value(label string) int64 {
+	if v, ok := c.m.Get(label).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// HistogramVec is a set of histograms with the same buckets, keyed by label.
+type HistogramVec struct {
+	buckets []float64
+	m       *expvar.Map
+	mu      sync.Mutex // serialises creating histograms
+}
+
+// NewHistogramVec publishes a HistogramVec under name with the given ascending upper
+// bounds. Calling it again with the same name returns the already published histograms.
+func NewHistogramVec(name string, buckets []float64) *HistogramVec {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &HistogramVec{buckets: b, m: publish(name, func() *expvar.Map { return new(expvar.Map).Init() })}
+}
+
+// Observe records v in the histogram for label.
+func (h *HistogramVec) Observe(label string, v float64) {
+	h.get(label).Observe(v)
+}
+
+// Snapshot returns the current state of the histogram for label.
+func (h *HistogramVec) Snapshot(label string) HistogramSnapshot {
+	if hist, ok := h.m.Get(label).(*Histogram); ok {
+		return hist.Snapshot()
+	}
+	return HistogramSnapshot{}
+}
+
+func (h *HistogramVec) get(label string) *Histogram {
+	if hist, ok := h.m.Get(label).(*Histogram); ok {
+		return hist
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hist, ok := h.m.Get(label).(*Histogram); ok {
+		return hist
+	}
+	hist := NewHistogram(h.buckets)
+	h.m.Set(label, hist)
+	return hist
+}
+
+// Histogram counts observations into cumulative buckets. It implements expvar.Var.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// NewHistogram returns a Histogram with the given ascending upper bounds.
+func NewHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe records v.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, le := range h.buckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// HistogramSnapshot is the state of a Histogram. Buckets maps each upper bound, formatted
+// with %g, to the number of observations less than or equal to it.
+type HistogramSnapshot struct {
+	Count   uint64            `json:"count"`
+	Sum     float64           `json:"sum"`
+	Buckets map[string]uint64 `json:"buckets"`
+}
+
+// Snapshot returns the current counts.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := HistogramSnapshot{Count: h.count, Sum: h.sum, Buckets: make(map[string]uint64, len(h.buckets))}
+	for i, le := range h.buckets {
+		s.Buckets[fmt.Sprintf("%g", le)] = h.counts[i]
+	}
+	return s
+}
+
+// String implements expvar.Var.
+func (h *Histogram) String() string {
+	b, _ := json.Marshal(h.Snapshot())
+	return string(b)
+}
+
+var publishMu sync.Mutex
+
+// publish returns the expvar published as name, publishing a new one if there is none.
+func publish[T expvar.Var](name string, newVar func() T) T {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+	if v, ok := expvar.Get(name).(T); ok {
+		return v
+	}
+	v := newVar()
+	expvar.Publish(name, v)
+	return v
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("test_counter")
+	c.Inc("ok")
+	c.Inc("ok")
+	c.Inc("error")
+
+	// Re-registering returns the same counters instead of panicking.
+	again := NewCounterVec("test_counter")
+	require.Equal(t, int64(2), again.Value("ok"))
+	require.Equal(t, int64(1), again.Value("error"))
+	require.Equal(t, int64(0), again.Value("missing"))
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_histogram", []float64{1, 0.1})
+	h.Observe("select", 0.05)
+	h.Observe("select", 0.5)
+	h.Observe("select", 5)
+
+	s := h.Snapshot("select")
+	require.Equal(t, uint64(3), s.Count)
+	require.InDelta(t, 5.55, s.Sum, 1e-9)
+	require.Equal(t, map[string]uint64{"0.1": 1, "1": 2}, s.Buckets)
+
+	// The published value is JSON, as served by /debug/vars.
+	var published map[string]HistogramSnapshot
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("test_histogram").String()), &published))
+	require.Equal(t, s, published["select"])
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// fallbackRetryInterval is how often a failing primary store is tried again.
+const fallbackRetryInterval = time.Second
+
+// FallbackStore consumes tokens from a primary store, usually a RedisStore shared by all
+// replicas, and from a fallback store while the primary fails, so limits still hold per
+// instance during an outage. The primary is tried again every second and used again as
+// soon as it answers.
+type FallbackStore struct {
+	primary  Store
+	fallback Store
+	onSwitch func(err error)
+	now      func() time.Time
+
+	mu        sync.Mutex
+	failing   bool
+	nextRetry time.Time
+}
+
+// NewFallbackStore creates a FallbackStore. onSwitch, if not nil, is called with the
+// primary's error when the fallback takes over, and with nil when the primary is back.
+func NewFallbackStore(primary, fallback Store, onSwitch func(err error)) *FallbackStore {
+	if onSwitch == nil {
+		onSwitch = func(error) {}
+	}
+	return &FallbackStore{primary: primary, fallback: fallback, onSwitch: onSwitch, now: time.Now}
+}
+
+// Allow consumes one token for key.
+func (s *FallbackStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	skip := s.failing && s.now().Before(s.nextRetry)
+	s.mu.Unlock()
+	if skip {
+		return s.fallback.Allow(ctx, key, limit)
+	}
+
+	res, err := s.primary.Allow(ctx, key, limit)
+	s.mu.Lock()
+	switched := s.failing != (err != nil)
+	s.failing = err != nil
+	s.nextRetry = s.now().Add(fallbackRetryInterval)
+	s.mu.Unlock()
+	if switched {
+		s.onSwitch(err)
+	}
+	if err != nil {
+		return s.fallback.Allow(ctx, key, limit)
+	}
+	return res, nil
+}
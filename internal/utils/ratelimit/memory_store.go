@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many Allow calls pass between sweeps of idle keys.
+const sweepEvery = 1024
+
+// MemoryStore keeps buckets in process memory. Limits are per instance, so it suits
+// single-replica deployments and tests.
+type MemoryStore struct {
+	mu    sync.Mutex
+	tats  map[string]time.Time
+	calls int
+	now   func() time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tats: map[string]time.Time{}, now: time.Now}
+}
+
+// Allow consumes one token for key.
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.calls++
+	if s.calls%sweepEvery == 0 {
+		// A bucket whose tat has passed is full again and can be forgotten.
+		for k, tat := range s.tats {
+			if tat.Before(now) {
+				delete(s.tats, k)
+			}
+		}
+	}
+
+	res, tat := gcra(now, s.tats[key], limit)
+	if res.Allowed {
+		s.tats[key] = tat
+	}
+	return res, nil
+}
@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Window, with bursts of up to Requests.
+// It is enforced as a token bucket using the generic cell rate algorithm (GCRA):
+// the bucket holds Requests tokens and refills one token every Window/Requests.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Window > 0
+}
+
+// Validate reports an error unless Requests and Window are positive and Window holds at
+// least one microsecond per request, the resolution at which the stores refill tokens.
+func (l Limit) Validate() error {
+	switch {
+	case l.Requests <= 0:
+		return fmt.Errorf("ratelimit: %s: request count must be positive", l)
+	case l.Window <= 0:
+		return fmt.Errorf("ratelimit: %s: window must be positive", l)
+	case l.Window < time.Duration(l.Requests)*time.Microsecond:
+		return fmt.Errorf("ratelimit: %s: more than one request per microsecond", l)
+	}
+	return nil
+}
+
+// interval is the time it takes to refill one token. It is at least 1µs, so that a limit
+// that did not pass Validate still limits rather than dividing by zero.
+func (l Limit) interval() time.Duration {
+	return max(l.Window/time.Duration(l.Requests), time.Microsecond)
+}
+
+// String formats the limit as "<requests>/<window>", e.g. "100/1m0s".
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// ParseLimit parses "<requests>/<window>" where window is a Go duration, e.g. "100/1m".
+func ParseLimit(s string) (Limit, error) {
+	n, w, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("ratelimit: invalid limit %q: expected <requests>/<window>", s)
+	}
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid request count in %q", s)
+	}
+	window, err := time.ParseDuration(w)
+	if err != nil || window <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid window in %q", s)
+	}
+	l := Limit{Requests: requests, Window: window}
+	if err := l.Validate(); err != nil {
+		return Limit{}, err
+	}
+	return l, nil
+}
+
+// Result is the outcome of consuming one token.
+type Result struct {
+	Allowed bool
+	// Limit is the bucket size (Limit.Requests).
+	Limit int
+	// Remaining is the number of requests that may still be made immediately.
+	Remaining int
+	// ResetAfter is the time until the bucket is full again.
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request would be allowed; zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// Store consumes tokens for a key. Implementations must be safe for concurrent use.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// gcra applies one request at now to a bucket whose theoretical arrival time is tat
+// (zero for an unseen key). It returns the result and the new tat to persist when allowed.
+func gcra(now, tat time.Time, limit Limit) (Result, time.Time) {
+	interval := limit.interval()
+	burst := time.Duration(limit.Requests) * interval
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(interval)
+	allowAt := newTat.Add(-burst)
+
+	if now.Before(allowAt) {
+		return Result{
+			Allowed:    false,
+			Limit:      limit.Requests,
+			Remaining:  0,
+			ResetAfter: tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}, tat
+	}
+	return Result{
+		Allowed:    true,
+		Limit:      limit.Requests,
+		Remaining:  int((burst - newTat.Sub(now)) / interval),
+		ResetAfter: newTat.Sub(now),
+	}, newTat
+}
@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// storesUnderTest returns each Store implementation wired to the same fake clock.
+func storesUnderTest(t *testing.T, clock *fakeClock) map[string]Store {
+	t.Helper()
+	mem := NewMemoryStore()
+	mem.now = clock.now
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	rs := NewRedisStore(client, "rl:")
+	rs.now = clock.now
+
+	return map[string]Store{"memory": mem, "redis": rs}
+}
+
+func TestStores_BurstThenRefill(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	limit := Limit{Requests: 3, Window: 3 * time.Second}
+
+	for name, store := range storesUnderTest(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			key := "client-" + name
+
+			for want := 2; want >= 0; want-- {
+				res, err := store.Allow(ctx, key, limit)
+				require.NoError(t, err)
+				require.True(t, res.Allowed)
+				require.Equal(t, want, res.Remaining)
+				require.Equal(t, 3, res.Limit)
+			}
+
+			res, err := store.Allow(ctx, key, limit)
+			require.NoError(t, err)
+			require.False(t, res.Allowed)
+			require.Equal(t, 0, res.Remaining)
+			require.Equal(t, time.Second, res.RetryAfter)
+
+			// One token refills per second.
+			clock.advance(time.Second)
+			res, err = store.Allow(ctx, key, limit)
+			require.NoError(t, err)
+			require.True(t, res.Allowed)
+			require.Equal(t, 0, res.Remaining)
+
+			// Other keys have their own bucket.
+			res, err = store.Allow(ctx, "other-"+name, limit)
+			require.NoError(t, err)
+			require.True(t, res.Allowed)
+			require.Equal(t, 2, res.Remaining)
+		})
+	}
+}
+
+func TestStores_TooFineLimitStillLimits(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	limit := Limit{Requests: 3, Window: time.Nanosecond} // refills faster than the clock resolution
+	require.Error(t, limit.Validate())
+
+	for name, store := range storesUnderTest(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for want := 2; want >= 0; want-- {
+				res, err := store.Allow(ctx, "fine-"+name, limit)
+				require.NoError(t, err)
+				require.True(t, res.Allowed)
+				require.Equal(t, want, res.Remaining)
+			}
+			res, err := store.Allow(ctx, "fine-"+name, limit)
+			require.NoError(t, err)
+			require.False(t, res.Allowed)
+			require.Equal(t, time.Microsecond, res.RetryAfter)
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	l, err := ParseLimit("100/1m")
+	require.NoError(t, err)
+	require.Equal(t, Limit{Requests: 100, Window: time.Minute}, l)
+
+	for _, bad := range []string{"", "100", "x/1m", "0/1m", "10/abc", "10/-1s", "1001/1ms", "5/1ns"} {
+		_, err := ParseLimit(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestFallbackStore_UsesFallbackWhilePrimaryIsDown(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	primary := NewRedisStore(client, "rl:")
+	primary.now = clock.now
+	fallback := NewMemoryStore()
+	fallback.now = clock.now
+
+	var switches []error
+	s := NewFallbackStore(primary, fallback, func(err error) { switches = append(switches, err) })
+	s.now = clock.now
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Window: time.Minute}
+
+	res, err := s.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Remaining)
+	require.Empty(t, switches)
+
+	mr.Close()
+	for i := 0; i < 2; i++ {
+		res, err = s.Allow(ctx, "k", limit)
+		require.NoError(t, err)
+		require.True(t, res.Allowed)
+	}
+	res, err = s.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed, "the fallback still limits")
+	require.Len(t, switches, 1)
+	require.Error(t, switches[0])
+
+	require.NoError(t, mr.Restart())
+	res, err = s.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed, "the primary is not retried within a second")
+
+	clock.advance(fallbackRetryInterval)
+	res, err = s.Allow(ctx, "k", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed, "the primary is back")
+	require.Len(t, switches, 2)
+	require.NoError(t, switches[1])
+}
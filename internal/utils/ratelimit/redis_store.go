@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript applies GCRA atomically on the Redis side.
+// KEYS[1] = bucket key; ARGV = now, interval, burst (all in microseconds).
+// Returns {allowed, remaining, reset_after_us, retry_after_us}.
+var gcraScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call('GET', KEYS[1])) or now
+if tat < now then
+  tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - burst
+
+if now < allow_at then
+  return {0, 0, tat - now, allow_at - now}
+end
+
+redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((burst - (new_tat - now)) / interval), new_tat - now, 0}
+`)
+
+// RedisStore keeps buckets in Redis (or any server speaking the Redis protocol with Lua
+// scripting), so limits are shared across all replicas.
+// The current time is taken from the calling instance; keep clocks in sync with NTP.
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore creates a RedisStore. Keys are namespaced with prefix (e.g. "ratelimit:").
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, now: time.Now}
+}
+
+// Allow consumes one token for key.
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	interval := limit.interval()
+	burst := time.Duration(limit.Requests) * interval
+
+	vals, err := gcraScript.Run(ctx, s.client, []string{s.prefix + key},
+		s.now().UnixMicro(), interval.Microseconds(), burst.Microseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: redis: %w", err)
+	}
+	if len(vals) != 4 {
+		return Result{}, fmt.Errorf("ratelimit: redis: unexpected reply %v", vals)
+	}
+	return Result{
+		Allowed:    vals[0] == 1,
+		Limit:      limit.Requests,
+		Remaining:  int(vals[1]),
+		ResetAfter: time.Duration(vals[2]) * time.Microsecond,
+		RetryAfter: time.Duration(vals[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// RefScheme prefixes config values that must be resolved through a Provider,
+// e.g. DB_PASSWORD=secret://db/password.
+const RefScheme = "secret://"
+
+// ErrNotFound is returned when a provider has no secret at the requested path.
+var ErrNotFound = errors.New("secrets: not found")
+
+// Provider resolves secret references. ref is the part after "secret://"; its last path
+// element names the field within the secret, e.g. "db/password" is field "password" of
+// secret "db".
+type Provider interface {
+	Secret(ctx context.Context, ref string) (string, error)
+}
+
+// IsRef reports whether v is a secret reference.
+func IsRef(v string) bool {
+	return strings.HasPrefix(v, RefScheme)
+}
+
+// splitRef splits "db/password" into secret path "db" and field "password".
+func splitRef(ref string) (secretPath, field string, err error) {
+	ref = strings.Trim(ref, "/")
+	i := strings.LastIndex(ref, "/")
+	if i <= 0 || i == len(ref)-1 {
+		return "", "", fmt.Errorf("secrets: invalid reference %q: expected <path>/<field>", ref)
+	}
+	return ref[:i], ref[i+1:], nil
+}
+
+// FileVault reads secrets from a directory tree: reference "db/password" is the file
+// <Dir>/db/password. It suits mounted Kubernetes or Docker secret volumes.
+type FileVault struct {
+	Dir string
+}
+
+// NewFileVault returns a FileVault rooted at dir.
+func NewFileVault(dir string) *FileVault {
+	return &FileVault{Dir: dir}
+}
+
+// Secret returns the file content with surrounding whitespace trimmed.
+func (v *FileVault) Secret(_ context.Context, ref string) (string, error) {
+	clean := path.Clean("/" + ref)
+	if clean == "/" {
+		return "", fmt.Errorf("secrets: invalid reference %q", ref)
+	}
+	raw, err := os.ReadFile(filepath.Join(v.Dir, filepath.FromSlash(clean)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+		}
+		return "", fmt.Errorf("secrets: read %s: %w", ref, err)
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
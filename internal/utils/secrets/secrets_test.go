@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileVault(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "db"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db", "password"), []byte("s3cret\n"), 0o600))
+
+	v := NewFileVault(dir)
+	got, err := v.Secret(context.Background(), "db/password")
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", got)
+
+	_, err = v.Secret(context.Background(), "db/missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// References cannot escape the vault directory.
+	_, err = v.Secret(context.Background(), "../../etc/passwd")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func fakeVault(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/data/app/db":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"pw","port":5432},"metadata":{"version":3}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := fakeVault(t)
+	defer srv.Close()
+
+	p := NewVaultProvider(srv.URL, "root", WithVaultMount("kv"), WithVaultHTTPClient(srv.Client()))
+	ctx := context.Background()
+
+	got, err := p.Secret(ctx, "app/db/password")
+	require.NoError(t, err)
+	require.Equal(t, "pw", got)
+
+	_, err = p.Secret(ctx, "app/db/user")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = p.Secret(ctx, "app/other/password")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = p.Secret(ctx, "app/db/port")
+	require.ErrorContains(t, err, "not a string")
+
+	_, err = p.Secret(ctx, "password")
+	require.ErrorContains(t, err, "invalid reference")
+
+	_, err = NewVaultProvider(srv.URL, "wrong", WithVaultMount("kv")).Secret(ctx, "app/db/password")
+	require.ErrorContains(t, err, "403")
+}
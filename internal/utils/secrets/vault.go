@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads secrets from a HashiCorp Vault compatible KV version 2 engine over
+// HTTP. Reference "db/password" reads field "password" of <Mount>/data/db.
+type VaultProvider struct {
+	addr   string
+	token  string
+	mount  string
+	client *http.Client
+}
+
+// VaultOption customises a VaultProvider.
+type VaultOption func(*VaultProvider)
+
+// WithVaultHTTPClient sets the client used to call Vault.
+func WithVaultHTTPClient(c *http.Client) VaultOption {
+	return func(p *VaultProvider) { p.client = c }
+}
+
+// WithVaultMount sets the KV v2 mount path (default "secret").
+func WithVaultMount(mount string) VaultOption {
+	return func(p *VaultProvider) { p.mount = strings.Trim(mount, "/") }
+}
+
+// NewVaultProvider returns a provider for the Vault server at addr, authenticating with token.
+func NewVaultProvider(addr, token string, opts ...VaultOption) *VaultProvider {
+	p := &VaultProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		mount:  "secret",
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+// Secret fetches the latest version of the secret and returns the requested field.
+func (p *VaultProvider) Secret(ctx context.Context, ref string) (string, error) {
+	secretPath, field, err := splitRef(ref)
+	if err != nil {
+		return "", err
+	}
+	u := p.addr + "/v1/" + p.mount + "/data/" + (&url.URL{Path: secretPath}).EscapedPath()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("secrets: vault request for %s: %w", ref, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("secrets: vault returned %s for %s", resp.Status, ref)
+	}
+
+	var body vaultKVResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("secrets: decode vault response for %s: %w", ref, err)
+	}
+	v, ok := body.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("secrets: field %s is not a string", ref)
+	}
+	return s, nil
+}
@@ -3,6 +3,8 @@ package validation
 import (
 	"sync"
 
+	"go-boilerplate/internal/entities"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -11,9 +13,37 @@ var (
 	once     sync.Once
 )
 
+// GetValidator returns the shared validator. On top of the built-in tags it validates
+// every entities.Money it meets (the currency must be supported) and provides:
+//
+//	currency        a string holding a supported ISO 4217 code
+//	money_positive  a Money above zero
+//	money_nonneg    a Money of zero or more
 func GetValidator() *validator.Validate {
 	once.Do(func() {
 		validate = validator.New()
+		register(validate)
 	})
 	return validate
 }
+
+func register(v *validator.Validate) {
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		m := sl.Current().Interface().(entities.Money)
+		if _, ok := entities.CurrencyExponent(m.Currency); !ok {
+			sl.ReportError(m.Currency, "Currency", "Currency", "currency", "")
+		}
+	}, entities.Money{})
+	_ = v.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		_, ok := entities.CurrencyExponent(fl.Field().String())
+		return ok
+	})
+	_ = v.RegisterValidation("money_positive", func(fl validator.FieldLevel) bool {
+		m, ok := fl.Field().Interface().(entities.Money)
+		return ok && m.Minor > 0
+	})
+	_ = v.RegisterValidation("money_nonneg", func(fl validator.FieldLevel) bool {
+		m, ok := fl.Field().Interface().(entities.Money)
+		return ok && m.Minor >= 0
+	})
+}
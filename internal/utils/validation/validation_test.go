@@ -1,6 +1,10 @@
 package validation
 
-import "testing"
+import (
+    "testing"
+
+    "go-boilerplate/internal/entities"
+)
 
 func TestGetValidatorSingleton(t *testing.T) {
     v1 := GetValidator()
@@ -8,4 +12,27 @@ func TestGetValidatorSingleton(t *testing.T) {
     if v1 == nil || v2 == nil || v1 != v2 {
         t.Fatalf("GetValidator should return a singleton instance")
     }
-}
\ No newline at end of file
+}
+
+func TestMoneyValidation(t *testing.T) {
+    type order struct {
+        Total    entities.Money `validate:"money_positive"`
+        Tip      entities.Money `validate:"money_nonneg"`
+        Currency string         `validate:"currency"`
+    }
+    v := GetValidator()
+    ok := order{Total: entities.Money{Minor: 1, Currency: "USD"}, Tip: entities.Money{Currency: "USD"}, Currency: "EUR"}
+    if err := v.Struct(ok); err != nil {
+        t.Fatalf("valid order rejected: %v", err)
+    }
+    for name, o := range map[string]order{
+        "zero total":       {Total: entities.Money{Currency: "USD"}, Tip: ok.Tip, Currency: "EUR"},
+        "negative tip":     {Total: ok.Total, Tip: entities.Money{Minor: -1, Currency: "USD"}, Currency: "EUR"},
+        "unknown code":     {Total: ok.Total, Tip: ok.Tip, Currency: "XYZ"},
+        "unknown currency": {Total: entities.Money{Minor: 1, Currency: "XYZ"}, Tip: ok.Tip, Currency: "EUR"},
+    } {
+        if err := v.Struct(o); err == nil {
+            t.Errorf("%s: expected a validation error", name)
+        }
+    }
+}
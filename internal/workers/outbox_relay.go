@@ -0,0 +1,148 @@
+package workers
+
+import (
+	"context"
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/repositories"
+	"go-boilerplate/internal/transports/rabbit"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EventPublisher publishes one message and returns once the broker has confirmed it.
+// *rabbit.Publisher implements it.
+type EventPublisher = rabbit.MessagePublisher
+
+// OutboxRelayOptions tunes the relay loop.
+type OutboxRelayOptions struct {
+	PollInterval   time.Duration
+	BatchSize      int
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// Retention is how long sent events are kept before being pruned.
+	Retention     time.Duration
+	PruneInterval time.Duration
+}
+
+// OutboxRelay publishes pending outbox events to RabbitMQ. Failed publishes are retried with
+// exponential backoff until MaxAttempts, after which the event is marked failed. Several
+// relays may run concurrently; each batch is locked with SKIP LOCKED.
+type OutboxRelay struct {
+	repo repositories.OutboxRepository
+	pub  EventPublisher
+	log  *zap.Logger
+	opts OutboxRelayOptions
+	now  func() time.Time
+}
+
+// NewOutboxRelay creates a relay reading from repo and publishing through pub.
+func NewOutboxRelay(repo repositories.OutboxRepository, pub EventPublisher, log *zap.Logger, opts OutboxRelayOptions) *OutboxRelay {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.RetryBaseDelay <= 0 {
+		opts.RetryBaseDelay = time.Second
+	}
+	if opts.RetryMaxDelay <= 0 {
+		opts.RetryMaxDelay = 10 * time.Minute
+	}
+	if opts.PruneInterval <= 0 {
+		opts.PruneInterval = time.Hour
+	}
+	return &OutboxRelay{repo: repo, pub: pub, log: log, opts: opts, now: time.Now}
+}
+
+// Run relays events until ctx is done. A full batch is followed immediately by the next one;
+// otherwise the relay sleeps for PollInterval.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	r.log.Info("outbox relay started", zap.Duration("poll_interval", r.opts.PollInterval), zap.Int("batch_size", r.opts.BatchSize))
+	lastPrune := time.Time{}
+	for {
+		n, err := r.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.log.Error("outbox relay batch failed", zap.Error(err))
+		}
+
+		if r.opts.Retention > 0 && r.now().Sub(lastPrune) >= r.opts.PruneInterval {
+			lastPrune = r.now()
+			if pruned, err := r.repo.Prune(ctx, lastPrune.Add(-r.opts.Retention).UTC()); err != nil {
+				r.log.Warn("outbox prune failed", zap.Error(err))
+			} else if pruned > 0 {
+				r.log.Info("outbox pruned", zap.Int64("deleted", pruned))
+			}
+		}
+
+		wait := r.opts.PollInterval
+		if err == nil && n == r.opts.BatchSize {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			r.log.Info("outbox relay stopped")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// RunOnce publishes a single batch of due events and returns how many were processed.
+func (r *OutboxRelay) RunOnce(ctx context.Context) (int, error) {
+	return r.repo.ProcessBatch(ctx, r.now().UTC(), r.opts.BatchSize, func(events []entities.OutboxEvent) {
+		for i := range events {
+			r.publish(ctx, &events[i])
+		}
+	})
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, e *entities.OutboxEvent) {
+	id := strconv.FormatInt(e.ID, 10)
+	err := r.pub.Publish(ctx, rabbit.Message{
+		RoutingKey: e.RoutingKey,
+		Body:       e.Payload,
+		MessageID:  id,
+		Type:       e.EventType,
+		Headers: map[string]any{
+			"event_id":       id,
+			"event_type":     e.EventType,
+			"aggregate_type": e.AggregateType,
+			"aggregate_id":   e.AggregateID,
+		},
+	})
+	now := r.now().UTC()
+	e.Attempts++
+	if err == nil {
+		e.Status = entities.OutboxStatusSent
+		e.SentAt = &now
+		e.LastError = ""
+		e.NextAttemptAt = now
+		return
+	}
+
+	e.LastError = err.Error()
+	if e.Attempts >= r.opts.MaxAttempts {
+		e.Status = entities.OutboxStatusFailed
+		e.NextAttemptAt = now
+		r.log.Error("outbox event failed permanently", zap.Int64("event_id", e.ID), zap.String("event_type", e.EventType), zap.Int("attempts", e.Attempts), zap.Error(err))
+		return
+	}
+	e.NextAttemptAt = now.Add(r.backoff(e.Attempts))
+	r.log.Warn("outbox event publish failed, will retry", zap.Int64("event_id", e.ID), zap.Int("attempts", e.Attempts), zap.Time("next_attempt_at", e.NextAttemptAt), zap.Error(err))
+}
+
+// backoff returns RetryBaseDelay * 2^(attempts-1), capped at RetryMaxDelay.
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	d := r.opts.RetryBaseDelay
+	for i := 1; i < attempts && d < r.opts.RetryMaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, r.opts.RetryMaxDelay)
+}
@@ -0,0 +1,79 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-boilerplate/internal/entities"
+	"go-boilerplate/internal/repositories/_mock"
+	"go-boilerplate/internal/transports/rabbit"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// batchOf returns a ProcessBatchFunc that hands events to the relay and captures the
+// state it asks the repository to persist.
+func batchOf(events []entities.OutboxEvent, persisted *[]entities.OutboxEvent) func(context.Context, time.Time, int, func([]entities.OutboxEvent)) (int, error) {
+	return func(ctx context.Context, now time.Time, limit int, fn func([]entities.OutboxEvent)) (int, error) {
+		batch := append([]entities.OutboxEvent(nil), events...)
+		fn(batch)
+		*persisted = batch
+		return len(batch), nil
+	}
+}
+
+func TestOutboxRelay_RunOnce(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []entities.OutboxEvent{
+		{ID: 1, EventType: "example.created", RoutingKey: "example.created", Status: entities.OutboxStatusPending, AggregateID: "10", Payload: []byte(`{"id":"10"}`)},
+		{ID: 2, EventType: "example.created", RoutingKey: "example.created", Status: entities.OutboxStatusPending, AggregateID: "11", Attempts: 2},
+		{ID: 3, EventType: "example.created", RoutingKey: "example.created", Status: entities.OutboxStatusPending, AggregateID: "12", Attempts: 4},
+	}
+	var persisted []entities.OutboxEvent
+	repo := &_mock.MockOutboxRepository{ProcessBatchFunc: batchOf(events, &persisted)}
+	pub := rabbit.NewFakePublisher()
+	pub.Err = func(msg rabbit.Message) error {
+		if msg.MessageID != "1" {
+			return errors.New("nack")
+		}
+		return nil
+	}
+
+	relay := NewOutboxRelay(repo, pub, zap.NewNop(), OutboxRelayOptions{MaxAttempts: 5, RetryBaseDelay: time.Second})
+	relay.now = func() time.Time { return now }
+
+	n, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	require.Len(t, pub.Messages(), 1)
+	msg := pub.Messages()[0]
+	require.Equal(t, "1", msg.MessageID)
+	require.Equal(t, "example.created", msg.RoutingKey)
+	require.Equal(t, "10", msg.Headers["aggregate_id"])
+
+	sent, retry, dead := persisted[0], persisted[1], persisted[2]
+	require.Equal(t, entities.OutboxStatusSent, sent.Status)
+	require.Equal(t, now, *sent.SentAt)
+
+	require.Equal(t, entities.OutboxStatusPending, retry.Status)
+	require.Equal(t, 3, retry.Attempts)
+	require.Equal(t, now.Add(4*time.Second), retry.NextAttemptAt)
+	require.Equal(t, "nack", retry.LastError)
+
+	require.Equal(t, entities.OutboxStatusFailed, dead.Status)
+	require.Equal(t, 5, dead.Attempts)
+}
+
+func TestOutboxRelay_BackoffIsCapped(t *testing.T) {
+	relay := NewOutboxRelay(&_mock.MockOutboxRepository{}, rabbit.NewFakePublisher(), zap.NewNop(), OutboxRelayOptions{
+		RetryBaseDelay: time.Second,
+		RetryMaxDelay:  time.Minute,
+	})
+	require.Equal(t, time.Second, relay.backoff(1))
+	require.Equal(t, 8*time.Second, relay.backoff(4))
+	require.Equal(t, time.Minute, relay.backoff(30))
+}